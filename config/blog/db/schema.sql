@@ -24,33 +24,359 @@ CREATE TABLE `sessions` (
   UNIQUE KEY (`key`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
 
+-- AtomPub API でユーザーを認証するための API キー. WSSE 認証でダイジェストを計算するため, ハッシュにせずに保存する
+CREATE TABLE `api_keys` (
+  `user_id` BIGINT UNSIGNED NOT NULL,
+  `api_key` VARCHAR(64) CHARSET ascii COLLATE ascii_bin NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- スクリプトや CI から API を使うためのパーソナルアクセストークン. トークンそのものは保存せず, SHA-256 のハッシュだけを保存する
+CREATE TABLE `access_tokens` (
+  `id` BIGINT UNSIGNED NOT NULL,
+  `user_id` BIGINT UNSIGNED NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `token_hash` CHAR(64) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `scopes` VARCHAR(255) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `expires_at` TIMESTAMP(6) NOT NULL,
+  `last_used_at` TIMESTAMP(6) NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY (`token_hash`),
+  KEY (`user_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- ドメインイベントのアウトボックス. 変更と同じトランザクションで書き込み, スケジューラーが購読者に渡す
+CREATE TABLE `events` (
+  `id` BIGINT UNSIGNED NOT NULL,
+  `type` VARCHAR(32) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `payload` TEXT NOT NULL,
+  `occurred_at` TIMESTAMP(6) NOT NULL,
+  `trace_parent` VARCHAR(64) CHARSET ascii COLLATE ascii_bin NOT NULL DEFAULT '',
+  `attempts` INT UNSIGNED NOT NULL DEFAULT 0,
+  `error` VARCHAR(1024) NOT NULL DEFAULT '',
+  `next_attempt_at` TIMESTAMP(6) NULL DEFAULT NULL,
+  `dispatched_at` TIMESTAMP(6) NULL DEFAULT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`id`),
+  KEY (`dispatched_at`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- ブログでできごとがあったときにペイロードを送る URL. 署名に使うため, 秘密鍵はハッシュにせずに保存する
+CREATE TABLE `webhooks` (
+  `id` BIGINT UNSIGNED NOT NULL,
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `url` VARCHAR(2048) NOT NULL,
+  `secret` VARCHAR(64) CHARSET ascii COLLATE ascii_bin NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`id`),
+  KEY (`blog_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Webhook の配信. スケジューラーがバックグラウンドで送り, 失敗したものは間隔を空けて送り直す
+CREATE TABLE `webhook_deliveries` (
+  `id` BIGINT UNSIGNED NOT NULL,
+  `webhook_id` BIGINT UNSIGNED NOT NULL,
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `event` VARCHAR(32) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `payload` TEXT NOT NULL,
+  `status` VARCHAR(16) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `attempts` INT UNSIGNED NOT NULL DEFAULT 0,
+  `status_code` INT UNSIGNED NOT NULL DEFAULT 0,
+  `error` VARCHAR(1024) NOT NULL DEFAULT '',
+  `trace_parent` VARCHAR(64) CHARSET ascii COLLATE ascii_bin NOT NULL DEFAULT '',
+  `next_attempt_at` TIMESTAMP(6) NULL DEFAULT NULL,
+  `last_attempted_at` TIMESTAMP(6) NULL DEFAULT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`id`),
+  KEY (`webhook_id`, `created_at`),
+  KEY (`blog_id`),
+  KEY (`status`, `next_attempt_at`),
+  KEY (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
 CREATE TABLE `blogs` (
   `id` BIGINT UNSIGNED NOT NULL,
   `user_id` BIGINT UNSIGNED NOT NULL,
   `path` VARCHAR(64) CHARSET ascii COLLATE ascii_bin NOT NULL,
   `title` VARCHAR(254) NOT NULL,
   `description` VARCHAR(512) NOT NULL,
+  `comments_enabled` TINYINT(1) NOT NULL DEFAULT 1,
+  `visibility` VARCHAR(16) CHARSET ascii COLLATE ascii_bin NOT NULL DEFAULT 'public',
+  `deleted_at` TIMESTAMP(6) NULL DEFAULT NULL,
 
   `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
   `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
 
   PRIMARY KEY (`id`),
-  KEY (`user_id`),
+  KEY (`user_id`, `title`),
+  KEY (`created_at`),
+  KEY (`deleted_at`),
   UNIQUE KEY (`path`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
 
+-- パスを変更したブログの古いパス. 古いパスへのアクセスは新しいパスにリダイレクトし,
+-- ブログが完全に削除されるまで他のブログには使わせない
+CREATE TABLE `blog_path_redirects` (
+  `path` VARCHAR(64) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`path`),
+  KEY (`blog_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- ブログを作成したユーザーは blogs.user_id をもってオーナーとし, このテーブルには含めない
+CREATE TABLE `blog_members` (
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `user_id` BIGINT UNSIGNED NOT NULL,
+  `role` VARCHAR(16) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `invited_by` BIGINT UNSIGNED NOT NULL,
+  `invited_at` TIMESTAMP(6) NOT NULL,
+  `accepted_at` TIMESTAMP(6) NULL DEFAULT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`blog_id`, `user_id`),
+  KEY (`user_id`, `accepted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
 CREATE TABLE `entries` (
   `id` BIGINT UNSIGNED NOT NULL,
   `blog_id` BIGINT UNSIGNED NOT NULL,
+  `author_id` BIGINT UNSIGNED NOT NULL,
   `title` VARCHAR(512) NOT NULL,
   `body` MEDIUMTEXT NOT NULL,
   `body_html` MEDIUMTEXT NOT NULL,
+  `status` VARCHAR(16) CHARSET ascii COLLATE ascii_bin NOT NULL,
   `published_at` TIMESTAMP(6) NOT NULL,
   `edited_at` TIMESTAMP(6) NOT NULL,
+  `visibility` VARCHAR(16) CHARSET ascii COLLATE ascii_bin NOT NULL DEFAULT 'public',
+  `slug` VARCHAR(128) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `format` VARCHAR(16) CHARSET ascii COLLATE ascii_bin NOT NULL DEFAULT 'markdown',
+  `deleted_at` TIMESTAMP(6) NULL DEFAULT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY (`blog_id`, `slug`),
+  KEY (`blog_id`, `published_at`),
+  KEY (`blog_id`, `id`),
+  KEY (`blog_id`, `status`, `edited_at`),
+  KEY (`status`, `published_at`),
+  KEY (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- スラッグを変更したエントリの古いスラッグ. 古いスラッグのパーマリンクへのアクセスは新しいパーマリンクにリダイレクトし,
+-- エントリが完全に削除されるまで同じブログの他のエントリには使わせない
+CREATE TABLE `entry_slug_redirects` (
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `slug` VARCHAR(128) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `entry_id` BIGINT UNSIGNED NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`blog_id`, `slug`),
+  KEY (`entry_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- 他のブログサービスから取り込んだエントリと, 取り込み元のデータでの識別子の対応
+-- source_key は識別子の SHA-256 で, 同じデータを何度取り込んでもエントリが重複しないようにする
+CREATE TABLE `entry_imports` (
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `source_key` CHAR(64) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `entry_id` BIGINT UNSIGNED NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`blog_id`, `source_key`),
+  KEY (`entry_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- ブログにアップロードされた画像. ファイル本体とサムネイルはメディアのストレージに保存する
+CREATE TABLE `media` (
+  `id` BIGINT UNSIGNED NOT NULL,
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `uploaded_by` BIGINT UNSIGNED NOT NULL,
+  `filename` VARCHAR(255) NOT NULL,
+  `content_type` VARCHAR(64) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `size` BIGINT UNSIGNED NOT NULL,
+  `width` INT UNSIGNED NOT NULL,
+  `height` INT UNSIGNED NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`id`),
+  KEY (`blog_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- ブログを zip ファイルに書き出すジョブ. 書き出した zip ファイルはメディアのストレージに保存する
+CREATE TABLE `blog_exports` (
+  `id` BIGINT UNSIGNED NOT NULL,
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `requested_by` BIGINT UNSIGNED NOT NULL,
+  `status` VARCHAR(16) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `size` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  `error` VARCHAR(1024) NOT NULL DEFAULT '',
+  `started_at` TIMESTAMP(6) NULL DEFAULT NULL,
+  `completed_at` TIMESTAMP(6) NULL DEFAULT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`id`),
+  KEY (`blog_id`, `created_at`),
+  KEY (`status`, `created_at`),
+  KEY (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- 共有リンクのトークンは id と expires_at から署名して作るので, トークン自体は保存しない
+CREATE TABLE `share_links` (
+  `id` BIGINT UNSIGNED NOT NULL,
+  `entry_id` BIGINT UNSIGNED NOT NULL,
+  `created_by` BIGINT UNSIGNED NOT NULL,
+  `expires_at` TIMESTAMP(6) NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`id`),
+  KEY (`entry_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE `entry_revisions` (
+  `id` BIGINT UNSIGNED NOT NULL,
+  `entry_id` BIGINT UNSIGNED NOT NULL,
+  `title` VARCHAR(512) NOT NULL,
+  `body` MEDIUMTEXT NOT NULL,
+  `edited_at` TIMESTAMP(6) NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`id`),
+  KEY (`entry_id`, `edited_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE `tags` (
+  `id` BIGINT UNSIGNED NOT NULL,
+  `name` VARCHAR(64) NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE `entry_tags` (
+  `entry_id` BIGINT UNSIGNED NOT NULL,
+  `tag_id` BIGINT UNSIGNED NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`entry_id`, `tag_id`),
+  KEY (`tag_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- エントリの閲覧数. UTC の日ごとに区切って数え, 閲覧はメモリにまとめてから加える
+CREATE TABLE `entry_views` (
+  `entry_id` BIGINT UNSIGNED NOT NULL,
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `day` TIMESTAMP(6) NOT NULL,
+  `count` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`entry_id`, `day`),
+  KEY (`blog_id`, `day`),
+  KEY (`day`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- ユーザーがエントリに付けたスター
+CREATE TABLE `stars` (
+  `user_id` BIGINT UNSIGNED NOT NULL,
+  `entry_id` BIGINT UNSIGNED NOT NULL,
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`user_id`, `entry_id`),
+  KEY (`user_id`, `created_at`, `entry_id`),
+  KEY (`entry_id`),
+  KEY (`blog_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- エントリごとのスターの数. スターを付け外しするたびに増減させる
+CREATE TABLE `entry_star_counts` (
+  `entry_id` BIGINT UNSIGNED NOT NULL,
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `count` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`entry_id`),
+  KEY (`blog_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- 大文字と小文字を区別せずに検索するため, 照合順序を utf8mb4_general_ci にしている
+CREATE TABLE `entry_search_index` (
+  `entry_id` BIGINT UNSIGNED NOT NULL,
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `title` VARCHAR(512) NOT NULL,
+  `body` MEDIUMTEXT NOT NULL,
+  `published_at` TIMESTAMP(6) NOT NULL,
+
+  `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+
+  PRIMARY KEY (`entry_id`),
+  KEY (`blog_id`),
+  FULLTEXT KEY (`title`) WITH PARSER ngram,
+  FULLTEXT KEY (`title`, `body`) WITH PARSER ngram
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+CREATE TABLE `comments` (
+  `id` BIGINT UNSIGNED NOT NULL,
+  `entry_id` BIGINT UNSIGNED NOT NULL,
+  `blog_id` BIGINT UNSIGNED NOT NULL,
+  `user_id` BIGINT UNSIGNED NOT NULL,
+  `body` TEXT NOT NULL,
+  `body_html` TEXT NOT NULL,
+  `status` VARCHAR(16) CHARSET ascii COLLATE ascii_bin NOT NULL,
+  `posted_at` TIMESTAMP(6) NOT NULL,
 
   `created_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
   `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
 
   PRIMARY KEY (`id`),
-  KEY (`blog_id`, `published_at`)
+  KEY (`entry_id`, `status`, `posted_at`),
+  KEY (`blog_id`, `status`, `posted_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// maxAccessTokenLifetime はパーソナルアクセストークンの有効期間の上限
+const maxAccessTokenLifetime = 365 * 24 * time.Hour
+
+type accessTokenContextKey struct{}
+
+// WithAccessToken はリクエストを認証したパーソナルアクセストークンを ctx に入れる
+// この ctx で行う操作は, ユーザーの権限のうちトークンのスコープで許されたものに限られる
+func WithAccessToken(ctx context.Context, token *domain.AccessToken) context.Context {
+	return context.WithValue(ctx, accessTokenContextKey{}, token)
+}
+
+// accessTokenFromContext は ctx に入っているパーソナルアクセストークンを返す. トークンで認証していない場合は nil
+func accessTokenFromContext(ctx context.Context) *domain.AccessToken {
+	token, _ := ctx.Value(accessTokenContextKey{}).(*domain.AccessToken)
+	return token
+}
+
+// authorizeScope はパーソナルアクセストークンで認証したリクエストで, トークンがスコープを持っていなければ ErrPermissionDenied を返す
+// ブログに対する権限によらない, ユーザー自身の操作のスコープを確認するために使う
+func authorizeScope(ctx context.Context, scope domain.AccessTokenScope) error {
+	if token := accessTokenFromContext(ctx); token != nil && !token.HasScope(scope) {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// CreateAccessToken はパーソナルアクセストークンを発行し, 保存したトークンとトークンそのものを返す
+// トークンそのものは保存しないので, 呼び出し側で一度だけユーザーに見せる
+func (a *App) CreateAccessToken(ctx context.Context, user *domain.User, name string, scopes []domain.AccessTokenScope, lifetime time.Duration) (*domain.AccessToken, string, error) {
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return nil, "", ErrInvalidArgument
+	}
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidArgument
+	}
+	for _, s := range scopes {
+		if _, ok := domain.ParseAccessTokenScope(string(s)); !ok {
+			return nil, "", ErrInvalidArgument
+		}
+	}
+	if lifetime <= 0 || lifetime > maxAccessTokenLifetime {
+		return nil, "", ErrInvalidArgument
+	}
+	now := time.Now()
+	repo := repository.NewRepository(a.db)
+	return user.CreateAccessToken(name, scopes, now.Add(lifetime), now)(ctx, repo)
+}
+
+// ListAccessTokens はユーザーのパーソナルアクセストークンを期限切れのものも含めて新しい順に返す
+func (a *App) ListAccessTokens(ctx context.Context, user *domain.User) ([]*domain.AccessToken, error) {
+	repo := repository.NewRepository(a.db)
+	return repo.AccessToken().ListByUserID(ctx, user.ID)
+}
+
+// RevokeAccessToken はユーザーのパーソナルアクセストークンを取り消す
+func (a *App) RevokeAccessToken(ctx context.Context, user *domain.User, tokenID domain.AccessTokenID) error {
+	repo := repository.NewRepository(a.db)
+	token, err := repo.AccessToken().FindByID(ctx, tokenID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if token.UserID != user.ID {
+		return ErrNotFound
+	}
+	return token.Revoke()(ctx, repo)
+}
+
+// AuthenticateByAccessToken はパーソナルアクセストークンでユーザーを認証し, ユーザーとトークンを返す
+// 存在しないトークンも期限切れのトークンも, 区別せずに ErrAuthenticationFailed を返す
+func (a *App) AuthenticateByAccessToken(ctx context.Context, tokenStr string) (*domain.User, *domain.AccessToken, error) {
+	if !domain.LooksLikeAccessToken(tokenStr) {
+		return nil, nil, ErrAuthenticationFailed
+	}
+	repo := repository.NewRepository(a.db)
+	token, err := repo.AccessToken().FindByTokenHash(ctx, domain.HashAccessToken(tokenStr))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, ErrAuthenticationFailed
+		}
+		return nil, nil, err
+	}
+	now := time.Now()
+	if token.IsExpired(now) {
+		return nil, nil, ErrAuthenticationFailed
+	}
+	user, err := repo.User().FindByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, ErrAuthenticationFailed
+		}
+		return nil, nil, err
+	}
+	if err := token.MarkUsed(now)(ctx, repo); err != nil {
+		return nil, nil, err
+	}
+	return user, token, nil
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// FindOrCreateAPIKey はユーザーの API キーを返す. まだなければ新しく作る
+func (a *App) FindOrCreateAPIKey(ctx context.Context, user *domain.User) (*domain.APIKey, error) {
+	var apiKey *domain.APIKey
+	err := a.withTx(ctx, "FindOrCreateAPIKey", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		apiKey, err = repo.APIKey().FindByUserID(ctx, user.ID)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		apiKey, err = user.RegenerateAPIKey(time.Now())(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// RegenerateAPIKey はユーザーの API キーを作り直す. それまでの API キーは使えなくなる
+func (a *App) RegenerateAPIKey(ctx context.Context, user *domain.User) (*domain.APIKey, error) {
+	var apiKey *domain.APIKey
+	err := a.withTx(ctx, "RegenerateAPIKey", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		apiKey, err = user.RegenerateAPIKey(time.Now())(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// AuthenticateByAPIKey はユーザー名と API キーでユーザーを認証する (Basic 認証)
+func (a *App) AuthenticateByAPIKey(ctx context.Context, name, key string) (*domain.User, error) {
+	user, apiKey, err := a.findAPIKeyByUserName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey.Key), []byte(key)) != 1 {
+		return nil, ErrAuthenticationFailed
+	}
+	return user, nil
+}
+
+// AuthenticateByAPIKeyFunc はユーザー名と, ユーザーの API キーを受け取って確かめる verify でユーザーを認証する
+// WSSE 認証のように, API キーそのものではなく API キーから作ったダイジェストが送られる場合に使う
+func (a *App) AuthenticateByAPIKeyFunc(ctx context.Context, name string, verify func(key string) bool) (*domain.User, error) {
+	user, apiKey, err := a.findAPIKeyByUserName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !verify(apiKey.Key) {
+		return nil, ErrAuthenticationFailed
+	}
+	return user, nil
+}
+
+// findAPIKeyByUserName はユーザー名でユーザーとその API キーを検索する
+// ユーザーがいない場合も API キーがない場合も, 区別せずに ErrAuthenticationFailed を返す
+func (a *App) findAPIKeyByUserName(ctx context.Context, name string) (*domain.User, *domain.APIKey, error) {
+	repo := repository.NewRepository(a.db)
+	user, err := repo.User().FindByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, ErrAuthenticationFailed
+		}
+		return nil, nil, err
+	}
+	apiKey, err := repo.APIKey().FindByUserID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, ErrAuthenticationFailed
+		}
+		return nil, nil, err
+	}
+	return user, apiKey, nil
+}
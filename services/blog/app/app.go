@@ -3,9 +3,13 @@ package app
 import (
 	"context"
 	"crypto/ecdsa"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
 	pb_account "github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/pb/account"
 	pb_renderer "github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/pb/renderer"
 )
@@ -16,6 +20,14 @@ type App struct {
 	accountClient         pb_account.AccountClient
 	accountECDSAPublicKey *ecdsa.PublicKey
 	rendererClient        pb_renderer.RendererClient
+	searchIndex           domain.SearchIndex
+	mediaStorage          domain.MediaStorage
+	trashRetention        time.Duration
+	shareLinkSecret       []byte
+	webhookSender         domain.WebhookSender
+	eventSubscribers      []*eventSubscriber
+	entryViews            *entryViewBuffer
+	popularEntries        *popularEntriesCache
 }
 
 // NewApp は App を作成する
@@ -24,14 +36,34 @@ func NewApp(
 	accountClient pb_account.AccountClient,
 	accountECDSAPublicKey *ecdsa.PublicKey,
 	rendererClient pb_renderer.RendererClient,
+	searchIndex domain.SearchIndex,
+	mediaStorage domain.MediaStorage,
+	trashRetention time.Duration,
+	shareLinkSecret []byte,
+	webhookSender domain.WebhookSender,
 ) *App {
-	return &App{db, accountClient, accountECDSAPublicKey, rendererClient}
+	a := &App{db, accountClient, accountECDSAPublicKey, rendererClient, searchIndex, mediaStorage, trashRetention, shareLinkSecret, webhookSender, nil, newEntryViewBuffer(), newPopularEntriesCache()}
+	a.Subscribe("NotifyWebhooks", a.notifyWebhooks)
+	return a
 }
 
-// Render は RendererClient を使った domain.BodyRenderer の実装
-func (a *App) Render(ctx context.Context, body string) (string, error) {
-	reply, err := a.rendererClient.Render(ctx, &pb_renderer.RenderRequest{Src: body})
+// markdownRenderer は RendererClient を使った domain.BodyRenderer の実装
+// 本文が参照できる画像はエントリのブログにアップロードされたメディアだけで, それ以外の画像を参照している場合は ErrInvalidArgument を返す
+type markdownRenderer struct {
+	app    *App
+	blogID domain.BlogID
+}
+
+func (r *markdownRenderer) Render(ctx context.Context, body string) (string, error) {
+	images, err := r.app.referencedImages(ctx, r.blogID, body)
+	if err != nil {
+		return "", err
+	}
+	reply, err := r.app.rendererClient.Render(ctx, &pb_renderer.RenderRequest{Src: body, Images: images})
 	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			return "", ErrInvalidArgument
+		}
 		return "", err
 	}
 	return reply.Html, nil
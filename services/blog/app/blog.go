@@ -4,50 +4,74 @@ import (
 	"context"
 	"errors"
 	"regexp"
+	"time"
 	"unicode/utf8"
 
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
 )
 
-// ListBlogs は全ユーザーのブログを検索する
-func (a *App) ListBlogs(ctx context.Context, page, limit int) ([]*domain.Blog, bool, error) {
-	if page < 1 {
-		page = 1
-	}
-	offset := (page - 1) * limit
+// ListBlogs は全ユーザーのブログを新しい順に cursor の位置から検索する
+// cursor が nil の場合は先頭から検索する
+func (a *App) ListBlogs(ctx context.Context, cursor *domain.Cursor, limit int) ([]*domain.Blog, *domain.Page, error) {
 	repo := repository.NewRepository(a.db)
-	blogs, err := repo.Blog().List(ctx, limit+1, offset)
+	blogs, err := repo.Blog().List(ctx, cursor, limit+1)
 	if err != nil {
-		return nil, false, err
-	}
-	if len(blogs) > limit {
-		return blogs[:limit], true, nil
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			return nil, nil, ErrInvalidArgument
+		}
+		return nil, nil, err
 	}
-	return blogs, false, nil
+	blogs, page := paginate(blogs, cursor, limit, func(b *domain.Blog) *domain.Cursor {
+		return domain.NewTimeCursor(b.CreatedAt, uint64(b.ID))
+	})
+	return blogs, page, nil
 }
 
-// ListBlogsByUser はユーザーのブログを検索する
-func (a *App) ListBlogsByUser(ctx context.Context, user *domain.User, page, limit int) ([]*domain.Blog, bool, error) {
-	if page < 1 {
-		page = 1
+// ListBlogsWithLatestEntries は ListBlogs で検索したブログを, それぞれの最新の公開されているエントリとあわせて返す
+// ブログの数によらず, ブログとエントリをそれぞれ 1 回のクエリで検索する
+func (a *App) ListBlogsWithLatestEntries(ctx context.Context, cursor *domain.Cursor, limit int) ([]*domain.Blog, map[domain.BlogID]*domain.Entry, *domain.Page, error) {
+	blogs, page, err := a.ListBlogs(ctx, cursor, limit)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	blogIDs := make([]domain.BlogID, 0, len(blogs))
+	for _, b := range blogs {
+		blogIDs = append(blogIDs, b.ID)
 	}
-	offset := (page - 1) * limit
 	repo := repository.NewRepository(a.db)
-	blogs, err := repo.Blog().ListByUserID(ctx, user.ID, limit+1, offset)
+	entries, err := repo.Entry().ListLatestByBlogIDs(ctx, blogIDs)
 	if err != nil {
-		return nil, false, err
+		return nil, nil, nil, err
 	}
-	if len(blogs) > limit {
-		return blogs[:limit], true, nil
+	return blogs, entries, page, nil
+}
+
+// ListBlogsByUser はユーザーのブログをタイトルの降順に cursor の位置から検索する
+// cursor が nil の場合は先頭から検索する
+func (a *App) ListBlogsByUser(ctx context.Context, user *domain.User, cursor *domain.Cursor, limit int) ([]*domain.Blog, *domain.Page, error) {
+	repo := repository.NewRepository(a.db)
+	blogs, err := repo.Blog().ListByUserID(ctx, user.ID, cursor, limit+1)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			return nil, nil, ErrInvalidArgument
+		}
+		return nil, nil, err
 	}
-	return blogs, false, nil
+	blogs, page := paginate(blogs, cursor, limit, func(b *domain.Blog) *domain.Cursor {
+		return &domain.Cursor{Key: b.Title, ID: uint64(b.ID)}
+	})
+	return blogs, page, nil
 }
 
 var pathRE = regexp.MustCompile(`^[0-9A-Za-z][-_0-9A-Za-z]{2,63}$`)
 
 // CreateBlog は新規ブログを作成する
+// 他のブログが使っているパスや古いパスとして予約されているパスの場合は ErrAlreadyExists を返す
 func (a *App) CreateBlog(ctx context.Context, user *domain.User, path, title, description string) (*domain.Blog, error) {
+	if err := authorizeScope(ctx, domain.AccessTokenScopeManageBlogs); err != nil {
+		return nil, err
+	}
 	if ok := pathRE.MatchString(path); !ok {
 		return nil, ErrInvalidArgument
 	}
@@ -57,8 +81,19 @@ func (a *App) CreateBlog(ctx context.Context, user *domain.User, path, title, de
 	if utf8.RuneCountInString(description) > 500 {
 		return nil, ErrInvalidArgument
 	}
-	repo := repository.NewRepository(a.db)
-	return user.CreateBlog(path, title, description)(ctx, repo)
+	var blog *domain.Blog
+	err := a.withTx(ctx, "CreateBlog", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		blog, err = user.CreateBlog(path, title, description, time.Now())(ctx, repo)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			return nil, ErrAlreadyExists
+		}
+		return nil, err
+	}
+	return blog, nil
 }
 
 // FindBlogByPath はパスでブログを検索する
@@ -74,10 +109,33 @@ func (a *App) FindBlogByPath(ctx context.Context, path string) (*domain.Blog, er
 	return blog, nil
 }
 
+// FindBlogByOldPath はパスを変更したブログを変更前の古いパスで検索する
+func (a *App) FindBlogByOldPath(ctx context.Context, path string) (*domain.Blog, error) {
+	repo := repository.NewRepository(a.db)
+	redirect, err := repo.BlogPathRedirect().FindByPath(ctx, path)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	blog, err := repo.Blog().FindByID(ctx, redirect.BlogID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if blog.IsTrashed() {
+		return nil, ErrNotFound
+	}
+	return blog, nil
+}
+
 // EditBlog はブログの情報を更新する
 func (a *App) EditBlog(ctx context.Context, user *domain.User, blog *domain.Blog, title, description string) (*domain.Blog, error) {
-	if blog.UserID != user.ID {
-		return nil, ErrPermissionDenied
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
 	}
 	if utf8.RuneCountInString(title) > 200 {
 		return nil, ErrInvalidArgument
@@ -85,15 +143,59 @@ func (a *App) EditBlog(ctx context.Context, user *domain.User, blog *domain.Blog
 	if utf8.RuneCountInString(description) > 500 {
 		return nil, ErrInvalidArgument
 	}
-	repo := repository.NewRepository(a.db)
-	return blog.Edit(title, description)(ctx, repo)
+	var edited *domain.Blog
+	err := a.withTx(ctx, "EditBlog", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		edited, err = blog.Edit(title, description, time.Now())(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return edited, nil
 }
 
-// DeleteBlog はブログを削除する
-func (a *App) DeleteBlog(ctx context.Context, user *domain.User, blog *domain.Blog) error {
-	if blog.UserID != user.ID {
-		return ErrPermissionDenied
+// RenameBlog はブログのパスを変更する. 変更前のパスへのアクセスは新しいパスにリダイレクトされる
+// 他のブログが使っているパスや古いパスとして予約されているパスの場合は ErrAlreadyExists を返す
+func (a *App) RenameBlog(ctx context.Context, user *domain.User, blog *domain.Blog, path string) (*domain.Blog, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	if ok := pathRE.MatchString(path); !ok {
+		return nil, ErrInvalidArgument
+	}
+	var renamed *domain.Blog
+	err := a.withTx(ctx, "RenameBlog", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		renamed, err = blog.Rename(path, time.Now())(ctx, repo)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			return nil, ErrAlreadyExists
+		}
+		return nil, err
+	}
+	return renamed, nil
+}
+
+// ListBlogPathRedirects はブログの古いパスを新しい順に検索する
+func (a *App) ListBlogPathRedirects(ctx context.Context, user *domain.User, blog *domain.Blog) ([]*domain.BlogPathRedirect, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
 	}
 	repo := repository.NewRepository(a.db)
-	return blog.Delete()(ctx, repo)
+	return repo.BlogPathRedirect().ListByBlogID(ctx, blog.ID)
+}
+
+// TrashBlog はブログをゴミ箱に移動する
+// ゴミ箱に残しておく期間が過ぎると PurgeTrash で完全に削除される
+func (a *App) TrashBlog(ctx context.Context, user *domain.User, blog *domain.Blog) error {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return err
+	}
+	return a.withTx(ctx, "TrashBlog", func(ctx context.Context, repo domain.Repository) error {
+		_, err := blog.Trash(time.Now())(ctx, repo)
+		return err
+	})
 }
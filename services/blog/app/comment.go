@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	pb_renderer "github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/pb/renderer"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// commentRenderer は RendererClient を使った, コメント向けに機能を制限した domain.BodyRenderer の実装
+type commentRenderer struct {
+	client pb_renderer.RendererClient
+}
+
+func (r *commentRenderer) Render(ctx context.Context, body string) (string, error) {
+	reply, err := r.client.Render(ctx, &pb_renderer.RenderRequest{Src: body, Restricted: true})
+	if err != nil {
+		return "", err
+	}
+	return reply.Html, nil
+}
+
+// PostComment はエントリにコメントを投稿する
+func (a *App) PostComment(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry, body string) (*domain.Comment, error) {
+	if entry.BlogID != blog.ID || !entry.IsPublished() {
+		return nil, ErrNotFound
+	}
+	if !blog.CommentsEnabled {
+		return nil, ErrCommentsDisabled
+	}
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > 2000 {
+		return nil, ErrInvalidArgument
+	}
+	repo := repository.NewRepository(a.db)
+	return entry.PostComment(blog, user, body, time.Now())(ctx, repo, &commentRenderer{a.rendererClient})
+}
+
+// ListCommentsByEntry はエントリに付けられた承認済みのコメントを投稿された順に cursor の位置から検索する
+// cursor が nil の場合は先頭から検索する
+func (a *App) ListCommentsByEntry(ctx context.Context, entry *domain.Entry, cursor *domain.Cursor, limit int) ([]*domain.Comment, *domain.Page, error) {
+	repo := repository.NewRepository(a.db)
+	comments, err := repo.Comment().ListByEntryID(ctx, entry.ID, domain.CommentStatusApproved, cursor, limit+1)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			return nil, nil, ErrInvalidArgument
+		}
+		return nil, nil, err
+	}
+	comments, page := paginate(comments, cursor, limit, func(c *domain.Comment) *domain.Cursor {
+		return domain.NewTimeCursor(c.PostedAt, uint64(c.ID))
+	})
+	return comments, page, nil
+}
+
+// ListCommentsByBlog はブログに付けられたコメントを状態ごとに新しい順に検索する
+func (a *App) ListCommentsByBlog(ctx context.Context, user *domain.User, blog *domain.Blog, status domain.CommentStatus, page, limit int) ([]*domain.Comment, bool, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, false, err
+	}
+	switch status {
+	case domain.CommentStatusPending, domain.CommentStatusApproved, domain.CommentStatusHidden:
+	default:
+		return nil, false, ErrInvalidArgument
+	}
+	if page < 1 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+	repo := repository.NewRepository(a.db)
+	comments, err := repo.Comment().ListByBlogID(ctx, blog.ID, status, limit+1, offset)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(comments) > limit {
+		return comments[:limit], true, nil
+	}
+	return comments, false, nil
+}
+
+// ListCommentUsers はコメントを投稿したユーザーを検索する
+func (a *App) ListCommentUsers(ctx context.Context, comments []*domain.Comment) (map[domain.UserID]*domain.User, error) {
+	userIDs := make([]domain.UserID, 0, len(comments))
+	for _, c := range comments {
+		userIDs = append(userIDs, c.UserID)
+	}
+	repo := repository.NewRepository(a.db)
+	users, err := repo.User().ListByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	usersByID := make(map[domain.UserID]*domain.User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	return usersByID, nil
+}
+
+// FindCommentByID はブログに付けられたコメントを ID で検索する
+func (a *App) FindCommentByID(ctx context.Context, user *domain.User, blog *domain.Blog, commentID domain.CommentID) (*domain.Comment, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	comment, err := repo.Comment().FindByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if comment.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	return comment, nil
+}
+
+// ApproveComment はコメントを承認する
+func (a *App) ApproveComment(ctx context.Context, user *domain.User, blog *domain.Blog, comment *domain.Comment) (*domain.Comment, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionModerateComments); err != nil {
+		return nil, err
+	}
+	if comment.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	repo := repository.NewRepository(a.db)
+	return comment.Approve()(ctx, repo)
+}
+
+// HideComment はコメントを非表示にする
+func (a *App) HideComment(ctx context.Context, user *domain.User, blog *domain.Blog, comment *domain.Comment) (*domain.Comment, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionModerateComments); err != nil {
+		return nil, err
+	}
+	if comment.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	repo := repository.NewRepository(a.db)
+	return comment.Hide()(ctx, repo)
+}
+
+// DeleteComment はコメントを削除する
+func (a *App) DeleteComment(ctx context.Context, user *domain.User, blog *domain.Blog, comment *domain.Comment) error {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionModerateComments); err != nil {
+		return err
+	}
+	if comment.BlogID != blog.ID {
+		return ErrNotFound
+	}
+	repo := repository.NewRepository(a.db)
+	return comment.Delete()(ctx, repo)
+}
+
+// SetBlogCommentsEnabled はブログでコメントを受け付けるかどうかを切り替える
+func (a *App) SetBlogCommentsEnabled(ctx context.Context, user *domain.User, blog *domain.Blog, enabled bool) (*domain.Blog, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	return blog.SetCommentsEnabled(enabled)(ctx, repo)
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"regexp"
 	"time"
 	"unicode/utf8"
 
@@ -10,14 +11,59 @@ import (
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
 )
 
-// ListEntriesByBlog はブログのエントリを検索する
-func (a *App) ListEntriesByBlog(ctx context.Context, blog *domain.Blog, page, limit int) ([]*domain.Entry, bool, error) {
+// ListEntriesByBlog はブログの公開されているエントリを新しい順に cursor の位置から検索する
+// 下書きを読めるブログのメンバー以外には, 公開範囲が public のエントリのみを返す
+// cursor が nil の場合は先頭から検索する
+func (a *App) ListEntriesByBlog(ctx context.Context, user *domain.User, blog *domain.Blog, cursor *domain.Cursor, limit int) ([]*domain.Entry, *domain.Page, error) {
+	listedOnly, err := a.listedOnly(ctx, user, blog)
+	if err != nil {
+		return nil, nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	entries, err := repo.Entry().ListByBlogID(ctx, blog.ID, listedOnly, cursor, limit+1)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			return nil, nil, ErrInvalidArgument
+		}
+		return nil, nil, err
+	}
+	entries, page := paginate(entries, cursor, limit, func(e *domain.Entry) *domain.Cursor {
+		return domain.NewTimeCursor(e.PublishedAt, uint64(e.ID))
+	})
+	return entries, page, nil
+}
+
+// ListAllEntriesByBlog はブログのエントリを公開されているかによらず公開日時の新しい順に cursor の位置から検索する
+// cursor が nil の場合は先頭から検索する
+func (a *App) ListAllEntriesByBlog(ctx context.Context, user *domain.User, blog *domain.Blog, cursor *domain.Cursor, limit int) ([]*domain.Entry, *domain.Page, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	entries, err := repo.Entry().ListRecentByBlogID(ctx, blog.ID, cursor, limit+1)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			return nil, nil, ErrInvalidArgument
+		}
+		return nil, nil, err
+	}
+	entries, page := paginate(entries, cursor, limit, func(e *domain.Entry) *domain.Cursor {
+		return domain.NewTimeCursor(e.PublishedAt, uint64(e.ID))
+	})
+	return entries, page, nil
+}
+
+// ListDraftEntriesByBlog はブログの下書きと非公開のエントリを検索する
+func (a *App) ListDraftEntriesByBlog(ctx context.Context, user *domain.User, blog *domain.Blog, page, limit int) ([]*domain.Entry, bool, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, false, err
+	}
 	if page < 1 {
 		page = 1
 	}
 	offset := (page - 1) * limit
 	repo := repository.NewRepository(a.db)
-	entries, err := repo.Entry().ListByBlogID(ctx, blog.ID, limit+1, offset)
+	entries, err := repo.Entry().ListDraftsByBlogID(ctx, blog.ID, limit+1, offset)
 	if err != nil {
 		return nil, false, err
 	}
@@ -27,20 +73,169 @@ func (a *App) ListEntriesByBlog(ctx context.Context, blog *domain.Blog, page, li
 	return entries, false, nil
 }
 
+// slugRE はエントリのスラッグに使える文字列
+// 数字だけのスラッグはスラッグを指定していないエントリの ID と重なりうるので使えない
+var slugRE = regexp.MustCompile(`^[0-9a-z]+(-[0-9a-z]+)*$`)
+var digitsRE = regexp.MustCompile(`^[0-9]+$`)
+
+// validateSlug はエントリのスラッグを検証する. 空の場合はスラッグを指定しないものとして扱う
+func validateSlug(slug string) error {
+	if slug == "" {
+		return nil
+	}
+	if len(slug) > 100 || !slugRE.MatchString(slug) || digitsRE.MatchString(slug) {
+		return ErrInvalidArgument
+	}
+	return nil
+}
+
+// changeSlug はエントリのスラッグを変更する. slug が空の場合はエントリの ID に戻す
+func changeSlug(ctx context.Context, repo domain.Repository, entry *domain.Entry, slug string) (*domain.Entry, error) {
+	entry, err := entry.ChangeSlug(slug, time.Now())(ctx, repo)
+	if err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			return nil, ErrAlreadyExists
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
 // PublishEntry は新規エントリを投稿する
-func (a *App) PublishEntry(ctx context.Context, user *domain.User, blog *domain.Blog, title, body string) (*domain.Entry, error) {
-	if blog.UserID != user.ID {
-		return nil, ErrPermissionDenied
+// publishAt が未来の日時であればその日時に公開されるように予約し, ゼロ値であれば今すぐ公開する
+func (a *App) PublishEntry(ctx context.Context, user *domain.User, blog *domain.Blog, title, slug, body string, tagNames []string, publishAt time.Time) (*domain.Entry, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, err
 	}
 	if utf8.RuneCountInString(title) > 500 {
 		return nil, ErrInvalidArgument
 	}
-	repo := repository.NewRepository(a.db)
-	return blog.PublishEntry(title, body, time.Now())(ctx, repo, a)
+	if err := validateSlug(slug); err != nil {
+		return nil, err
+	}
+	tagNames, err := normalizeTagNames(tagNames)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var entry *domain.Entry
+	err = a.withTx(ctx, "PublishEntry", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		if publishAt.After(now) {
+			entry, err = blog.ScheduleEntry(user, title, body, publishAt, now)(ctx, repo, &markdownRenderer{a, blog.ID})
+		} else {
+			if publishAt.IsZero() {
+				publishAt = now
+			}
+			entry, err = blog.PublishEntry(user, title, body, publishAt)(ctx, repo, &markdownRenderer{a, blog.ID})
+		}
+		if err != nil {
+			return err
+		}
+		entry, err = changeSlug(ctx, repo, entry, slug)
+		if err != nil {
+			return err
+		}
+		_, err = entry.SetTags(tagNames)(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.reindexEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// SaveDraftEntry は新規エントリを下書きとして保存する
+func (a *App) SaveDraftEntry(ctx context.Context, user *domain.User, blog *domain.Blog, title, slug, body string, tagNames []string) (*domain.Entry, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(title) > 500 {
+		return nil, ErrInvalidArgument
+	}
+	if err := validateSlug(slug); err != nil {
+		return nil, err
+	}
+	tagNames, err := normalizeTagNames(tagNames)
+	if err != nil {
+		return nil, err
+	}
+	var entry *domain.Entry
+	err = a.withTx(ctx, "SaveDraftEntry", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		entry, err = blog.SaveDraftEntry(user, title, body, time.Now())(ctx, repo, &markdownRenderer{a, blog.ID})
+		if err != nil {
+			return err
+		}
+		entry, err = changeSlug(ctx, repo, entry, slug)
+		if err != nil {
+			return err
+		}
+		_, err = entry.SetTags(tagNames)(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// PublishDraftEntry は下書きや非公開のエントリを公開する
+// publishAt が未来の日時であればその日時に公開されるように予約する
+func (a *App) PublishDraftEntry(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry, publishAt time.Time) (*domain.Entry, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, err
+	}
+	if entry.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	now := time.Now()
+	var published *domain.Entry
+	err := a.withTx(ctx, "PublishDraftEntry", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		if publishAt.After(now) {
+			published, err = entry.Schedule(publishAt)(ctx, repo)
+		} else {
+			published, err = entry.Publish(now)(ctx, repo)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.reindexEntry(ctx, published); err != nil {
+		return nil, err
+	}
+	return published, nil
+}
+
+// PublishScheduledEntries は公開日時を過ぎた予約投稿のエントリを公開する
+// 複数のプロセスから同時に呼ばれても, 行ロックによってそれぞれのエントリは一度だけ公開される
+func (a *App) PublishScheduledEntries(ctx context.Context, now time.Time, limit int) ([]*domain.Entry, error) {
+	var entries []*domain.Entry
+	err := a.withTx(ctx, "PublishScheduledEntries", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		entries, err = domain.PublishDueEntries(now, limit)(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if err := a.reindexEntry(ctx, e); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
 }
 
 // FindEntryByID は ID でエントリを検索する
-func (a *App) FindEntryByID(ctx context.Context, blog *domain.Blog, entryID domain.EntryID) (*domain.Entry, error) {
+// 公開されていないエントリや公開範囲が private のエントリは下書きを読めるブログのメンバー以外には,
+// ゴミ箱にあるエントリは誰にも見つからないものとして扱う
+func (a *App) FindEntryByID(ctx context.Context, user *domain.User, blog *domain.Blog, entryID domain.EntryID) (*domain.Entry, error) {
 	repo := repository.NewRepository(a.db)
 	entry, err := repo.Entry().FindByID(ctx, entryID)
 	if err != nil {
@@ -49,16 +244,67 @@ func (a *App) FindEntryByID(ctx context.Context, blog *domain.Blog, entryID doma
 		}
 		return nil, err
 	}
-	if entry.BlogID != blog.ID {
+	return a.checkEntryReadable(ctx, user, blog, entry)
+}
+
+// FindEntryBySlug はスラッグでエントリを検索する. 見つからないものとして扱うエントリは FindEntryByID と同じ
+func (a *App) FindEntryBySlug(ctx context.Context, user *domain.User, blog *domain.Blog, slug string) (*domain.Entry, error) {
+	repo := repository.NewRepository(a.db)
+	entry, err := repo.Entry().FindBySlug(ctx, blog.ID, slug)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return a.checkEntryReadable(ctx, user, blog, entry)
+}
+
+// FindEntryByOldSlug はスラッグを変更したエントリを変更前の古いスラッグで検索する. 見つからないものとして扱うエントリは FindEntryByID と同じ
+// エントリの ID のスラッグは古いスラッグとして残さないので, 数字だけのスラッグはエントリの ID として検索する
+func (a *App) FindEntryByOldSlug(ctx context.Context, user *domain.User, blog *domain.Blog, slug string) (*domain.Entry, error) {
+	repo := repository.NewRepository(a.db)
+	entryID, err := domain.ParseEntryID(slug)
+	if err != nil {
+		redirect, err := repo.EntrySlugRedirect().FindBySlug(ctx, blog.ID, slug)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		entryID = redirect.EntryID
+	}
+	entry, err := repo.Entry().FindByID(ctx, entryID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return a.checkEntryReadable(ctx, user, blog, entry)
+}
+
+func (a *App) checkEntryReadable(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry) (*domain.Entry, error) {
+	if entry.BlogID != blog.ID || entry.IsTrashed() {
 		return nil, ErrNotFound
 	}
+	if !entry.IsPublished() || entry.VisibilityIn(blog) == domain.VisibilityPrivate {
+		canRead, err := a.HasPermission(ctx, user, blog, domain.PermissionReadDrafts)
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			return nil, ErrNotFound
+		}
+	}
 	return entry, nil
 }
 
 // EditEntry はエントリを編集する
-func (a *App) EditEntry(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry, title, body string) (*domain.Entry, error) {
-	if blog.UserID != user.ID {
-		return nil, ErrPermissionDenied
+func (a *App) EditEntry(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry, title, slug, body string, tagNames []string) (*domain.Entry, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, err
 	}
 	if entry.BlogID != blog.ID {
 		return nil, ErrNotFound
@@ -66,18 +312,57 @@ func (a *App) EditEntry(ctx context.Context, user *domain.User, blog *domain.Blo
 	if utf8.RuneCountInString(title) > 500 {
 		return nil, ErrInvalidArgument
 	}
-	repo := repository.NewRepository(a.db)
-	return entry.Edit(title, body, time.Now())(ctx, repo, a)
+	if err := validateSlug(slug); err != nil {
+		return nil, err
+	}
+	tagNames, err := normalizeTagNames(tagNames)
+	if err != nil {
+		return nil, err
+	}
+	var edited *domain.Entry
+	err = a.withTx(ctx, "EditEntry", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		edited, err = entry.Edit(title, body, time.Now())(ctx, repo, a.entryRenderer(entry.BlogID, entry.Format))
+		if err != nil {
+			return err
+		}
+		edited, err = changeSlug(ctx, repo, edited, slug)
+		if err != nil {
+			return err
+		}
+		_, err = edited.SetTags(tagNames)(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.reindexEntry(ctx, edited); err != nil {
+		return nil, err
+	}
+	return edited, nil
+}
+
+// PreviewEntry はエントリの本文を保存せずに HTML に変換する
+func (a *App) PreviewEntry(ctx context.Context, user *domain.User, blog *domain.Blog, body string) (string, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return "", err
+	}
+	return (&markdownRenderer{a, blog.ID}).Render(ctx, body)
 }
 
-// UnpublishEntry はエントリを削除する
+// UnpublishEntry はエントリを非公開にする
 func (a *App) UnpublishEntry(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry) error {
-	if blog.UserID != user.ID {
-		return ErrPermissionDenied
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return err
 	}
 	if entry.BlogID != blog.ID {
 		return ErrNotFound
 	}
-	repo := repository.NewRepository(a.db)
-	return entry.Unpublish()(ctx, repo)
+	err := a.withTx(ctx, "UnpublishEntry", func(ctx context.Context, repo domain.Repository) error {
+		return entry.Unpublish(time.Now())(ctx, repo)
+	})
+	if err != nil {
+		return err
+	}
+	return a.searchIndex.Remove(ctx, entry.ID)
 }
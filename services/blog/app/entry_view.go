@@ -0,0 +1,267 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// PopularEntriesPeriod は人気のエントリを決める閲覧数を数える期間
+const PopularEntriesPeriod = 7 * 24 * time.Hour
+
+// maxBufferedEntryViews はメモリに貯めておくエントリと日の組の数の上限. 書き込めない間に上限を超えた閲覧は数えない
+const maxBufferedEntryViews = 100000
+
+// entryViewFlushBatchSize は閲覧数を書き込むときに一度のクエリで書き込むエントリと日の組の数
+const entryViewFlushBatchSize = 500
+
+// popularEntriesCacheTTL は人気のエントリの順位をキャッシュしておく期間
+// 閲覧数はスケジューラーが書き込むまで変わらないので, ページを表示するたびに集計しない
+const popularEntriesCacheTTL = time.Minute
+
+type entryViewKey struct {
+	entryID domain.EntryID
+	blogID  domain.BlogID
+	day     time.Time
+}
+
+// entryViewBuffer は閲覧数に加えるまでのエントリの閲覧をメモリに貯めておく
+type entryViewBuffer struct {
+	mu     sync.Mutex
+	counts map[entryViewKey]int64
+}
+
+func newEntryViewBuffer() *entryViewBuffer {
+	return &entryViewBuffer{counts: map[entryViewKey]int64{}}
+}
+
+// add は閲覧を count 回貯める. 上限を超える場合は貯めずに false を返す
+func (b *entryViewBuffer) add(key entryViewKey, count int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.counts[key]; !ok && len(b.counts) >= maxBufferedEntryViews {
+		return false
+	}
+	b.counts[key] += count
+	return true
+}
+
+// take は貯めておいた閲覧をすべて取り出す
+func (b *entryViewBuffer) take() map[entryViewKey]int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	counts := b.counts
+	b.counts = map[entryViewKey]int64{}
+	return counts
+}
+
+// popularEntriesKey は人気のエントリの順位を区別するキー. blogID が 0 のものはすべてのブログの順位
+type popularEntriesKey struct {
+	blogID     domain.BlogID
+	listedOnly bool
+	limit      int
+}
+
+type popularEntries struct {
+	counts    []*domain.EntryViewCount
+	expiresAt time.Time
+}
+
+// popularEntriesCache は集計した人気のエントリの順位を popularEntriesCacheTTL の間メモリに置いておく
+type popularEntriesCache struct {
+	mu        sync.Mutex
+	entries   map[popularEntriesKey]popularEntries
+	nextSweep time.Time
+}
+
+func newPopularEntriesCache() *popularEntriesCache {
+	return &popularEntriesCache{entries: map[popularEntriesKey]popularEntries{}}
+}
+
+// get は now の時点で期限が切れていない順位を返す
+func (c *popularEntriesCache) get(key popularEntriesKey, now time.Time) ([]*domain.EntryViewCount, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.entries[key]
+	if !ok || !now.Before(p.expiresAt) {
+		return nil, false
+	}
+	return p.counts, true
+}
+
+// set は now に集計した順位を置く. 期限の切れた順位は popularEntriesCacheTTL ごとにまとめて捨てる
+func (c *popularEntriesCache) set(key popularEntriesKey, counts []*domain.EntryViewCount, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !now.Before(c.nextSweep) {
+		for k, p := range c.entries {
+			if !now.Before(p.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.nextSweep = now.Add(popularEntriesCacheTTL)
+	}
+	c.entries[key] = popularEntries{counts, now.Add(popularEntriesCacheTTL)}
+}
+
+// RecordEntryView はエントリの閲覧を記録する. 閲覧数には FlushEntryViews で書き込んだときに加わる
+// 読者に見えていないエントリの閲覧と, エントリを書いたユーザー自身の閲覧は数えない. ボットの閲覧は呼び出し側で除く
+func (a *App) RecordEntryView(user *domain.User, entry *domain.Entry, viewedAt time.Time) {
+	if !entry.CountsView(user) {
+		return
+	}
+	a.entryViews.add(entryViewKey{entry.ID, entry.BlogID, domain.EntryViewDay(viewedAt)}, 1)
+}
+
+// FlushEntryViews はメモリに貯めておいた閲覧を閲覧数に加え, 加えた閲覧の数を返す
+// 書き込めなかった閲覧はメモリに戻し, 次に呼んだときに書き込む
+func (a *App) FlushEntryViews(ctx context.Context) (int64, error) {
+	counts := a.entryViews.take()
+	if len(counts) == 0 {
+		return 0, nil
+	}
+	keys := make([]entryViewKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	// 複数のプロセスから同時に書き込んでもデッドロックしないよう, 行ロックを取る順番をそろえる
+	slices.SortFunc(keys, func(a, b entryViewKey) int {
+		if c := cmp.Compare(a.entryID, b.entryID); c != 0 {
+			return c
+		}
+		return a.day.Compare(b.day)
+	})
+	repo := repository.NewRepository(a.db)
+	var flushed int64
+	for start := 0; start < len(keys); start += entryViewFlushBatchSize {
+		batch := keys[start:min(start+entryViewFlushBatchSize, len(keys))]
+		inputs := make([]*domain.AddEntryViewsInput, 0, len(batch))
+		for _, key := range batch {
+			inputs = append(inputs, &domain.AddEntryViewsInput{
+				EntryID: key.entryID,
+				BlogID:  key.blogID,
+				Day:     key.day,
+				Count:   counts[key],
+			})
+		}
+		if err := repo.EntryView().Add(ctx, inputs); err != nil {
+			for _, key := range keys[start:] {
+				a.entryViews.add(key, counts[key])
+			}
+			return flushed, err
+		}
+		for _, input := range inputs {
+			flushed += input.Count
+		}
+	}
+	return flushed, nil
+}
+
+// CountEntryViews はブログの複数のエントリのこれまでの閲覧数を返す. まだ書き込んでいない閲覧は含まない
+func (a *App) CountEntryViews(ctx context.Context, user *domain.User, blog *domain.Blog, entries []*domain.Entry) (map[domain.EntryID]int64, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, err
+	}
+	entryIDs := make([]domain.EntryID, 0, len(entries))
+	for _, e := range entries {
+		if e.BlogID == blog.ID {
+			entryIDs = append(entryIDs, e.ID)
+		}
+	}
+	repo := repository.NewRepository(a.db)
+	return repo.EntryView().CountByEntryIDs(ctx, entryIDs)
+}
+
+// ListPopularEntriesByBlog はブログの公開されているエントリを PopularEntriesPeriod の間の閲覧数の多い順に検索する
+// 下書きを読めるブログのメンバー以外には, 公開範囲が public のエントリのみを返す
+// 順位は popularEntriesCacheTTL の間キャッシュするが, その間に削除されたり公開されなくなったりしたエントリは含まない
+func (a *App) ListPopularEntriesByBlog(ctx context.Context, user *domain.User, blog *domain.Blog, now time.Time, limit int) ([]*domain.Entry, error) {
+	listedOnly, err := a.listedOnly(ctx, user, blog)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	key := popularEntriesKey{blog.ID, listedOnly, limit}
+	counts, ok := a.popularEntries.get(key, now)
+	if !ok {
+		counts, err = repo.EntryView().ListPopularByBlogID(ctx, blog.ID, now.Add(-PopularEntriesPeriod), listedOnly, limit)
+		if err != nil {
+			return nil, err
+		}
+		a.popularEntries.set(key, counts, now)
+	}
+	return listEntriesByViewCounts(ctx, repo, counts, listedOnly)
+}
+
+// ListPopularEntries はすべてのブログの公開されているエントリを PopularEntriesPeriod の間の閲覧数の多い順に, エントリのブログとともに検索する
+// ゴミ箱にあるブログのエントリや, エントリかブログの公開範囲が public でないものは含まない
+// 順位は popularEntriesCacheTTL の間キャッシュするが, その間に削除されたり公開されなくなったりしたエントリは含まない
+func (a *App) ListPopularEntries(ctx context.Context, now time.Time, limit int) ([]*domain.Entry, map[domain.BlogID]*domain.Blog, error) {
+	repo := repository.NewRepository(a.db)
+	key := popularEntriesKey{0, true, limit}
+	counts, ok := a.popularEntries.get(key, now)
+	if !ok {
+		var err error
+		counts, err = repo.EntryView().ListPopular(ctx, now.Add(-PopularEntriesPeriod), limit)
+		if err != nil {
+			return nil, nil, err
+		}
+		a.popularEntries.set(key, counts, now)
+	}
+	found, err := listEntriesByViewCounts(ctx, repo, counts, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	blogIDs := make([]domain.BlogID, 0, len(found))
+	for _, e := range found {
+		blogIDs = append(blogIDs, e.BlogID)
+	}
+	blogs, err := repo.Blog().ListByIDs(ctx, blogIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	blogsByID := make(map[domain.BlogID]*domain.Blog, len(blogs))
+	for _, b := range blogs {
+		if b.Visibility == domain.VisibilityPublic {
+			blogsByID[b.ID] = b
+		}
+	}
+	entries := make([]*domain.Entry, 0, len(found))
+	for _, e := range found {
+		if _, ok := blogsByID[e.BlogID]; ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries, blogsByID, nil
+}
+
+// listEntriesByViewCounts は閲覧数の順にエントリを検索する
+// 順位を集計してから検索するまでの間に削除されたり公開されなくなったりしたエントリと, listedOnly のときは公開範囲が public でなくなったエントリは除く
+func listEntriesByViewCounts(ctx context.Context, repo domain.Repository, counts []*domain.EntryViewCount, listedOnly bool) ([]*domain.Entry, error) {
+	entryIDs := make([]domain.EntryID, 0, len(counts))
+	for _, c := range counts {
+		entryIDs = append(entryIDs, c.EntryID)
+	}
+	found, err := repo.Entry().ListByIDs(ctx, entryIDs)
+	if err != nil {
+		return nil, err
+	}
+	entriesByID := make(map[domain.EntryID]*domain.Entry, len(found))
+	for _, e := range found {
+		entriesByID[e.ID] = e
+	}
+	entries := make([]*domain.Entry, 0, len(counts))
+	for _, id := range entryIDs {
+		e, ok := entriesByID[id]
+		if !ok || !e.IsPublished() || (listedOnly && e.Visibility != domain.VisibilityPublic) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
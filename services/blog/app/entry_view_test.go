@@ -0,0 +1,83 @@
+package app
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func TestPopularEntriesCache(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	c := newPopularEntriesCache()
+	blogKey := popularEntriesKey{1, true, 5}
+	counts := []*domain.EntryViewCount{{EntryID: 10, Count: 3}}
+	c.set(blogKey, counts, now)
+
+	tests := []struct {
+		name string
+		key  popularEntriesKey
+		now  time.Time
+		want bool
+	}{
+		{"cached", blogKey, now, true},
+		{"before expiry", blogKey, now.Add(popularEntriesCacheTTL - time.Second), true},
+		{"expired", blogKey, now.Add(popularEntriesCacheTTL), false},
+		{"another blog", popularEntriesKey{2, true, 5}, now, false},
+		{"including unlisted entries", popularEntriesKey{1, false, 5}, now, false},
+		{"another limit", popularEntriesKey{1, true, 10}, now, false},
+		{"all blogs", popularEntriesKey{0, true, 5}, now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.get(tt.key, tt.now)
+			if ok != tt.want {
+				t.Fatalf("get() ok = %v, want %v", ok, tt.want)
+			}
+			if ok && (len(got) != 1 || got[0] != counts[0]) {
+				t.Errorf("get() = %v, want %v", got, counts)
+			}
+		})
+	}
+
+	// 期限の切れた順位は次に掃除するときに捨てる
+	c.set(popularEntriesKey{2, true, 5}, nil, now.Add(popularEntriesCacheTTL))
+	c.mu.Lock()
+	n := len(c.entries)
+	c.mu.Unlock()
+	if n != 1 {
+		t.Errorf("cache has %d rankings after sweeping, want 1", n)
+	}
+}
+
+func TestEntryViewBufferConcurrently(t *testing.T) {
+	day := domain.EntryViewDay(time.Now())
+	b := newEntryViewBuffer()
+	var total int64
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				b.add(entryViewKey{domain.EntryID(i % 5), 1, day}, 1)
+			}
+			counts := b.take()
+			mu.Lock()
+			for _, n := range counts {
+				total += n
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	for _, n := range b.take() {
+		total += n
+	}
+	// 取り出すのと貯めるのが並んでも, 閲覧を失ったり二重に数えたりしない
+	if total != 50*100 {
+		t.Errorf("took %d views, want %d", total, 50*100)
+	}
+}
@@ -16,3 +16,12 @@ var ErrNotFound = errors.New("not found")
 
 // ErrPermissionDenied は操作が許可されないときに返される
 var ErrPermissionDenied = errors.New("permission denied")
+
+// ErrCommentsDisabled はブログがコメントを受け付けていないときに返される
+var ErrCommentsDisabled = errors.New("comments disabled")
+
+// ErrAlreadyExists は作成や変更しようとしたリソースと重複するものが既に存在するときに返される
+var ErrAlreadyExists = errors.New("already exists")
+
+// ErrUnsupportedMedia はアップロードされたファイルが受け付けない形式のときに返される
+var ErrUnsupportedMedia = errors.New("unsupported media")
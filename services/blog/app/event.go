@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// EventRetention は処理し終わったドメインイベントをアウトボックスに残しておく期間
+const EventRetention = 7 * 24 * time.Hour
+
+// eventSubscriber はドメインイベントの購読者
+type eventSubscriber struct {
+	name    string
+	handler domain.EventHandler
+}
+
+// Subscribe はドメインイベントの購読者を登録する. 購読者はイベントが起きた順にすべてのイベントを受け取る
+// RelayEvents を呼ぶ前 (アプリケーションを初期化するとき) に呼ぶ
+func (a *App) Subscribe(name string, handler domain.EventHandler) {
+	a.eventSubscribers = append(a.eventSubscribers, &eventSubscriber{name, handler})
+}
+
+// RelayEvents はアウトボックスのドメインイベントを 1 件ずつ購読者に渡し, 処理したイベントを返す
+// イベントごとのトランザクションで購読者に渡し, すべての購読者が処理し終わったらイベントを処理済みにしてコミットする
+// 失敗した場合はロールバックして, 渡した回数に応じて間隔を空けて渡し直す
+func (a *App) RelayEvents(ctx context.Context, now time.Time, limit int) ([]*domain.Event, error) {
+	relayed := make([]*domain.Event, 0, limit)
+	for range limit {
+		var event, dispatched *domain.Event
+		err := a.withTx(ctx, "RelayEvent", func(ctx context.Context, repo domain.Repository) error {
+			events, err := repo.Event().ListDueForUpdate(ctx, now, 1)
+			if err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				return nil
+			}
+			event = events[0]
+			if err := a.dispatchEvent(ctx, repo, event); err != nil {
+				return err
+			}
+			dispatched, err = event.MarkDispatched(time.Now())(ctx, repo)
+			return err
+		})
+		if err != nil {
+			// イベントを読む前に失敗した場合や停止するときに中断した場合は, 次の実行で渡し直す
+			if event == nil || ctx.Err() != nil {
+				return relayed, err
+			}
+			repo := repository.NewRepository(a.db)
+			failed, err := event.Fail(truncateString(err.Error(), 1024), time.Now())(ctx, repo)
+			if err != nil {
+				return relayed, err
+			}
+			relayed = append(relayed, failed)
+			continue
+		}
+		if event == nil {
+			break
+		}
+		relayed = append(relayed, dispatched)
+	}
+	return relayed, nil
+}
+
+// dispatchEvent はドメインイベントを登録したすべての購読者に渡す
+// 購読者ごとのスパンは, イベントを起こしたリクエストのスパンにリンクする
+func (a *App) dispatchEvent(ctx context.Context, repo domain.Repository, event *domain.Event) error {
+	var links []trace.Link
+	origin := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier{"traceparent": event.TraceParent})
+	if sc := trace.SpanContextFromContext(origin); sc.IsValid() {
+		links = append(links, trace.Link{SpanContext: sc})
+	}
+	for _, s := range a.eventSubscribers {
+		err := func() error {
+			ctx, span := tracer.Start(ctx, "event."+s.name,
+				trace.WithLinks(links...),
+				trace.WithAttributes(
+					attribute.String("event.id", event.ID.String()),
+					attribute.String("event.type", string(event.Type)),
+					attribute.Int("event.attempt", event.Attempts+1),
+				),
+			)
+			defer span.End()
+			if err := s.handler(ctx, repo, event); err != nil {
+				span.SetStatus(codes.Error, "failed to handle event")
+				span.RecordError(err)
+				return fmt.Errorf("%s: %w", s.name, err)
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeDispatchedEvents は残しておく期間を過ぎた処理済みのドメインイベントを最大 limit 件削除し, 削除した件数を返す
+func (a *App) PurgeDispatchedEvents(ctx context.Context, now time.Time, limit int) (int, error) {
+	repo := repository.NewRepository(a.db)
+	return repo.Event().DeleteDispatchedBefore(ctx, now.Add(-EventRetention), limit)
+}
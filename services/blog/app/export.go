@@ -0,0 +1,250 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/exporter"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// BlogExportRetention は書き出した zip ファイルをダウンロードできるように残しておく期間
+const BlogExportRetention = 7 * 24 * time.Hour
+
+// blogExportTimeout は書き出しにかかる時間の上限
+// これより前に始めたまま終わっていない書き出しは, 書き出していたプロセスが止まったものとして始め直す
+const blogExportTimeout = time.Hour
+
+// exportBatchSize は書き出すときに一度にリポジトリから読むエントリの数
+const exportBatchSize = 100
+
+// RequestBlogExport はブログを zip ファイルに書き出すジョブを登録する. 書き出しはスケジューラーがバックグラウンドで行う
+// 書き出しを待っているか書き出している途中のものがあれば, 新しく登録せずにそれを返す
+func (a *App) RequestBlogExport(ctx context.Context, user *domain.User, blog *domain.Blog) (*domain.BlogExport, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	var export *domain.BlogExport
+	err := a.withTx(ctx, "RequestBlogExport", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		export, err = blog.RequestExport(user, time.Now())(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// ListBlogExports はブログの書き出しを新しい順に返す
+func (a *App) ListBlogExports(ctx context.Context, user *domain.User, blog *domain.Blog) ([]*domain.BlogExport, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	return repo.BlogExport().ListByBlogID(ctx, blog.ID)
+}
+
+// FindBlogExport はブログの書き出しを ID で検索する
+func (a *App) FindBlogExport(ctx context.Context, user *domain.User, blog *domain.Blog, exportID domain.BlogExportID) (*domain.BlogExport, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	export, err := repo.BlogExport().FindByID(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if export.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	return export, nil
+}
+
+// OpenBlogExport は書き出した zip ファイルを読み出す. 書き出しが終わっていない場合は ErrNotFound を返す
+func (a *App) OpenBlogExport(ctx context.Context, user *domain.User, blog *domain.Blog, export *domain.BlogExport) (io.ReadCloser, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	if export.BlogID != blog.ID || export.Status != domain.BlogExportStatusCompleted {
+		return nil, ErrNotFound
+	}
+	r, err := a.mediaStorage.Get(ctx, export.StorageKey())
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// RunBlogExports は書き出しを待っているブログを zip ファイルに書き出し, メディアのストレージに保存する
+// 書き出しに失敗したものは失敗したことを記録し, 処理した書き出しを返す
+func (a *App) RunBlogExports(ctx context.Context, now time.Time, limit int) ([]*domain.BlogExport, error) {
+	var started []*domain.BlogExport
+	err := a.withTx(ctx, "StartBlogExports", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		started, err = domain.StartBlogExports(now, now.Add(-blogExportTimeout), limit)(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	finished := make([]*domain.BlogExport, 0, len(started))
+	for _, e := range started {
+		size, err := a.writeBlogExport(ctx, e)
+		if err != nil {
+			// 停止するときに中断したものは, 次に起動したときに始め直す
+			if ctx.Err() != nil {
+				return finished, ctx.Err()
+			}
+			export, err := e.Fail(truncateString(err.Error(), 1024), time.Now())(ctx, repo)
+			if err != nil {
+				return finished, err
+			}
+			finished = append(finished, export)
+			continue
+		}
+		export, err := e.Complete(size, time.Now())(ctx, repo)
+		if err != nil {
+			// 書き出している間にブログとともに削除されたものは, zip ファイルも残さない
+			if errors.Is(err, domain.ErrNotFound) {
+				a.deleteBlogExportFile(ctx, e)
+				continue
+			}
+			return finished, err
+		}
+		finished = append(finished, export)
+	}
+	return finished, nil
+}
+
+// writeBlogExport はブログを一時ファイルに zip ファイルとして書き出してからメディアのストレージに保存し, その大きさを返す
+// ブログとエントリは 1 つのスナップショットから読むので, 書き出しの途中で編集されても zip ファイルの中のファイルの内容は食い違わない
+func (a *App) writeBlogExport(ctx context.Context, export *domain.BlogExport) (int64, error) {
+	f, err := os.CreateTemp("", "blog-export-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+	err = a.withSnapshot(ctx, "WriteBlogExport", func(ctx context.Context, repo domain.Repository) error {
+		blog, err := repo.Blog().FindByID(ctx, export.BlogID)
+		if err != nil {
+			return err
+		}
+		return exporter.Write(f, blog, time.Now(), exportEntries(ctx, repo, blog))
+	})
+	if err != nil {
+		return 0, err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if err := a.mediaStorage.Put(ctx, export.StorageKey(), "application/zip", f); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// exportEntries はブログのゴミ箱にないすべてのエントリを, exportBatchSize 件ずつリポジトリから読んで渡す exporter.EntryIterator を返す
+func exportEntries(ctx context.Context, repo domain.Repository, blog *domain.Blog) exporter.EntryIterator {
+	return func(fn func(e *exporter.Entry) error) error {
+		var afterID domain.EntryID
+		for {
+			entries, err := repo.Entry().ListAllByBlogID(ctx, blog.ID, afterID, exportBatchSize)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return nil
+			}
+			entryIDs := make([]domain.EntryID, 0, len(entries))
+			var authorIDs []domain.UserID
+			seen := map[domain.UserID]bool{}
+			for _, e := range entries {
+				entryIDs = append(entryIDs, e.ID)
+				if !seen[e.AuthorID] {
+					seen[e.AuthorID] = true
+					authorIDs = append(authorIDs, e.AuthorID)
+				}
+			}
+			tags, err := repo.Tag().ListByEntryIDs(ctx, entryIDs)
+			if err != nil {
+				return err
+			}
+			authors, err := repo.User().ListByIDs(ctx, authorIDs)
+			if err != nil {
+				return err
+			}
+			authorNames := make(map[domain.UserID]string, len(authors))
+			for _, u := range authors {
+				authorNames[u.ID] = u.Name
+			}
+			for _, e := range entries {
+				tagNames := make([]string, 0, len(tags[e.ID]))
+				for _, t := range tags[e.ID] {
+					tagNames = append(tagNames, t.Name)
+				}
+				err := fn(&exporter.Entry{
+					Entry:      e,
+					AuthorName: authorNames[e.AuthorID],
+					Tags:       tagNames,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if len(entries) < exportBatchSize {
+				return nil
+			}
+			afterID = entries[len(entries)-1].ID
+		}
+	}
+}
+
+// PurgeExpiredBlogExports は残しておく期間を過ぎた書き出しを zip ファイルとともに削除する
+func (a *App) PurgeExpiredBlogExports(ctx context.Context, now time.Time, limit int) ([]*domain.BlogExport, error) {
+	repo := repository.NewRepository(a.db)
+	exports, err := repo.BlogExport().ListCreatedBefore(ctx, now.Add(-BlogExportRetention), limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range exports {
+		if err := e.Delete()(ctx, repo); err != nil {
+			return nil, err
+		}
+		a.deleteBlogExportFile(ctx, e)
+	}
+	return exports, nil
+}
+
+// deleteBlogExportFile は書き出した zip ファイルをストレージから削除する
+// リポジトリから削除した後に呼ぶので, 失敗してもファイルが残るだけでダウンロードできることはない
+func (a *App) deleteBlogExportFile(ctx context.Context, export *domain.BlogExport) {
+	_ = a.mediaStorage.Delete(ctx, export.StorageKey())
+}
+
+// truncateString は s を先頭から n 文字までに切り詰める
+func truncateString(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// ListRecentEntries はすべてのブログの公開されているエントリを新しい順に検索する
+// エントリが属するブログもあわせて返す
+func (a *App) ListRecentEntries(ctx context.Context, limit int) ([]*domain.Entry, map[domain.BlogID]*domain.Blog, error) {
+	repo := repository.NewRepository(a.db)
+	entries, err := repo.Entry().ListPublished(ctx, limit, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	blogIDs := make([]domain.BlogID, 0, len(entries))
+	for _, e := range entries {
+		blogIDs = append(blogIDs, e.BlogID)
+	}
+	blogs, err := repo.Blog().ListByIDs(ctx, blogIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	blogsByID := make(map[domain.BlogID]*domain.Blog, len(blogs))
+	for _, b := range blogs {
+		blogsByID[b.ID] = b
+	}
+	return entries, blogsByID, nil
+}
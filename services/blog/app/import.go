@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/importer"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/sanitize"
+)
+
+// htmlRenderer は他のブログサービスから取り込んだ HTML の本文を,
+// 許可したタグと属性だけを残して表示してよい HTML にする domain.BodyRenderer の実装
+type htmlRenderer struct{}
+
+func (r htmlRenderer) Render(ctx context.Context, body string) (string, error) {
+	return sanitize.HTML(body), nil
+}
+
+// entryRenderer はブログのエントリの本文の記法にあわせた domain.BodyRenderer を返す
+func (a *App) entryRenderer(blogID domain.BlogID, format domain.EntryFormat) domain.BodyRenderer {
+	if format == domain.EntryFormatHTML {
+		return htmlRenderer{}
+	}
+	return &markdownRenderer{a, blogID}
+}
+
+// ImportResult はファイルから読み取ったエントリ 1 件を取り込んだ結果を表す
+type ImportResult struct {
+	Title string
+	// Entry は取り込んだエントリ. 取り込まなかった場合は nil
+	Entry *domain.Entry
+	// Skipped は以前に取り込んだことがあるので取り込まなかったことを表す
+	Skipped bool
+	// Err は取り込めなかった理由
+	Err error
+}
+
+// ImportEntries は他のブログサービスから書き出したファイルから読み取ったエントリをブログに取り込む
+// 取り込めないエントリがあっても残りのエントリは取り込み, エントリごとの結果を返す
+// 以前に同じブログに取り込んだエントリは取り込まないので, 同じファイルを何度取り込んでもエントリは重複しない
+func (a *App) ImportEntries(ctx context.Context, user *domain.User, blog *domain.Blog, entries []*importer.Entry) ([]*ImportResult, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	results := make([]*ImportResult, 0, len(entries))
+	for _, e := range entries {
+		result := &ImportResult{Title: e.Title}
+		results = append(results, result)
+		if e.Err != nil {
+			result.Err = e.Err
+			continue
+		}
+		entry, err := a.importEntry(ctx, user, blog, e)
+		if err != nil {
+			if errors.Is(err, ErrAlreadyExists) {
+				result.Skipped = true
+				continue
+			}
+			result.Err = err
+			continue
+		}
+		result.Entry = entry
+	}
+	return results, nil
+}
+
+func (a *App) importEntry(ctx context.Context, user *domain.User, blog *domain.Blog, e *importer.Entry) (*domain.Entry, error) {
+	if utf8.RuneCountInString(e.Title) > 500 {
+		return nil, ErrInvalidArgument
+	}
+	tagNames, err := normalizeTagNames(e.Tags)
+	if err != nil {
+		return nil, err
+	}
+	// 書き出し元のスラッグがこのブログのスラッグとして使えなければ, エントリの ID のままにする
+	slug := e.Slug
+	if validateSlug(slug) != nil {
+		slug = ""
+	}
+	now := time.Now()
+	status := e.Status
+	if status == domain.EntryStatusPublished && e.PublishedAt.After(now) {
+		status = domain.EntryStatusScheduled
+	}
+	sum := sha256.Sum256([]byte(e.Key))
+	input := &domain.ImportEntryInput{
+		SourceKey:   hex.EncodeToString(sum[:]),
+		Title:       e.Title,
+		Body:        e.Body,
+		Format:      e.Format,
+		Status:      status,
+		Visibility:  e.Visibility,
+		PublishedAt: e.PublishedAt,
+	}
+	var entry *domain.Entry
+	err = a.withTx(ctx, "ImportEntry", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		entry, err = blog.ImportEntry(user, input, now)(ctx, repo, a.entryRenderer(blog.ID, e.Format))
+		if err != nil {
+			if errors.Is(err, domain.ErrAlreadyExists) {
+				return ErrAlreadyExists
+			}
+			return err
+		}
+		if slug != "" {
+			// 他のエントリが既に使っているスラッグであれば, エントリの ID のままにする
+			slugged, err := entry.ChangeSlug(slug, now)(ctx, repo)
+			if err != nil && !errors.Is(err, domain.ErrAlreadyExists) {
+				return err
+			}
+			if err == nil {
+				entry = slugged
+			}
+		}
+		_, err = entry.SetTags(tagNames)(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.reindexEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
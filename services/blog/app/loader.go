@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/loader"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// Loaders はリクエストの処理で使う Loader をまとめたもの
+// 結果をキャッシュするので, リクエストごとに NewLoaders で作成して使う
+type Loaders struct {
+	BlogOwner *loader.Loader[domain.UserID, *domain.User]
+	StarCount *loader.Loader[domain.EntryID, int]
+}
+
+// NewLoaders は Loaders を作成する
+func (a *App) NewLoaders() *Loaders {
+	return &Loaders{
+		BlogOwner: a.NewBlogOwnerLoader(),
+		StarCount: a.NewStarCountLoader(),
+	}
+}
+
+// NewBlogOwnerLoader はブログを作成したユーザーを, ブログの UserID でまとめて検索する Loader を作成する
+// 結果をキャッシュするので, リクエストごとに作成して使う
+func (a *App) NewBlogOwnerLoader() *loader.Loader[domain.UserID, *domain.User] {
+	return loader.New("BlogOwner", func(ctx context.Context, userIDs []domain.UserID) (map[domain.UserID]*domain.User, error) {
+		repo := repository.NewRepository(a.db)
+		users, err := repo.User().ListByIDs(ctx, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		usersByID := make(map[domain.UserID]*domain.User, len(users))
+		for _, u := range users {
+			usersByID[u.ID] = u
+		}
+		return usersByID, nil
+	})
+}
+
+// NewStarCountLoader はエントリのスターの数をまとめて数える Loader を作成する. スターが付いていないエントリは見つからない
+// 結果をキャッシュするので, リクエストごとに作成して使う
+func (a *App) NewStarCountLoader() *loader.Loader[domain.EntryID, int] {
+	return loader.New("StarCount", func(ctx context.Context, entryIDs []domain.EntryID) (map[domain.EntryID]int, error) {
+		repo := repository.NewRepository(a.db)
+		return repo.Star().CountByEntryIDs(ctx, entryIDs)
+	})
+}
@@ -0,0 +1,249 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	pb_renderer "github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/pb/renderer"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// MaxMediaSize はアップロードできるファイルの大きさの上限
+const MaxMediaSize = 10 << 20
+
+// maxMediaPixels はアップロードできる画像のピクセル数の上限
+// ファイルが小さくても, デコードすると大量のメモリを使う画像を受け付けないようにする
+const maxMediaPixels = 40_000_000
+
+// mediaContentTypes はアップロードできるファイルの形式
+// 形式はクライアントが送ってきた Content-Type ではなく, ファイルの中身から判定する
+var mediaContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+// UploadMedia はブログに画像をアップロードし, サムネイルとあわせてメディアのストレージに保存する
+func (a *App) UploadMedia(ctx context.Context, user *domain.User, blog *domain.Blog, filename string, data []byte) (*domain.Media, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data) > MaxMediaSize {
+		return nil, ErrInvalidArgument
+	}
+	contentType := http.DetectContentType(data)
+	if !mediaContentTypes[contentType] {
+		return nil, ErrUnsupportedMedia
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxMediaPixels {
+		return nil, ErrUnsupportedMedia
+	}
+	filename = strings.TrimSpace(path.Base(strings.ReplaceAll(filename, "\\", "/")))
+	if filename == "" || filename == "." || filename == "/" {
+		filename = "image"
+	}
+	if utf8.RuneCountInString(filename) > 255 {
+		return nil, ErrInvalidArgument
+	}
+
+	repo := repository.NewRepository(a.db)
+	media, err := blog.UploadMedia(user, filename, contentType, int64(len(data)), config.Width, config.Height, time.Now())(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.storeMediaFiles(ctx, media, data); err != nil {
+		// ファイルを保存できなかったメディアは残さない
+		_ = media.Delete()(ctx, repo)
+		a.deleteMediaFiles(ctx, media)
+		return nil, err
+	}
+	return media, nil
+}
+
+func (a *App) storeMediaFiles(ctx context.Context, media *domain.Media, data []byte) error {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ErrUnsupportedMedia
+	}
+	width, height := media.ThumbnailDimensions()
+	thumbnail, err := makeThumbnail(img, width, height, media.ThumbnailContentType())
+	if err != nil {
+		return err
+	}
+	if err := a.mediaStorage.Put(ctx, media.StorageKey(), media.ContentType, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return a.mediaStorage.Put(ctx, media.ThumbnailStorageKey(), media.ThumbnailContentType(), bytes.NewReader(thumbnail))
+}
+
+// deleteMediaFiles はメディアのファイル本体とサムネイルをストレージから削除する
+// リポジトリから削除した後に呼ぶので, 失敗してもファイルが残るだけで読めなくなることはない
+func (a *App) deleteMediaFiles(ctx context.Context, media *domain.Media) {
+	_ = a.mediaStorage.Delete(ctx, media.StorageKey())
+	_ = a.mediaStorage.Delete(ctx, media.ThumbnailStorageKey())
+}
+
+// ListMedia はブログのメディアをアップロードされた新しい順に cursor の位置から検索する
+// cursor が nil の場合は先頭から検索する
+func (a *App) ListMedia(ctx context.Context, user *domain.User, blog *domain.Blog, cursor *domain.Cursor, limit int) ([]*domain.Media, *domain.Page, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	media, err := repo.Media().ListByBlogID(ctx, blog.ID, cursor, limit+1)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			return nil, nil, ErrInvalidArgument
+		}
+		return nil, nil, err
+	}
+	media, page := paginate(media, cursor, limit, func(m *domain.Media) *domain.Cursor {
+		return domain.NewTimeCursor(m.CreatedAt, uint64(m.ID))
+	})
+	return media, page, nil
+}
+
+// listAllMedia はブログのすべてのメディアを検索する
+func (a *App) listAllMedia(ctx context.Context, blog *domain.Blog) ([]*domain.Media, error) {
+	repo := repository.NewRepository(a.db)
+	var all []*domain.Media
+	var cursor *domain.Cursor
+	for {
+		media, err := repo.Media().ListByBlogID(ctx, blog.ID, cursor, 100)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, media...)
+		if len(media) < 100 {
+			return all, nil
+		}
+		last := media[len(media)-1]
+		cursor = domain.NewTimeCursor(last.CreatedAt, uint64(last.ID))
+	}
+}
+
+// FindMedia はブログのメディアを ID で検索する
+func (a *App) FindMedia(ctx context.Context, user *domain.User, blog *domain.Blog, mediaID domain.MediaID) (*domain.Media, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	media, err := repo.Media().FindByID(ctx, mediaID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if media.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	return media, nil
+}
+
+// DeleteMedia はメディアを削除する. 削除したメディアを参照しているエントリの画像は表示されなくなる
+func (a *App) DeleteMedia(ctx context.Context, user *domain.User, blog *domain.Blog, media *domain.Media) error {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return err
+	}
+	if media.BlogID != blog.ID {
+		return ErrNotFound
+	}
+	repo := repository.NewRepository(a.db)
+	if err := media.Delete()(ctx, repo); err != nil {
+		return err
+	}
+	a.deleteMediaFiles(ctx, media)
+	return nil
+}
+
+// OpenMedia はメディアのファイルを読み出す. thumbnail が true の場合はサムネイルを読み出す
+// ゴミ箱にあるブログや, 公開範囲が private のブログのメディアはメンバー以外には見つからないものとして扱う
+func (a *App) OpenMedia(ctx context.Context, user *domain.User, mediaID domain.MediaID, thumbnail bool) (*domain.Media, io.ReadCloser, error) {
+	repo := repository.NewRepository(a.db)
+	media, err := repo.Media().FindByID(ctx, mediaID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	blog, err := repo.Blog().FindByID(ctx, media.BlogID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	if blog.IsTrashed() {
+		return nil, nil, ErrNotFound
+	}
+	canView, err := a.CanViewBlog(ctx, user, blog)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !canView {
+		return nil, nil, ErrNotFound
+	}
+	key := media.StorageKey()
+	if thumbnail {
+		key = media.ThumbnailStorageKey()
+	}
+	r, err := a.mediaStorage.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	return media, r, nil
+}
+
+// mediaRefRE はエントリの本文からメディアを参照している URL を探す
+var mediaRefRE = regexp.MustCompile(`/media/([0-9]+)`)
+
+// referencedImages はエントリの本文が参照しているブログのメディアを, レンダラに渡す画像として返す
+// レンダラは返した画像だけを width と height を付けてレンダリングするので, 他のブログのメディアは本文に埋め込めない
+func (a *App) referencedImages(ctx context.Context, blogID domain.BlogID, body string) ([]*pb_renderer.Image, error) {
+	var ids []domain.MediaID
+	seen := map[domain.MediaID]bool{}
+	for _, m := range mediaRefRE.FindAllStringSubmatch(body, -1) {
+		id, err := domain.ParseMediaID(m[1])
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	repo := repository.NewRepository(a.db)
+	media, err := repo.Media().ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	images := make([]*pb_renderer.Image, 0, len(media)*2)
+	for _, m := range media {
+		if m.BlogID != blogID {
+			continue
+		}
+		thumbnailWidth, thumbnailHeight := m.ThumbnailDimensions()
+		images = append(images,
+			&pb_renderer.Image{Src: m.URL(), Width: uint32(m.Width), Height: uint32(m.Height)},
+			&pb_renderer.Image{Src: m.ThumbnailURL(), Width: uint32(thumbnailWidth), Height: uint32(thumbnailHeight)},
+		)
+	}
+	return images, nil
+}
@@ -0,0 +1,246 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// RoleOf はユーザーのブログでの役割を返す. メンバーでない場合はゼロ値を返す
+func (a *App) RoleOf(ctx context.Context, user *domain.User, blog *domain.Blog) (domain.MemberRole, error) {
+	repo := repository.NewRepository(a.db)
+	return blog.RoleOf(user)(ctx, repo)
+}
+
+// HasPermission はユーザーがブログに対する権限を持っているかを判定する
+// user が nil の場合はどの権限も持っていないものとして扱う
+// パーソナルアクセストークンで認証した ctx では, トークンのスコープで許されていない権限も持っていないものとして扱う
+func (a *App) HasPermission(ctx context.Context, user *domain.User, blog *domain.Blog, permission domain.Permission) (bool, error) {
+	if token := accessTokenFromContext(ctx); token != nil && !token.Allows(permission) {
+		return false, nil
+	}
+	role, err := a.RoleOf(ctx, user, blog)
+	if err != nil {
+		return false, err
+	}
+	return role.Can(permission), nil
+}
+
+// Authorize はユーザーがブログに対する権限を持っていなければ ErrPermissionDenied を返す
+// ブログに対する操作の権限はすべてこの関数で確認する
+func (a *App) Authorize(ctx context.Context, user *domain.User, blog *domain.Blog, permission domain.Permission) error {
+	ok, err := a.HasPermission(ctx, user, blog, permission)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// ListMembers はブログのメンバーを招待中のものも含めて検索する
+// メンバーのユーザーもあわせて返す
+func (a *App) ListMembers(ctx context.Context, user *domain.User, blog *domain.Blog) ([]*domain.Member, map[domain.UserID]*domain.User, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	members, err := repo.Member().ListByBlogID(ctx, blog.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	userIDs := make([]domain.UserID, 0, len(members)+1)
+	userIDs = append(userIDs, blog.UserID)
+	for _, m := range members {
+		userIDs = append(userIDs, m.UserID)
+	}
+	users, err := repo.User().ListByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	usersByID := make(map[domain.UserID]*domain.User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	return members, usersByID, nil
+}
+
+// FindInvitation はユーザーがまだ承諾していないブログへの招待を検索する
+func (a *App) FindInvitation(ctx context.Context, user *domain.User, blog *domain.Blog) (*domain.Member, error) {
+	repo := repository.NewRepository(a.db)
+	member, err := repo.Member().Find(ctx, blog.ID, user.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if member.IsAccepted() {
+		return nil, ErrNotFound
+	}
+	return member, nil
+}
+
+// ListInvitations はユーザーがまだ承諾していない招待を検索する
+// 招待されたブログもあわせて返し, ゴミ箱にあるブログへの招待は含めない
+func (a *App) ListInvitations(ctx context.Context, user *domain.User) ([]*domain.Member, map[domain.BlogID]*domain.Blog, error) {
+	repo := repository.NewRepository(a.db)
+	invitations, err := repo.Member().ListInvitationsByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	blogIDs := make([]domain.BlogID, 0, len(invitations))
+	for _, m := range invitations {
+		blogIDs = append(blogIDs, m.BlogID)
+	}
+	blogs, err := repo.Blog().ListByIDs(ctx, blogIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	blogsByID := make(map[domain.BlogID]*domain.Blog, len(blogs))
+	for _, b := range blogs {
+		blogsByID[b.ID] = b
+	}
+	found := make([]*domain.Member, 0, len(invitations))
+	for _, m := range invitations {
+		if _, ok := blogsByID[m.BlogID]; ok {
+			found = append(found, m)
+		}
+	}
+	return found, blogsByID, nil
+}
+
+// InviteMember は名前で指定したユーザーをブログのメンバーに招待する
+func (a *App) InviteMember(ctx context.Context, user *domain.User, blog *domain.Blog, inviteeName string, role domain.MemberRole) (*domain.Member, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	if _, ok := domain.ParseMemberRole(string(role)); !ok {
+		return nil, ErrInvalidArgument
+	}
+	repo := repository.NewRepository(a.db)
+	invitee, err := repo.User().FindByName(ctx, inviteeName)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	member, err := blog.InviteMember(user, invitee, role, time.Now())(ctx, repo)
+	if err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			return nil, ErrAlreadyRegistered
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+// AcceptInvitation はユーザーへのブログの招待を承諾する
+func (a *App) AcceptInvitation(ctx context.Context, user *domain.User, blog *domain.Blog) (*domain.Member, error) {
+	invitation, err := a.FindInvitation(ctx, user, blog)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	return invitation.Accept(time.Now())(ctx, repo)
+}
+
+// DeclineInvitation はユーザーへのブログの招待を辞退する
+func (a *App) DeclineInvitation(ctx context.Context, user *domain.User, blog *domain.Blog) error {
+	invitation, err := a.FindInvitation(ctx, user, blog)
+	if err != nil {
+		return err
+	}
+	repo := repository.NewRepository(a.db)
+	return invitation.Remove()(ctx, repo)
+}
+
+// FindMember はブログのメンバーをユーザーの ID で検索する
+func (a *App) FindMember(ctx context.Context, user *domain.User, blog *domain.Blog, userID domain.UserID) (*domain.Member, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	member, err := repo.Member().Find(ctx, blog.ID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+// ChangeMemberRole はメンバーの役割を変更する. オーナーにはできず, オーナーを譲る場合は TransferBlogOwnership を使う
+func (a *App) ChangeMemberRole(ctx context.Context, user *domain.User, blog *domain.Blog, member *domain.Member, role domain.MemberRole) (*domain.Member, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	if member.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	if _, ok := domain.ParseMemberRole(string(role)); !ok {
+		return nil, ErrInvalidArgument
+	}
+	repo := repository.NewRepository(a.db)
+	return member.ChangeRole(role)(ctx, repo)
+}
+
+// RemoveMember はメンバーをブログから外す. 招待中のメンバーの場合は招待を取り消す
+// 自分自身を外すことはできる
+func (a *App) RemoveMember(ctx context.Context, user *domain.User, blog *domain.Blog, member *domain.Member) error {
+	if member.UserID != user.ID {
+		if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+			return err
+		}
+	}
+	if member.BlogID != blog.ID {
+		return ErrNotFound
+	}
+	repo := repository.NewRepository(a.db)
+	return member.Remove()(ctx, repo)
+}
+
+// TransferBlogOwnership はブログのオーナーを招待を承諾したメンバーに譲る. オーナー自身だけが譲れる
+// 譲ったユーザーは editor のメンバーとしてブログに残る
+func (a *App) TransferBlogOwnership(ctx context.Context, user *domain.User, blog *domain.Blog, member *domain.Member) (*domain.Blog, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionTransferOwnership); err != nil {
+		return nil, err
+	}
+	if member.BlogID != blog.ID || !member.IsAccepted() {
+		return nil, ErrNotFound
+	}
+	var transferred *domain.Blog
+	err := a.withTx(ctx, "TransferBlogOwnership", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		transferred, err = blog.TransferOwnership(member, time.Now())(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transferred, nil
+}
+
+// ListEntryAuthors はエントリを書いたユーザーを検索する
+func (a *App) ListEntryAuthors(ctx context.Context, entries []*domain.Entry) (map[domain.UserID]*domain.User, error) {
+	userIDs := make([]domain.UserID, 0, len(entries))
+	for _, e := range entries {
+		userIDs = append(userIDs, e.AuthorID)
+	}
+	repo := repository.NewRepository(a.db)
+	users, err := repo.User().ListByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	usersByID := make(map[domain.UserID]*domain.User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	return usersByID, nil
+}
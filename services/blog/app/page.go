@@ -0,0 +1,35 @@
+package app
+
+import (
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// paginate はキーセットページングで limit+1 件まで読んだ一覧から 1 ページ分を切り出し, 前後のページの位置を求める
+// cursorOf は一覧の要素からその要素の位置を返す
+func paginate[T any](items []T, cursor *domain.Cursor, limit int, cursorOf func(T) *domain.Cursor) ([]T, *domain.Page) {
+	page := &domain.Page{}
+	backward := cursor != nil && cursor.Backward
+	hasMore := len(items) > limit
+	if hasMore {
+		if backward {
+			// 前のページを読んだ場合, 余分に読んだ 1 件は先頭にある
+			items = items[len(items)-limit:]
+		} else {
+			items = items[:limit]
+		}
+	}
+	if len(items) == 0 {
+		return items, page
+	}
+	// 次のページは, 前のページを読んだ場合は常にあり, そうでなければ余分に読めたときだけある
+	if backward || hasMore {
+		page.Next = cursorOf(items[len(items)-1])
+	}
+	// 前のページは, 前のページを読んだ場合は余分に読めたときだけあり, そうでなければ cursor の位置から読んだときは常にある
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		prev := cursorOf(items[0])
+		prev.Backward = true
+		page.Prev = prev
+	}
+	return items, page
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func TestPaginate(t *testing.T) {
+	cursorOf := func(n int) *domain.Cursor { return &domain.Cursor{ID: uint64(n)} }
+	tests := []struct {
+		name     string
+		items    []int
+		cursor   *domain.Cursor
+		want     []int
+		wantPrev *domain.Cursor
+		wantNext *domain.Cursor
+	}{
+		{
+			name:  "first page only",
+			items: []int{1, 2},
+			want:  []int{1, 2},
+		},
+		{
+			name:     "first page with more",
+			items:    []int{1, 2, 3, 4},
+			want:     []int{1, 2, 3},
+			wantNext: &domain.Cursor{ID: 3},
+		},
+		{
+			name:     "middle page",
+			items:    []int{4, 5, 6, 7},
+			cursor:   &domain.Cursor{ID: 3},
+			want:     []int{4, 5, 6},
+			wantPrev: &domain.Cursor{ID: 4, Backward: true},
+			wantNext: &domain.Cursor{ID: 6},
+		},
+		{
+			name:     "last page",
+			items:    []int{7, 8},
+			cursor:   &domain.Cursor{ID: 6},
+			want:     []int{7, 8},
+			wantPrev: &domain.Cursor{ID: 7, Backward: true},
+		},
+		{
+			name:     "previous page with more",
+			items:    []int{3, 4, 5, 6},
+			cursor:   &domain.Cursor{ID: 7, Backward: true},
+			want:     []int{4, 5, 6},
+			wantPrev: &domain.Cursor{ID: 4, Backward: true},
+			wantNext: &domain.Cursor{ID: 6},
+		},
+		{
+			name:     "previous page reaching the first",
+			items:    []int{1, 2},
+			cursor:   &domain.Cursor{ID: 3, Backward: true},
+			want:     []int{1, 2},
+			wantNext: &domain.Cursor{ID: 2},
+		},
+		{
+			name:   "empty page",
+			items:  []int{},
+			cursor: &domain.Cursor{ID: 8},
+			want:   []int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, page := paginate(tt.items, tt.cursor, 3, cursorOf)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("items = %v, want %v", got, tt.want)
+			}
+			if !equalCursor(page.Prev, tt.wantPrev) {
+				t.Errorf("prev = %+v, want %+v", page.Prev, tt.wantPrev)
+			}
+			if !equalCursor(page.Next, tt.wantNext) {
+				t.Errorf("next = %+v, want %+v", page.Next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func equalCursor(a, b *domain.Cursor) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// ListEntryRevisions はエントリのリビジョンを新しい順に検索する
+func (a *App) ListEntryRevisions(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry, page, limit int) ([]*domain.EntryRevision, bool, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, false, err
+	}
+	if entry.BlogID != blog.ID {
+		return nil, false, ErrNotFound
+	}
+	if page < 1 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+	repo := repository.NewRepository(a.db)
+	revisions, err := repo.EntryRevision().ListByEntryID(ctx, entry.ID, limit+1, offset)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(revisions) > limit {
+		return revisions[:limit], true, nil
+	}
+	return revisions, false, nil
+}
+
+// FindEntryRevisionByID は ID でエントリのリビジョンを検索する
+// ID がゼロ値の場合はエントリの現在の内容を返す
+func (a *App) FindEntryRevisionByID(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry, revisionID domain.EntryRevisionID) (*domain.EntryRevision, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionReadDrafts); err != nil {
+		return nil, err
+	}
+	if entry.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	if revisionID == 0 {
+		return entry.Revision(), nil
+	}
+	repo := repository.NewRepository(a.db)
+	revision, err := repo.EntryRevision().FindByID(ctx, revisionID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if revision.EntryID != entry.ID {
+		return nil, ErrNotFound
+	}
+	return revision, nil
+}
+
+// RestoreEntryRevision はエントリの内容をリビジョンの内容に戻す
+func (a *App) RestoreEntryRevision(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry, revision *domain.EntryRevision) (*domain.Entry, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, err
+	}
+	if entry.BlogID != blog.ID || revision.EntryID != entry.ID {
+		return nil, ErrNotFound
+	}
+	var restored *domain.Entry
+	err := a.withTx(ctx, "RestoreEntryRevision", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		restored, err = entry.RestoreRevision(revision, time.Now())(ctx, repo, a.entryRenderer(entry.BlogID, entry.Format))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.reindexEntry(ctx, restored); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// reindexEntry はエントリの公開状態に合わせて検索インデックスを更新する
+func (a *App) reindexEntry(ctx context.Context, entry *domain.Entry) error {
+	if entry.IsPublished() {
+		return a.searchIndex.Index(ctx, entry)
+	}
+	return a.searchIndex.Remove(ctx, entry.ID)
+}
+
+// SearchEntries はすべてのブログの公開されている, ブログとエントリの公開範囲がどちらも public のエントリを全文検索する
+// 見つかったエントリが属するブログもあわせて返す
+func (a *App) SearchEntries(ctx context.Context, query string, page, limit int) ([]*domain.Entry, map[domain.BlogID]*domain.Blog, bool, error) {
+	entries, hasNextPage, err := a.searchEntries(ctx, query, domain.SearchOptions{ListedOnly: true}, page, limit)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	blogIDs := make([]domain.BlogID, 0, len(entries))
+	for _, e := range entries {
+		blogIDs = append(blogIDs, e.BlogID)
+	}
+	repo := repository.NewRepository(a.db)
+	blogs, err := repo.Blog().ListByIDs(ctx, blogIDs)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	blogsByID := make(map[domain.BlogID]*domain.Blog, len(blogs))
+	for _, b := range blogs {
+		blogsByID[b.ID] = b
+	}
+	return entries, blogsByID, hasNextPage, nil
+}
+
+// SearchEntriesInBlog はブログの公開されているエントリを全文検索する
+// 下書きを読めるブログのメンバー以外には, 公開範囲が public のエントリのみを返す
+func (a *App) SearchEntriesInBlog(ctx context.Context, user *domain.User, blog *domain.Blog, query string, page, limit int) ([]*domain.Entry, bool, error) {
+	listedOnly, err := a.listedOnly(ctx, user, blog)
+	if err != nil {
+		return nil, false, err
+	}
+	return a.searchEntries(ctx, query, domain.SearchOptions{BlogID: blog.ID, ListedOnly: listedOnly}, page, limit)
+}
+
+func (a *App) searchEntries(ctx context.Context, query string, opts domain.SearchOptions, page, limit int) ([]*domain.Entry, bool, error) {
+	if utf8.RuneCountInString(query) > 200 {
+		return nil, false, ErrInvalidArgument
+	}
+	if page < 1 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+	hits, err := a.searchIndex.Search(ctx, query, opts, limit+1, offset)
+	if err != nil {
+		return nil, false, err
+	}
+	hasNextPage := false
+	if len(hits) > limit {
+		hits = hits[:limit]
+		hasNextPage = true
+	}
+	entryIDs := make([]domain.EntryID, 0, len(hits))
+	for _, h := range hits {
+		entryIDs = append(entryIDs, h.EntryID)
+	}
+	repo := repository.NewRepository(a.db)
+	found, err := repo.Entry().ListByIDs(ctx, entryIDs)
+	if err != nil {
+		return nil, false, err
+	}
+	// スコアの順に並べ直す. 検索してから読むまでの間に非公開にされたエントリは除く
+	byID := make(map[domain.EntryID]*domain.Entry, len(found))
+	for _, e := range found {
+		byID[e.ID] = e
+	}
+	entries := make([]*domain.Entry, 0, len(hits))
+	for _, h := range hits {
+		if e, ok := byID[h.EntryID]; ok && e.IsPublished() {
+			entries = append(entries, e)
+		}
+	}
+	return entries, hasNextPage, nil
+}
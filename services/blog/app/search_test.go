@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/search"
+)
+
+func TestReindexEntry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		status domain.EntryStatus
+		found  bool
+	}{
+		{"published", domain.EntryStatusPublished, true},
+		{"draft", domain.EntryStatusDraft, false},
+		{"unpublished", domain.EntryStatusUnpublished, false},
+		{"scheduled", domain.EntryStatusScheduled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{searchIndex: search.NewMemoryIndex()}
+			entry := &domain.Entry{ID: 1, BlogID: 1, Title: "全文検索", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic, PublishedAt: now}
+			if err := a.reindexEntry(ctx, entry); err != nil {
+				t.Fatal(err)
+			}
+			// 公開されなくなったエントリはインデックスから取り除く
+			changed := *entry
+			changed.Title = "検索の仕組み"
+			changed.Status = tt.status
+			if err := a.reindexEntry(ctx, &changed); err != nil {
+				t.Fatal(err)
+			}
+			hits, err := a.searchIndex.Search(ctx, "検索", domain.SearchOptions{ListedOnly: true}, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found := len(hits) == 1; found != tt.found {
+				t.Errorf("found = %v, want %v", found, tt.found)
+			}
+			if hits, _ := a.searchIndex.Search(ctx, "全文", domain.SearchOptions{}, 10, 0); len(hits) != 0 {
+				t.Errorf("found by the old title: %v", hits)
+			}
+		})
+	}
+}
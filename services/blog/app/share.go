@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// maxShareLinkLifetime は共有リンクの有効期間の上限
+const maxShareLinkLifetime = 90 * 24 * time.Hour
+
+// shareLinkPayloadSize は共有リンクのトークンのうち署名する部分の長さ
+// 共有リンクの ID と有効期限の Unix 時間をそれぞれ 8 バイトで並べる
+const shareLinkPayloadSize = 16
+
+// ShareLinkToken は共有リンクの URL に埋め込む署名付きのトークンを返す
+func (a *App) ShareLinkToken(link *domain.ShareLink) string {
+	payload := make([]byte, shareLinkPayloadSize, shareLinkPayloadSize+sha256.Size)
+	binary.BigEndian.PutUint64(payload[0:8], uint64(link.ID))
+	binary.BigEndian.PutUint64(payload[8:16], uint64(link.ExpiresAt.Unix()))
+	return base64.RawURLEncoding.EncodeToString(append(payload, a.signShareLink(payload)...))
+}
+
+func (a *App) signShareLink(payload []byte) []byte {
+	mac := hmac.New(sha256.New, a.shareLinkSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// parseShareLinkToken は共有リンクのトークンの署名を検証し, 共有リンクの ID と有効期限を取り出す
+func (a *App) parseShareLinkToken(token string) (domain.ShareLinkID, time.Time, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) != shareLinkPayloadSize+sha256.Size {
+		return 0, time.Time{}, false
+	}
+	payload, sig := b[:shareLinkPayloadSize], b[shareLinkPayloadSize:]
+	if !hmac.Equal(sig, a.signShareLink(payload)) {
+		return 0, time.Time{}, false
+	}
+	id := domain.ShareLinkID(binary.BigEndian.Uint64(payload[0:8]))
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[8:16])), 0)
+	return id, expiresAt, true
+}
+
+// ListShareLinks はエントリの共有リンクを検索する
+func (a *App) ListShareLinks(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry) ([]*domain.ShareLink, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, err
+	}
+	if entry.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	repo := repository.NewRepository(a.db)
+	return repo.ShareLink().ListByEntryID(ctx, entry.ID)
+}
+
+// CreateShareLink はエントリの共有リンクを作成する. lifetime は作成してからリンクが使えなくなるまでの期間
+func (a *App) CreateShareLink(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry, lifetime time.Duration) (*domain.ShareLink, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, err
+	}
+	if entry.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	if lifetime <= 0 || lifetime > maxShareLinkLifetime {
+		return nil, ErrInvalidArgument
+	}
+	now := time.Now()
+	repo := repository.NewRepository(a.db)
+	// トークンには秒単位で埋め込むので, 有効期限も秒単位に揃える
+	return entry.CreateShareLink(user, now.Add(lifetime).Truncate(time.Second), now)(ctx, repo)
+}
+
+// FindShareLink はエントリの共有リンクを ID で検索する
+func (a *App) FindShareLink(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry, linkID domain.ShareLinkID) (*domain.ShareLink, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	link, err := repo.ShareLink().FindByID(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if entry.BlogID != blog.ID || link.EntryID != entry.ID {
+		return nil, ErrNotFound
+	}
+	return link, nil
+}
+
+// RevokeShareLink は共有リンクを取り消す
+func (a *App) RevokeShareLink(ctx context.Context, user *domain.User, blog *domain.Blog, link *domain.ShareLink) error {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return err
+	}
+	repo := repository.NewRepository(a.db)
+	return link.Revoke()(ctx, repo)
+}
+
+// FindSharedEntry は共有リンクのトークンを使って共有されたエントリを検索する
+// トークンの署名が正しくない場合や, 有効期限が切れていたり取り消されていたりする場合は見つからないものとして扱う
+func (a *App) FindSharedEntry(ctx context.Context, blog *domain.Blog, token string) (*domain.Entry, error) {
+	linkID, expiresAt, ok := a.parseShareLinkToken(token)
+	if !ok || !time.Now().Before(expiresAt) {
+		return nil, ErrNotFound
+	}
+	repo := repository.NewRepository(a.db)
+	link, err := repo.ShareLink().FindByID(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !link.ExpiresAt.Equal(expiresAt) {
+		return nil, ErrNotFound
+	}
+	entry, err := repo.Entry().FindByID(ctx, link.EntryID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if entry.BlogID != blog.ID || entry.IsTrashed() || !entry.IsPublished() {
+		return nil, ErrNotFound
+	}
+	return entry, nil
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// StarEntry はユーザーがエントリにスターを付け, 付けた後のエントリのスターの数を返す. すでに付けている場合は何もしない
+// 公開されていないエントリにはスターを付けられない
+func (a *App) StarEntry(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry) (int, error) {
+	if entry.BlogID != blog.ID || !entry.IsPublished() || entry.IsTrashed() {
+		return 0, ErrNotFound
+	}
+	err := a.withTx(ctx, "StarEntry", func(ctx context.Context, repo domain.Repository) error {
+		return entry.Star(user, time.Now())(ctx, repo)
+	})
+	if err != nil {
+		return 0, err
+	}
+	counts, err := a.CountStars(ctx, []*domain.Entry{entry})
+	if err != nil {
+		return 0, err
+	}
+	return counts[entry.ID], nil
+}
+
+// UnstarEntry はユーザーがエントリに付けたスターを外し, 外した後のエントリのスターの数を返す. 付けていない場合は何もしない
+func (a *App) UnstarEntry(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry) (int, error) {
+	if entry.BlogID != blog.ID {
+		return 0, ErrNotFound
+	}
+	err := a.withTx(ctx, "UnstarEntry", func(ctx context.Context, repo domain.Repository) error {
+		return entry.Unstar(user)(ctx, repo)
+	})
+	if err != nil {
+		return 0, err
+	}
+	counts, err := a.CountStars(ctx, []*domain.Entry{entry})
+	if err != nil {
+		return 0, err
+	}
+	return counts[entry.ID], nil
+}
+
+// CountStars は複数のエントリのスターの数を返す. スターが付いていないエントリは結果に含まれない
+func (a *App) CountStars(ctx context.Context, entries []*domain.Entry) (map[domain.EntryID]int, error) {
+	entryIDs := make([]domain.EntryID, 0, len(entries))
+	for _, e := range entries {
+		entryIDs = append(entryIDs, e.ID)
+	}
+	repo := repository.NewRepository(a.db)
+	return repo.Star().CountByEntryIDs(ctx, entryIDs)
+}
+
+// ListStarredEntryIDs は複数のエントリのうち, ユーザーがスターを付けているものを返す
+// ログインしていない場合は空の結果を返す
+func (a *App) ListStarredEntryIDs(ctx context.Context, user *domain.User, entries []*domain.Entry) (map[domain.EntryID]bool, error) {
+	if user == nil {
+		return map[domain.EntryID]bool{}, nil
+	}
+	entryIDs := make([]domain.EntryID, 0, len(entries))
+	for _, e := range entries {
+		entryIDs = append(entryIDs, e.ID)
+	}
+	repo := repository.NewRepository(a.db)
+	return repo.Star().ListStarredEntryIDs(ctx, user.ID, entryIDs)
+}
+
+// ListStarredEntries はユーザーがスターを付けたエントリを, スターを付けた日時の新しい順に cursor の位置から, エントリのブログとともに検索する
+// cursor が nil の場合は先頭から検索する. スターを付けた後にユーザーが読めなくなったエントリは除くため, 1 ページが limit 件より少なくなることがある
+func (a *App) ListStarredEntries(ctx context.Context, user *domain.User, cursor *domain.Cursor, limit int) ([]*domain.Entry, map[domain.BlogID]*domain.Blog, *domain.Page, error) {
+	repo := repository.NewRepository(a.db)
+	stars, err := repo.Star().ListByUserID(ctx, user.ID, cursor, limit+1)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			return nil, nil, nil, ErrInvalidArgument
+		}
+		return nil, nil, nil, err
+	}
+	stars, page := paginate(stars, cursor, limit, func(s *domain.Star) *domain.Cursor {
+		return domain.NewTimeCursor(s.CreatedAt, uint64(s.EntryID))
+	})
+
+	entryIDs := make([]domain.EntryID, 0, len(stars))
+	blogIDs := make([]domain.BlogID, 0, len(stars))
+	for _, s := range stars {
+		entryIDs = append(entryIDs, s.EntryID)
+		blogIDs = append(blogIDs, s.BlogID)
+	}
+	found, err := repo.Entry().ListByIDs(ctx, entryIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	entriesByID := make(map[domain.EntryID]*domain.Entry, len(found))
+	for _, e := range found {
+		entriesByID[e.ID] = e
+	}
+	blogs, err := repo.Blog().ListByIDs(ctx, blogIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	blogsByID := make(map[domain.BlogID]*domain.Blog, len(blogs))
+	for _, b := range blogs {
+		blogsByID[b.ID] = b
+	}
+
+	entries := make([]*domain.Entry, 0, len(stars))
+	for _, s := range stars {
+		entry, ok := entriesByID[s.EntryID]
+		if !ok {
+			continue
+		}
+		blog, ok := blogsByID[entry.BlogID]
+		if !ok {
+			continue
+		}
+		if _, err := a.checkEntryReadable(ctx, user, blog, entry); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, nil, nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, blogsByID, page, nil
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// maxTagsPerEntry はエントリに付けられるタグの最大数
+const maxTagsPerEntry = 10
+
+// normalizeTagNames はタグの名前の前後の空白を取り除き, 空のものと重複したものを除く
+func normalizeTagNames(names []string) ([]string, error) {
+	normalized := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if utf8.RuneCountInString(name) > 64 {
+			return nil, ErrInvalidArgument
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	if len(normalized) > maxTagsPerEntry {
+		return nil, ErrInvalidArgument
+	}
+	return normalized, nil
+}
+
+// ListTagCountsByBlog はブログで使われているタグをエントリの数とともに検索する
+// 下書きを読めるブログのメンバー以外には, 公開範囲が public のエントリのみを数える
+func (a *App) ListTagCountsByBlog(ctx context.Context, user *domain.User, blog *domain.Blog) ([]*domain.TagCount, error) {
+	listedOnly, err := a.listedOnly(ctx, user, blog)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	return repo.Tag().ListCountsByBlogID(ctx, blog.ID, listedOnly)
+}
+
+// ListTagsByEntry はエントリに付けられたタグを検索する
+func (a *App) ListTagsByEntry(ctx context.Context, entry *domain.Entry) ([]*domain.Tag, error) {
+	repo := repository.NewRepository(a.db)
+	return repo.Tag().ListByEntryID(ctx, entry.ID)
+}
+
+// ListTagsByEntries は複数のエントリに付けられたタグをまとめて検索する
+func (a *App) ListTagsByEntries(ctx context.Context, entries []*domain.Entry) (map[domain.EntryID][]*domain.Tag, error) {
+	entryIDs := make([]domain.EntryID, 0, len(entries))
+	for _, e := range entries {
+		entryIDs = append(entryIDs, e.ID)
+	}
+	repo := repository.NewRepository(a.db)
+	return repo.Tag().ListByEntryIDs(ctx, entryIDs)
+}
+
+// ListEntriesByBlogAndTag はブログの公開されているエントリのうちタグが付けられたものを検索する
+// 下書きを読めるブログのメンバー以外には, 公開範囲が public のエントリのみを返す
+func (a *App) ListEntriesByBlogAndTag(ctx context.Context, user *domain.User, blog *domain.Blog, tagName string, page, limit int) ([]*domain.Entry, bool, error) {
+	listedOnly, err := a.listedOnly(ctx, user, blog)
+	if err != nil {
+		return nil, false, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+	repo := repository.NewRepository(a.db)
+	entries, err := repo.Entry().ListByBlogIDAndTagName(ctx, blog.ID, tagName, listedOnly, limit+1, offset)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(entries) > limit {
+		return entries[:limit], true, nil
+	}
+	return entries, false, nil
+}
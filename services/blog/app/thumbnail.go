@@ -0,0 +1,57 @@
+package app
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	_ "image/gif" // GIF の画像をデコードするために必要
+	"image/jpeg"
+	"image/png"
+)
+
+// makeThumbnail は画像を width x height に縮小し, contentType の形式にエンコードする
+// 縮小先の 1 ピクセルに対応する元の画像の範囲を平均する
+// 元の画像全体を RGBA に変換するとピクセル数の上限の画像で 160MB ほど使うので, 縮小先の 1 行に対応する行だけを変換しながら縮小する
+func makeThumbnail(img image.Image, width, height int, contentType string) ([]byte, error) {
+	b := img.Bounds()
+	// band は元の画像の行を変換しておく領域で, 縮小先の 1 行に対応する行数の分だけ確保する
+	band := image.NewRGBA(image.Rect(0, 0, b.Dx(), (b.Dy()+height-1)/height))
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*b.Dy()/height, max((y+1)*b.Dy()/height, y*b.Dy()/height+1)
+		draw.Draw(band, image.Rect(0, 0, b.Dx(), y1-y0), img, image.Pt(b.Min.X, b.Min.Y+y0), draw.Src)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*b.Dx()/width, max((x+1)*b.Dx()/width, x*b.Dx()/width+1)
+			var r, g, bl, a, n uint64
+			for sy := 0; sy < y1-y0; sy++ {
+				row := band.Pix[sy*band.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					bl += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+)
+
+// naiveThumbnail は元の画像全体を RGBA に変換してから縮小する, makeThumbnail と同じ結果になるはずの実装
+func naiveThumbnail(img image.Image, width, height int) *image.RGBA {
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*b.Dy()/height, max((y+1)*b.Dy()/height, y*b.Dy()/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*b.Dx()/width, max((x+1)*b.Dx()/width, x*b.Dx()/width+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					p := src.RGBAAt(sx, sy)
+					r += uint64(p.R)
+					g += uint64(p.G)
+					bl += uint64(p.B)
+					a += uint64(p.A)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(bl / n), uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+func fill(img draw.Image) draw.Image {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x * 7), uint8(y * 13), uint8(x ^ y), uint8(128 + x%128)})
+		}
+	}
+	return img
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	palette := color.Palette{color.Black, color.White, color.RGBA{255, 0, 0, 255}, color.Transparent}
+	tests := []struct {
+		name          string
+		img           image.Image
+		width, height int
+	}{
+		{"rgba", fill(image.NewRGBA(image.Rect(0, 0, 97, 61))), 20, 13},
+		{"nrgba", fill(image.NewNRGBA(image.Rect(0, 0, 64, 64))), 16, 16},
+		{"gray", fill(image.NewGray(image.Rect(0, 0, 33, 90))), 7, 19},
+		{"paletted", fill(image.NewPaletted(image.Rect(0, 0, 50, 40), palette)), 25, 20},
+		{"ycbcr", image.NewYCbCr(image.Rect(0, 0, 41, 29), image.YCbCrSubsampleRatio420), 10, 7},
+		{"not at origin", fill(image.NewRGBA(image.Rect(0, 0, 120, 80))).(*image.RGBA).SubImage(image.Rect(15, 9, 110, 71)), 19, 12},
+		{"same size", fill(image.NewRGBA(image.Rect(0, 0, 10, 10))), 10, 10},
+		{"single row", fill(image.NewRGBA(image.Rect(0, 0, 300, 1))), 30, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := makeThumbnail(tt.img, tt.width, tt.height, "image/png")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Bounds() != image.Rect(0, 0, tt.width, tt.height) {
+				t.Fatalf("bounds = %v, want %dx%d", got.Bounds(), tt.width, tt.height)
+			}
+			want := naiveThumbnail(tt.img, tt.width, tt.height)
+			gotRGBA := image.NewRGBA(got.Bounds())
+			draw.Draw(gotRGBA, got.Bounds(), got, image.Point{}, draw.Src)
+			for y := 0; y < tt.height; y++ {
+				for x := 0; x < tt.width; x++ {
+					// PNG は premultiplied でない色で保存するので, 透明に近い色は丸めの分だけずれる
+					g, w := gotRGBA.RGBAAt(x, y), want.RGBAAt(x, y)
+					if diff(g.R, w.R) > 2 || diff(g.G, w.G) > 2 || diff(g.B, w.B) > 2 || g.A != w.A {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, g, w)
+					}
+				}
+			}
+		})
+	}
+}
+
+func diff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// TrashRetention はゴミ箱に移動したブログやエントリを完全に削除するまで残しておく期間を返す
+func (a *App) TrashRetention() time.Duration {
+	return a.trashRetention
+}
+
+// TrashEntry はエントリをゴミ箱に移動する
+func (a *App) TrashEntry(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry) error {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return err
+	}
+	if entry.BlogID != blog.ID {
+		return ErrNotFound
+	}
+	return a.withTx(ctx, "TrashEntry", func(ctx context.Context, repo domain.Repository) error {
+		_, err := entry.Trash(time.Now())(ctx, repo)
+		return err
+	})
+}
+
+// ListTrash はユーザーのゴミ箱にあるブログとエントリを検索する
+// エントリが属するブログもあわせて返す
+func (a *App) ListTrash(ctx context.Context, user *domain.User, limit int) ([]*domain.Blog, []*domain.Entry, map[domain.BlogID]*domain.Blog, error) {
+	repo := repository.NewRepository(a.db)
+	blogs, err := repo.Blog().ListTrashedByUserID(ctx, user.ID, limit)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	entries, err := repo.Entry().ListTrashedByUserID(ctx, user.ID, limit)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	blogIDs := make([]domain.BlogID, 0, len(entries))
+	for _, e := range entries {
+		blogIDs = append(blogIDs, e.BlogID)
+	}
+	entryBlogs, err := repo.Blog().ListByIDs(ctx, blogIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	entryBlogsByID := make(map[domain.BlogID]*domain.Blog, len(entryBlogs))
+	for _, b := range entryBlogs {
+		entryBlogsByID[b.ID] = b
+	}
+	return blogs, entries, entryBlogsByID, nil
+}
+
+// RestoreBlog はゴミ箱にあるブログを元に戻す
+// ゴミ箱に残しておく期間を過ぎたブログは, 完全に削除される前でも見つからないものとして扱う
+func (a *App) RestoreBlog(ctx context.Context, user *domain.User, blogID domain.BlogID) (*domain.Blog, error) {
+	var restored *domain.Blog
+	err := a.withTx(ctx, "RestoreBlog", func(ctx context.Context, repo domain.Repository) error {
+		blog, err := repo.Blog().FindByID(ctx, blogID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if !blog.IsTrashed() || !blog.PurgeAt(a.trashRetention).After(time.Now()) {
+			return ErrNotFound
+		}
+		if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+			return err
+		}
+		restored, err = blog.Restore()(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// RestoreEntry はゴミ箱にあるエントリを元に戻す
+// ゴミ箱に残しておく期間を過ぎたエントリや, ゴミ箱にあるブログのエントリは見つからないものとして扱う
+func (a *App) RestoreEntry(ctx context.Context, user *domain.User, entryID domain.EntryID) (*domain.Entry, *domain.Blog, error) {
+	repo := repository.NewRepository(a.db)
+	entry, err := repo.Entry().FindByID(ctx, entryID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	if !entry.IsTrashed() || !entry.PurgeAt(a.trashRetention).After(time.Now()) {
+		return nil, nil, ErrNotFound
+	}
+	blog, err := repo.Blog().FindByID(ctx, entry.BlogID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	if blog.IsTrashed() {
+		return nil, nil, ErrNotFound
+	}
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, nil, err
+	}
+	var restored *domain.Entry
+	err = a.withTx(ctx, "RestoreEntry", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		restored, err = entry.Restore(time.Now())(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return restored, blog, nil
+}
+
+// PurgeTrash はゴミ箱に残しておく期間を過ぎたブログとエントリを完全に削除する
+// ブログはそのエントリとともに削除し, 検索インデックスからも取り除く
+func (a *App) PurgeTrash(ctx context.Context, now time.Time, limit int) ([]*domain.Blog, []*domain.Entry, error) {
+	before := now.Add(-a.trashRetention)
+	repo := repository.NewRepository(a.db)
+	blogs, err := repo.Blog().ListTrashedBefore(ctx, before, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, b := range blogs {
+		media, err := a.listAllMedia(ctx, b)
+		if err != nil {
+			return nil, nil, err
+		}
+		exports, err := repo.BlogExport().ListByBlogID(ctx, b.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = a.withTx(ctx, "PurgeBlog", func(ctx context.Context, repo domain.Repository) error {
+			return b.Purge()(ctx, repo)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := a.searchIndex.RemoveByBlogID(ctx, b.ID); err != nil {
+			return nil, nil, err
+		}
+		for _, m := range media {
+			a.deleteMediaFiles(ctx, m)
+		}
+		for _, e := range exports {
+			a.deleteBlogExportFile(ctx, e)
+		}
+	}
+	entries, err := repo.Entry().ListTrashedBefore(ctx, before, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range entries {
+		err := a.withTx(ctx, "PurgeEntry", func(ctx context.Context, repo domain.Repository) error {
+			return e.Purge()(ctx, repo)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := a.searchIndex.Remove(ctx, e.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return blogs, entries, nil
+}
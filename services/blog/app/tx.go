@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+var tracer = otel.Tracer("app")
+
+// withTx は fn を 1 つのトランザクション内で実行する
+// fn がエラーを返した場合はロールバックし, そうでなければコミットする
+// name はトレースのスパンの名前に使う
+func (a *App) withTx(ctx context.Context, name string, fn func(ctx context.Context, repo domain.Repository) error) error {
+	return a.withTxOptions(ctx, name, nil, fn)
+}
+
+// withSnapshot は fn を 1 つの読み取り専用のトランザクション内で実行する
+// fn の中の検索は, すべて最初の検索の時点の同じスナップショットを読む
+func (a *App) withSnapshot(ctx context.Context, name string, fn func(ctx context.Context, repo domain.Repository) error) error {
+	return a.withTxOptions(ctx, name, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, fn)
+}
+
+func (a *App) withTxOptions(ctx context.Context, name string, opts *sql.TxOptions, fn func(ctx context.Context, repo domain.Repository) error) (err error) {
+	ctx, span := tracer.Start(ctx, "tx."+name)
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, "transaction rolled back")
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
+	tx, err := a.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// コミット済みの場合は何もしない
+		_ = tx.Rollback()
+	}()
+	if err := fn(ctx, repository.NewRepository(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
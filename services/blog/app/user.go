@@ -35,18 +35,24 @@ func (a *App) Signup(ctx context.Context, name, password string, sessionExpiresA
 	if err != nil {
 		return nil, nil, err
 	}
-	repo := repository.NewRepository(a.db)
-	user, err := domain.CreateUser(stub.AccountID, stub.Name)(ctx, repo)
+	// アカウントはアカウントサービスに作成済みなので, ユーザーの作成に失敗した場合は Signin で作成し直す
+	var user *domain.User
+	var sess *domain.Session
+	err = a.withTx(ctx, "Signup", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		user, err = domain.CreateUser(stub.AccountID, stub.Name, time.Now())(ctx, repo)
+		if err != nil {
+			return err
+		}
+		sess, err = user.StartSession(sessionExpiresAt)(ctx, repo)
+		return err
+	})
 	if err != nil {
 		if errors.Is(err, domain.ErrAlreadyExists) {
 			return nil, nil, ErrAlreadyRegistered
 		}
 		return nil, nil, err
 	}
-	sess, err := user.StartSession(sessionExpiresAt)(ctx, repo)
-	if err != nil {
-		return nil, nil, err
-	}
 	return user, sess, nil
 }
 
@@ -70,21 +76,26 @@ func (a *App) Signin(ctx context.Context, name, password string, sessionExpiresA
 	if err != nil {
 		return nil, nil, err
 	}
-	repo := repository.NewRepository(a.db)
-	user, err := repo.User().FindByAccountID(ctx, stub.AccountID)
-	if err != nil {
-		if !errors.Is(err, domain.ErrNotFound) {
-			return nil, nil, err
-		}
-		user, err = domain.CreateUser(stub.AccountID, stub.Name)(ctx, repo)
+	var user *domain.User
+	var sess *domain.Session
+	err = a.withTx(ctx, "Signin", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		user, err = repo.User().FindByAccountID(ctx, stub.AccountID)
 		if err != nil {
-			if errors.Is(err, domain.ErrAlreadyExists) {
-				return nil, nil, errors.New("invalid state")
+			if !errors.Is(err, domain.ErrNotFound) {
+				return err
+			}
+			user, err = domain.CreateUser(stub.AccountID, stub.Name, time.Now())(ctx, repo)
+			if err != nil {
+				if errors.Is(err, domain.ErrAlreadyExists) {
+					return errors.New("invalid state")
+				}
+				return err
 			}
-			return nil, nil, err
 		}
-	}
-	sess, err := user.StartSession(sessionExpiresAt)(ctx, repo)
+		sess, err = user.StartSession(sessionExpiresAt)(ctx, repo)
+		return err
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -156,3 +167,16 @@ func (a *App) FindUserBySessionKey(ctx context.Context, key string) (*domain.Use
 	}
 	return user, sess, nil
 }
+
+// FindUserByName はユーザー名でユーザーを検索する
+func (a *App) FindUserByName(ctx context.Context, name string) (*domain.User, error) {
+	repo := repository.NewRepository(a.db)
+	user, err := repo.User().FindByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// CanViewBlog はユーザーがブログのページを読めるかを判定する
+// 公開範囲が private のブログは下書きを読めるブログのメンバーだけが読める
+func (a *App) CanViewBlog(ctx context.Context, user *domain.User, blog *domain.Blog) (bool, error) {
+	if blog.Visibility != domain.VisibilityPrivate {
+		return true, nil
+	}
+	return a.HasPermission(ctx, user, blog, domain.PermissionReadDrafts)
+}
+
+// listedOnly は一覧に公開範囲が public のエントリのみを載せるべきかを判定する
+// 下書きを読めるブログのメンバーには公開範囲によらずすべてのエントリを見せる
+func (a *App) listedOnly(ctx context.Context, user *domain.User, blog *domain.Blog) (bool, error) {
+	canRead, err := a.HasPermission(ctx, user, blog, domain.PermissionReadDrafts)
+	if err != nil {
+		return false, err
+	}
+	return !canRead, nil
+}
+
+// SetBlogVisibility はブログの公開範囲を変更する
+func (a *App) SetBlogVisibility(ctx context.Context, user *domain.User, blog *domain.Blog, visibility domain.Visibility) (*domain.Blog, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	if _, ok := domain.ParseVisibility(string(visibility)); !ok {
+		return nil, ErrInvalidArgument
+	}
+	repo := repository.NewRepository(a.db)
+	return blog.SetVisibility(visibility)(ctx, repo)
+}
+
+// SetEntryVisibility はエントリの公開範囲を変更する
+func (a *App) SetEntryVisibility(ctx context.Context, user *domain.User, blog *domain.Blog, entry *domain.Entry, visibility domain.Visibility) (*domain.Entry, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionWriteEntries); err != nil {
+		return nil, err
+	}
+	if entry.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	if _, ok := domain.ParseVisibility(string(visibility)); !ok {
+		return nil, ErrInvalidArgument
+	}
+	repo := repository.NewRepository(a.db)
+	return entry.SetVisibility(visibility)(ctx, repo)
+}
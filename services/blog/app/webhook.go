@@ -0,0 +1,310 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// MaxWebhooksPerBlog は 1 つのブログに登録できる Webhook の数の上限
+const MaxWebhooksPerBlog = 10
+
+// WebhookDeliveryRetention は Webhook の配信の記録を残しておく期間
+const WebhookDeliveryRetention = 30 * 24 * time.Hour
+
+// webhookDeliveryLimit は配信の記録のページに表示する配信の数
+const webhookDeliveryLimit = 50
+
+// webhookDeliveryLease は配信を送り始めてから, 送っていたプロセスが止まったものとして送り直すまでの時間
+const webhookDeliveryLease = 5 * time.Minute
+
+// webhookConcurrency は同時に送る配信の数
+const webhookConcurrency = 10
+
+// Webhook のリクエストに付けるヘッダ
+const (
+	webhookEventHeader     = "X-Blog-Event"
+	webhookDeliveryHeader  = "X-Blog-Delivery"
+	webhookSignatureHeader = "X-Blog-Signature-256"
+)
+
+// CreateWebhook はブログに Webhook を登録する. URL は http か https の絶対 URL でなければならない
+func (a *App) CreateWebhook(ctx context.Context, user *domain.User, blog *domain.Blog, webhookURL string) (*domain.Webhook, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	if len(webhookURL) > 2048 {
+		return nil, ErrInvalidArgument
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return nil, ErrInvalidArgument
+	}
+	var webhook *domain.Webhook
+	err = a.withTx(ctx, "CreateWebhook", func(ctx context.Context, repo domain.Repository) error {
+		webhooks, err := repo.Webhook().ListByBlogID(ctx, blog.ID)
+		if err != nil {
+			return err
+		}
+		if len(webhooks) >= MaxWebhooksPerBlog {
+			return ErrInvalidArgument
+		}
+		webhook, err = blog.CreateWebhook(u.String(), time.Now())(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// ListWebhooks はブログの Webhook を登録した順に返す
+func (a *App) ListWebhooks(ctx context.Context, user *domain.User, blog *domain.Blog) ([]*domain.Webhook, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	return repo.Webhook().ListByBlogID(ctx, blog.ID)
+}
+
+// FindWebhook はブログの Webhook を ID で検索する
+func (a *App) FindWebhook(ctx context.Context, user *domain.User, blog *domain.Blog, webhookID domain.WebhookID) (*domain.Webhook, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(a.db)
+	webhook, err := repo.Webhook().FindByID(ctx, webhookID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if webhook.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook は Webhook をその配信とともに削除する
+func (a *App) DeleteWebhook(ctx context.Context, user *domain.User, blog *domain.Blog, webhook *domain.Webhook) error {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return err
+	}
+	if webhook.BlogID != blog.ID {
+		return ErrNotFound
+	}
+	return a.withTx(ctx, "DeleteWebhook", func(ctx context.Context, repo domain.Repository) error {
+		return webhook.Delete()(ctx, repo)
+	})
+}
+
+// ListWebhookDeliveries は Webhook の最近の配信を新しい順に返す
+func (a *App) ListWebhookDeliveries(ctx context.Context, user *domain.User, blog *domain.Blog, webhook *domain.Webhook) ([]*domain.WebhookDelivery, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	if webhook.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	repo := repository.NewRepository(a.db)
+	return repo.WebhookDelivery().ListByWebhookID(ctx, webhook.ID, webhookDeliveryLimit)
+}
+
+// RedeliverWebhook は Webhook の配信と同じペイロードを送り直す. 送るのはスケジューラーがバックグラウンドで行う
+func (a *App) RedeliverWebhook(ctx context.Context, user *domain.User, blog *domain.Blog, webhook *domain.Webhook, deliveryID domain.WebhookDeliveryID) (*domain.WebhookDelivery, error) {
+	if err := a.Authorize(ctx, user, blog, domain.PermissionManageBlog); err != nil {
+		return nil, err
+	}
+	if webhook.BlogID != blog.ID {
+		return nil, ErrNotFound
+	}
+	repo := repository.NewRepository(a.db)
+	delivery, err := repo.WebhookDelivery().FindByID(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if delivery.WebhookID != webhook.ID {
+		return nil, ErrNotFound
+	}
+	return delivery.Redeliver(traceParentFromContext(ctx), time.Now())(ctx, repo)
+}
+
+// notifyWebhooks はドメインイベントを購読して, ブログの Webhook にできごとを通知する配信を登録する
+// 配信の中身はイベントを受け取ったときのブログとエントリから作る. 削除されていた場合は通知しない
+func (a *App) notifyWebhooks(ctx context.Context, repo domain.Repository, event *domain.Event) error {
+	de, err := event.Decode()
+	if err != nil {
+		return err
+	}
+	var blogID domain.BlogID
+	var entryID domain.EntryID
+	var webhookEvent domain.WebhookEvent
+	switch e := de.(type) {
+	case *domain.EntryPublished:
+		blogID, entryID, webhookEvent = e.BlogID, e.EntryID, domain.WebhookEventEntryPublished
+	case *domain.EntryEdited:
+		// 読者に見えていないエントリの編集は通知しない
+		if e.Status != domain.EntryStatusPublished {
+			return nil
+		}
+		blogID, entryID, webhookEvent = e.BlogID, e.EntryID, domain.WebhookEventEntryEdited
+	case *domain.EntryUnpublished:
+		blogID, entryID, webhookEvent = e.BlogID, e.EntryID, domain.WebhookEventEntryUnpublished
+	case *domain.BlogEdited:
+		blogID, webhookEvent = e.BlogID, domain.WebhookEventBlogEdited
+	case *domain.BlogDeleted:
+		blogID, webhookEvent = e.BlogID, domain.WebhookEventBlogDeleted
+	default:
+		return nil
+	}
+	blog, err := repo.Blog().FindByID(ctx, blogID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	// ゴミ箱にあるブログのできごとは, ゴミ箱に移動したこと以外は通知しない
+	if blog.IsTrashed() && webhookEvent != domain.WebhookEventBlogDeleted {
+		return nil
+	}
+	var entry *domain.Entry
+	if entryID != 0 {
+		entry, err = repo.Entry().FindByID(ctx, entryID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		// エントリのゴミ箱への移動は, 移動した後のエントリではなく非公開になったものとして通知する
+		if webhookEvent == domain.WebhookEventEntryUnpublished && entry.IsPublished() {
+			unpublished := *entry
+			unpublished.Status = domain.EntryStatusUnpublished
+			entry = &unpublished
+		}
+	}
+	_, err = blog.NotifyWebhooks(webhookEvent, entry, event.OccurredAt, event.TraceParent)(ctx, repo)
+	return err
+}
+
+// DeliverWebhooks は次に送る日時を過ぎた Webhook の配信を送り, 送った配信を返す
+// 失敗したものは送った回数に応じて間隔を空けて送り直すように記録する
+func (a *App) DeliverWebhooks(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	var started []*domain.WebhookDelivery
+	err := a.withTx(ctx, "StartWebhookDeliveries", func(ctx context.Context, repo domain.Repository) error {
+		var err error
+		started, err = domain.StartWebhookDeliveries(now, now.Add(webhookDeliveryLease), limit)(ctx, repo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	finished := make([]*domain.WebhookDelivery, len(started))
+	errs := make([]error, len(started))
+	sem := make(chan struct{}, webhookConcurrency)
+	var wg sync.WaitGroup
+	for i, d := range started {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			finished[i], errs[i] = a.deliverWebhook(ctx, d)
+		}()
+	}
+	wg.Wait()
+
+	delivered := make([]*domain.WebhookDelivery, 0, len(finished))
+	for _, d := range finished {
+		if d != nil {
+			delivered = append(delivered, d)
+		}
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// deliverWebhook は 1 つの配信を送り, 結果を記録する
+// できごとがあったリクエストのトレースにつなげたスパンの中で送り, そのトレースコンテキストをリクエストに付ける
+func (a *App) deliverWebhook(ctx context.Context, delivery *domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	// できごとがあったリクエストのトレースがなければ, スケジューラーのスパンの子にする
+	// 停止するときに送っている途中のリクエストを中断できるよう, どちらの場合も ctx のキャンセルは引き継ぐ
+	startCtx := ctx
+	opts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindClient)}
+	parent := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier{"traceparent": delivery.TraceParent})
+	if sc := trace.SpanContextFromContext(parent); sc.IsValid() {
+		startCtx = trace.ContextWithRemoteSpanContext(ctx, sc)
+		opts = append(opts, trace.WithLinks(trace.LinkFromContext(ctx)))
+	}
+	sendCtx, span := tracer.Start(startCtx, "webhook.Deliver", append(opts,
+		trace.WithAttributes(
+			attribute.String("webhook.event", string(delivery.Event)),
+			attribute.String("webhook.delivery_id", delivery.ID.String()),
+			attribute.Int("webhook.attempt", delivery.Attempts),
+		),
+	)...)
+	defer span.End()
+
+	repo := repository.NewRepository(a.db)
+	webhook, err := repo.Webhook().FindByID(ctx, delivery.WebhookID)
+	if err != nil {
+		// 送る前に Webhook とともに削除された配信は何もしない
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	body := []byte(delivery.Payload)
+	statusCode, err := a.webhookSender.Send(sendCtx, webhook.URL, map[string]string{
+		webhookEventHeader:     string(delivery.Event),
+		webhookDeliveryHeader:  delivery.ID.String(),
+		webhookSignatureHeader: webhook.Sign(body),
+	}, body)
+	if err != nil && ctx.Err() != nil {
+		// 停止するときに中断したものは, 次に起動したときにリースが切れてから送り直す
+		return nil, ctx.Err()
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	if err == nil && statusCode >= 200 && statusCode < 300 {
+		return delivery.Succeed(statusCode)(ctx, repo)
+	}
+	reason := fmt.Sprintf("unexpected status code: %d", statusCode)
+	if err != nil {
+		reason = err.Error()
+	}
+	span.SetStatus(codes.Error, "failed to deliver webhook")
+	return delivery.Fail(statusCode, truncateString(reason, 1024), time.Now())(ctx, repo)
+}
+
+// PurgeExpiredWebhookDeliveries は残しておく期間を過ぎた Webhook の配信の記録を最大 limit 件削除し, 削除した件数を返す
+func (a *App) PurgeExpiredWebhookDeliveries(ctx context.Context, now time.Time, limit int) (int, error) {
+	repo := repository.NewRepository(a.db)
+	return repo.WebhookDelivery().DeleteCreatedBefore(ctx, now.Add(-WebhookDeliveryRetention), limit)
+}
+
+// traceParentFromContext は ctx のトレースコンテキストを W3C Trace Context の traceparent の形で返す
+// トレースしていない場合は空文字列を返す
+func traceParentFromContext(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
@@ -0,0 +1,73 @@
+// Package atompub ははてなブログの AtomPub API と互換のある XML の読み書きと, WSSE 認証を扱う
+package atompub
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+const (
+	// ServiceContentType はサービス文書の Content-Type
+	ServiceContentType = "application/atomsvc+xml; charset=utf-8"
+	// FeedContentType はコレクションの一覧の Content-Type
+	FeedContentType = "application/atom+xml; charset=utf-8"
+	// EntryContentType はエントリの Content-Type
+	EntryContentType = "application/atom+xml; type=entry; charset=utf-8"
+)
+
+const (
+	nsAtom       = "http://www.w3.org/2005/Atom"
+	nsApp        = "http://www.w3.org/2007/app"
+	nsHatena     = "http://www.hatena.ne.jp/info/xmlns#"
+	nsHatenablog = "http://www.hatena.ne.jp/info/xmlns#hatenablog"
+)
+
+// Service はブログのサービス文書を表す. ブログごとにエントリのコレクションを 1 つ持つ
+type Service struct {
+	Title string
+	// CollectionURL はエントリのコレクションの URL
+	CollectionURL string
+}
+
+type xmlService struct {
+	XMLName   xml.Name     `xml:"service"`
+	Xmlns     string       `xml:"xmlns,attr"`
+	XmlnsAtom string       `xml:"xmlns:atom,attr"`
+	Workspace xmlWorkspace `xml:"workspace"`
+}
+
+type xmlWorkspace struct {
+	Title      string        `xml:"atom:title"`
+	Collection xmlCollection `xml:"collection"`
+}
+
+type xmlCollection struct {
+	Href   string `xml:"href,attr"`
+	Title  string `xml:"atom:title"`
+	Accept string `xml:"accept"`
+}
+
+// WriteService はサービス文書 (RFC 5023) を書き出す
+func (s *Service) WriteService(w io.Writer) error {
+	return writeXML(w, &xmlService{
+		Xmlns:     nsApp,
+		XmlnsAtom: nsAtom,
+		Workspace: xmlWorkspace{
+			Title: s.Title,
+			Collection: xmlCollection{
+				Href:   s.CollectionURL,
+				Title:  s.Title,
+				Accept: "application/atom+xml;type=entry",
+			},
+		},
+	})
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
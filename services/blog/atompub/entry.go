@@ -0,0 +1,251 @@
+package atompub
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrInvalidEntry は送られてきたエントリの XML が読めないときに返される
+var ErrInvalidEntry = errors.New("invalid entry")
+
+const (
+	// ContentTypeMarkdown は Markdown で書かれた本文の type
+	ContentTypeMarkdown = "text/x-markdown"
+	// ContentTypeHTML は HTML で書かれた本文の type
+	ContentTypeHTML = "text/html"
+)
+
+// Entry はコレクションのエントリを表す
+type Entry struct {
+	// ID はエントリを一意に識別する IRI
+	ID string
+	// EditURL はエントリを取得, 編集, 削除する URL
+	EditURL string
+	// AlternateURL はエントリの HTML ページの URL
+	AlternateURL string
+	Author       string
+	Title        string
+	// Published はエントリの公開日時. はてなブログと同じく updated にもこの日時を書く
+	Published time.Time
+	// Edited はエントリを最後に編集した日時
+	Edited time.Time
+	// Content はエントリの本文で, ContentType はその記法
+	Content     string
+	ContentType string
+	// FormattedContent は本文を HTML に変換したもの
+	FormattedContent string
+	Categories       []string
+	// CustomURL はエントリのスラッグ. スラッグを指定していない場合は空にする
+	CustomURL string
+	// Draft は公開されていない (予約投稿を除く) エントリであることを表す
+	Draft bool
+}
+
+// Feed はコレクションのエントリの一覧の 1 ページを表す
+type Feed struct {
+	ID           string
+	Title        string
+	Author       string
+	Updated      time.Time
+	AlternateURL string
+	// FirstURL は一覧の最初のページの URL
+	FirstURL string
+	// NextURL は一覧の次のページの URL. 次のページがない場合は空にする
+	NextURL string
+	Entries []*Entry
+}
+
+type xmlFeed struct {
+	XMLName         xml.Name    `xml:"feed"`
+	Xmlns           string      `xml:"xmlns,attr"`
+	XmlnsApp        string      `xml:"xmlns:app,attr"`
+	XmlnsHatena     string      `xml:"xmlns:hatena,attr"`
+	XmlnsHatenablog string      `xml:"xmlns:hatenablog,attr"`
+	ID              string      `xml:"id"`
+	Title           string      `xml:"title"`
+	Links           []xmlLink   `xml:"link"`
+	Updated         string      `xml:"updated"`
+	Author          xmlAuthor   `xml:"author"`
+	Entries         []*xmlEntry `xml:"entry"`
+}
+
+// xmlEntry は書き出すエントリ. フィードの中に書くときは名前空間の宣言を省く
+type xmlEntry struct {
+	XMLName          xml.Name      `xml:"entry"`
+	Xmlns            string        `xml:"xmlns,attr,omitempty"`
+	XmlnsApp         string        `xml:"xmlns:app,attr,omitempty"`
+	XmlnsHatena      string        `xml:"xmlns:hatena,attr,omitempty"`
+	XmlnsHatenablog  string        `xml:"xmlns:hatenablog,attr,omitempty"`
+	ID               string        `xml:"id"`
+	Links            []xmlLink     `xml:"link"`
+	Author           xmlAuthor     `xml:"author"`
+	Title            string        `xml:"title"`
+	Updated          string        `xml:"updated"`
+	Published        string        `xml:"published"`
+	Edited           string        `xml:"app:edited"`
+	Content          xmlContent    `xml:"content"`
+	FormattedContent xmlContent    `xml:"hatena:formatted-content"`
+	Categories       []xmlCategory `xml:"category"`
+	CustomURL        string        `xml:"hatenablog:custom-url,omitempty"`
+	Control          xmlControl    `xml:"app:control"`
+}
+
+type xmlLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type xmlAuthor struct {
+	Name string `xml:"name"`
+}
+
+type xmlContent struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type xmlCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type xmlControl struct {
+	Draft string `xml:"app:draft"`
+}
+
+func (e *Entry) toXML() *xmlEntry {
+	x := &xmlEntry{
+		ID: e.ID,
+		Links: []xmlLink{
+			{Rel: "edit", Href: e.EditURL},
+			{Rel: "alternate", Type: "text/html", Href: e.AlternateURL},
+		},
+		Author:           xmlAuthor{Name: e.Author},
+		Title:            e.Title,
+		Updated:          formatTime(e.Published),
+		Published:        formatTime(e.Published),
+		Edited:           formatTime(e.Edited),
+		Content:          xmlContent{Type: e.ContentType, Body: e.Content},
+		FormattedContent: xmlContent{Type: "text/html", Body: e.FormattedContent},
+		Categories:       make([]xmlCategory, 0, len(e.Categories)),
+		CustomURL:        e.CustomURL,
+		Control:          xmlControl{Draft: yesNo(e.Draft)},
+	}
+	for _, c := range e.Categories {
+		x.Categories = append(x.Categories, xmlCategory{Term: c})
+	}
+	return x
+}
+
+// WriteEntry はエントリを 1 件だけ書き出す
+func (e *Entry) WriteEntry(w io.Writer) error {
+	x := e.toXML()
+	x.Xmlns = nsAtom
+	x.XmlnsApp = nsApp
+	x.XmlnsHatena = nsHatena
+	x.XmlnsHatenablog = nsHatenablog
+	return writeXML(w, x)
+}
+
+// WriteFeed はコレクションのエントリの一覧を書き出す
+func (f *Feed) WriteFeed(w io.Writer) error {
+	x := &xmlFeed{
+		Xmlns:           nsAtom,
+		XmlnsApp:        nsApp,
+		XmlnsHatena:     nsHatena,
+		XmlnsHatenablog: nsHatenablog,
+		ID:              f.ID,
+		Title:           f.Title,
+		Links: []xmlLink{
+			{Rel: "first", Href: f.FirstURL},
+			{Rel: "alternate", Type: "text/html", Href: f.AlternateURL},
+		},
+		Updated: formatTime(f.Updated),
+		Author:  xmlAuthor{Name: f.Author},
+		Entries: make([]*xmlEntry, 0, len(f.Entries)),
+	}
+	if f.NextURL != "" {
+		x.Links = append(x.Links, xmlLink{Rel: "next", Href: f.NextURL})
+	}
+	for _, e := range f.Entries {
+		x.Entries = append(x.Entries, e.toXML())
+	}
+	return writeXML(w, x)
+}
+
+// EntryInput はクライアントから送られてきたエントリの内容
+type EntryInput struct {
+	Title string
+	// Content はエントリの本文. 本文の記法によらず, エントリの記法で書かれたものとして扱う
+	Content    string
+	Categories []string
+	// CustomURL はエントリのスラッグ. 空の場合はスラッグを指定しない
+	CustomURL string
+	Draft     bool
+	// Updated はエントリの公開日時. 指定されていない場合はゼロ値
+	Updated time.Time
+}
+
+// xmlEntryInput は読み取るエントリ
+// クライアントによって名前空間の書き方が異なるので, 名前空間を指定せずに要素の名前だけで読む
+type xmlEntryInput struct {
+	XMLName    xml.Name      `xml:"entry"`
+	Title      string        `xml:"title"`
+	Updated    string        `xml:"updated"`
+	Content    xmlContent    `xml:"content"`
+	Categories []xmlCategory `xml:"category"`
+	CustomURL  string        `xml:"custom-url"`
+	Control    struct {
+		Draft string `xml:"draft"`
+	} `xml:"control"`
+}
+
+// ParseEntry はクライアントから送られてきたエントリを読み取る
+func ParseEntry(r io.Reader) (*EntryInput, error) {
+	var x xmlEntryInput
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, ErrInvalidEntry
+	}
+	input := &EntryInput{
+		Title:     strings.TrimSpace(x.Title),
+		Content:   x.Content.Body,
+		CustomURL: strings.TrimSpace(x.CustomURL),
+		Draft:     strings.TrimSpace(x.Control.Draft) == "yes",
+	}
+	for _, c := range x.Categories {
+		input.Categories = append(input.Categories, c.Term)
+	}
+	if updated := strings.TrimSpace(x.Updated); updated != "" {
+		t, err := parseUpdated(updated)
+		if err != nil {
+			return nil, ErrInvalidEntry
+		}
+		input.Updated = t
+	}
+	return input, nil
+}
+
+// updatedLocalLayout はタイムゾーンを省いた updated の形式. はてなブログのドキュメントの例もこの形式で送る
+const updatedLocalLayout = "2006-01-02T15:04:05"
+
+// parseUpdated は updated の日時をパースする. タイムゾーンが書かれていない場合はサーバーのタイムゾーンとする
+func parseUpdated(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(updatedLocalLayout, s, time.Local)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
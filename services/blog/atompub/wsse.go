@@ -0,0 +1,117 @@
+package atompub
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidWSSE は X-WSSE ヘッダが読めないときに返される
+var ErrInvalidWSSE = errors.New("invalid wsse")
+
+// wsseMaxClockSkew は WSSE の Created とサーバーの時刻のずれの上限
+// 盗まれたヘッダを後から使い回されないよう, これより古いものは受け付けない
+const wsseMaxClockSkew = 5 * time.Minute
+
+var wsseParamRE = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// WSSEToken は X-WSSE ヘッダの UsernameToken を表す
+type WSSEToken struct {
+	Username       string
+	PasswordDigest string
+	Nonce          string
+	Created        string
+}
+
+// ParseWSSE は UsernameToken Username="...", PasswordDigest="...", Nonce="...", Created="..." の形式の X-WSSE ヘッダを読み取る
+func ParseWSSE(header string) (*WSSEToken, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(header), "UsernameToken ")
+	if !ok {
+		return nil, ErrInvalidWSSE
+	}
+	params := map[string]string{}
+	for _, m := range wsseParamRE.FindAllStringSubmatch(rest, -1) {
+		params[m[1]] = m[2]
+	}
+	token := &WSSEToken{
+		Username:       params["Username"],
+		PasswordDigest: params["PasswordDigest"],
+		Nonce:          params["Nonce"],
+		Created:        params["Created"],
+	}
+	if token.Username == "" || token.PasswordDigest == "" || token.Nonce == "" || token.Created == "" {
+		return nil, ErrInvalidWSSE
+	}
+	return token, nil
+}
+
+// Verify は PasswordDigest が Base64(SHA1(Nonce + Created + password)) と一致し, Created が now から離れすぎていないかを確かめる
+// Nonce は Base64 でエンコードされたものをデコードしてからダイジェストの計算に使う
+func (t *WSSEToken) Verify(password string, now time.Time) bool {
+	created, err := time.Parse(time.RFC3339, t.Created)
+	if err != nil {
+		return false
+	}
+	if skew := now.Sub(created); skew > wsseMaxClockSkew || skew < -wsseMaxClockSkew {
+		return false
+	}
+	nonce, err := base64.StdEncoding.DecodeString(t.Nonce)
+	if err != nil {
+		return false
+	}
+	h := sha1.New()
+	h.Write(nonce)
+	h.Write([]byte(t.Created))
+	h.Write([]byte(password))
+	digest := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	return subtle.ConstantTimeCompare([]byte(digest), []byte(t.PasswordDigest)) == 1
+}
+
+// NonceCache は WSSE 認証に使われた Nonce をユーザーごとに覚えておき, 盗まれたヘッダがそのまま使い回されるのを防ぐ
+// Nonce は Created から wsseMaxClockSkew が過ぎて Verify が受け付けなくなるまで覚えておく
+// プロセスのメモリに保存するので, 複数のサーバーで動かす場合はサーバーごとにしか防げない
+type NonceCache struct {
+	mu        sync.Mutex
+	expires   map[nonceKey]time.Time
+	nextSweep time.Time
+}
+
+type nonceKey struct {
+	username string
+	nonce    string
+}
+
+// NewNonceCache は NonceCache を作成する
+func NewNonceCache() *NonceCache {
+	return &NonceCache{expires: make(map[nonceKey]time.Time)}
+}
+
+// Use はトークンの Nonce を使用済みにする. ユーザーが同じ Nonce を既に使っていた場合は false を返す
+// 検証していないトークンで覚える Nonce を増やされないよう, Verify で検証してから呼ぶ
+func (c *NonceCache) Use(t *WSSEToken, now time.Time) bool {
+	created, err := time.Parse(time.RFC3339, t.Created)
+	if err != nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !now.Before(c.nextSweep) {
+		for key, expiresAt := range c.expires {
+			if now.After(expiresAt) {
+				delete(c.expires, key)
+			}
+		}
+		c.nextSweep = now.Add(wsseMaxClockSkew)
+	}
+	key := nonceKey{t.Username, t.Nonce}
+	if expiresAt, ok := c.expires[key]; ok && !now.After(expiresAt) {
+		return false
+	}
+	c.expires[key] = created.Add(wsseMaxClockSkew)
+	return true
+}
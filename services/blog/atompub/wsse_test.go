@@ -0,0 +1,153 @@
+package atompub
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newToken は password で署名した UsernameToken を作成する
+func newToken(username, password string, nonce []byte, created time.Time) *WSSEToken {
+	c := created.UTC().Format(time.RFC3339)
+	h := sha1.New()
+	h.Write(nonce)
+	h.Write([]byte(c))
+	h.Write([]byte(password))
+	return &WSSEToken{
+		Username:       username,
+		PasswordDigest: base64.StdEncoding.EncodeToString(h.Sum(nil)),
+		Nonce:          base64.StdEncoding.EncodeToString(nonce),
+		Created:        c,
+	}
+}
+
+func TestParseWSSE(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   *WSSEToken
+	}{
+		{
+			name:   "valid",
+			header: `UsernameToken Username="alice", PasswordDigest="ZGlnZXN0", Nonce="bm9uY2U=", Created="2026-10-16T00:00:00Z"`,
+			want:   &WSSEToken{Username: "alice", PasswordDigest: "ZGlnZXN0", Nonce: "bm9uY2U=", Created: "2026-10-16T00:00:00Z"},
+		},
+		{
+			name:   "parameters in any order",
+			header: `UsernameToken Created="2026-10-16T00:00:00Z", Nonce="bm9uY2U=", Username="alice", PasswordDigest="ZGlnZXN0"`,
+			want:   &WSSEToken{Username: "alice", PasswordDigest: "ZGlnZXN0", Nonce: "bm9uY2U=", Created: "2026-10-16T00:00:00Z"},
+		},
+		{
+			name:   "missing nonce",
+			header: `UsernameToken Username="alice", PasswordDigest="ZGlnZXN0", Created="2026-10-16T00:00:00Z"`,
+		},
+		{
+			name:   "not a username token",
+			header: `Username="alice", PasswordDigest="ZGlnZXN0", Nonce="bm9uY2U=", Created="2026-10-16T00:00:00Z"`,
+		},
+		{
+			name:   "empty",
+			header: ``,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWSSE(tt.header)
+			if tt.want == nil {
+				if err != ErrInvalidWSSE {
+					t.Errorf("ParseWSSE() = (%v, %v), want ErrInvalidWSSE", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != *tt.want {
+				t.Errorf("ParseWSSE() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWSSETokenVerify(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		token    *WSSEToken
+		password string
+		want     bool
+	}{
+		{"valid", newToken("alice", "key", []byte("nonce"), now), "key", true},
+		{"wrong password", newToken("alice", "key", []byte("nonce"), now), "other", false},
+		{"created within the skew", newToken("alice", "key", []byte("nonce"), now.Add(-wsseMaxClockSkew)), "key", true},
+		{"created too long ago", newToken("alice", "key", []byte("nonce"), now.Add(-wsseMaxClockSkew-time.Second)), "key", false},
+		{"created in the future", newToken("alice", "key", []byte("nonce"), now.Add(wsseMaxClockSkew+time.Second)), "key", false},
+		{"nonce not in base64", &WSSEToken{Username: "alice", PasswordDigest: newToken("alice", "key", []byte("nonce"), now).PasswordDigest, Nonce: "nonce", Created: now.Format(time.RFC3339)}, "key", false},
+		{"created not in RFC 3339", &WSSEToken{Username: "alice", PasswordDigest: "x", Nonce: "bm9uY2U=", Created: "yesterday"}, "key", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.token.Verify(tt.password, now); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNonceCacheUse(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	c := NewNonceCache()
+	tests := []struct {
+		name  string
+		token *WSSEToken
+		now   time.Time
+		want  bool
+	}{
+		{"first use", newToken("alice", "key", []byte("n1"), now), now, true},
+		{"replayed", newToken("alice", "key", []byte("n1"), now), now.Add(time.Minute), false},
+		{"replayed at the end of the skew", newToken("alice", "key", []byte("n1"), now), now.Add(wsseMaxClockSkew), false},
+		{"same nonce by another user", newToken("bob", "key", []byte("n1"), now), now.Add(time.Minute), true},
+		{"another nonce", newToken("alice", "key", []byte("n2"), now), now.Add(time.Minute), true},
+		// Verify が受け付けなくなった後は忘れてよい
+		{"after the skew", newToken("alice", "key", []byte("n1"), now), now.Add(wsseMaxClockSkew + time.Second), true},
+		{"created not in RFC 3339", &WSSEToken{Username: "alice", Nonce: "bjM=", Created: "yesterday"}, now, false},
+	}
+	for _, tt := range tests {
+		if got := c.Use(tt.token, tt.now); got != tt.want {
+			t.Errorf("%s: Use() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// 古い Nonce は次に掃除するときに捨てる
+	c.Use(newToken("carol", "key", []byte("n3"), now.Add(time.Hour)), now.Add(time.Hour))
+	c.mu.Lock()
+	n := len(c.expires)
+	c.mu.Unlock()
+	if n != 1 {
+		t.Errorf("cache has %d nonces after sweeping, want 1", n)
+	}
+}
+
+func TestNonceCacheUseConcurrently(t *testing.T) {
+	now := time.Now()
+	c := NewNonceCache()
+	token := newToken("alice", "key", []byte("nonce"), now)
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c.Use(token, now) {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := accepted.Load(); n != 1 {
+		t.Errorf("accepted %d times, want 1", n)
+	}
+}
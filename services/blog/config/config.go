@@ -6,26 +6,41 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config は各種設定をまとめたもの
 type Config struct {
-	Mode                  string
-	Port                  int
-	DatabaseDSN           string
-	AccountAddr           string
-	AccountECDSAPublicKey *ecdsa.PublicKey
-	RendererAddr          string
-	GracefulStopTimeout   time.Duration
-	MackerelAPIKey        string
-	TraceEndpoint         string
-	MetricEndpoint        string
-	ServiceName           string
-	ServiceNameSpace      string
-	ServiceVersion        string
+	Mode                       string
+	Port                       int
+	BaseURL                    string
+	DatabaseDSN                string
+	AccountAddr                string
+	AccountECDSAPublicKey      *ecdsa.PublicKey
+	RendererAddr               string
+	GracefulStopTimeout        time.Duration
+	SchedulerInterval          time.Duration
+	TrashRetention             time.Duration
+	ShareLinkSecret            []byte
+	WebhookTimeout             time.Duration
+	WebhookAllowPrivateNetwork bool
+	MediaStorage               string
+	MediaDir                   string
+	MediaS3Endpoint            string
+	MediaS3Region              string
+	MediaS3Bucket              string
+	MediaS3AccessKeyID         string
+	MediaS3SecretKey           string
+	MackerelAPIKey             string
+	TraceEndpoint              string
+	MetricEndpoint             string
+	ServiceName                string
+	ServiceNameSpace           string
+	ServiceVersion             string
 }
 
 // Load は環境変数から設定を読み込む
@@ -33,7 +48,14 @@ func Load() (*Config, error) {
 	conf := &Config{
 		Mode:                "development",
 		Port:                8080,
+		BaseURL:             "http://localhost:8080",
 		GracefulStopTimeout: 10 * time.Second,
+		SchedulerInterval:   30 * time.Second,
+		TrashRetention:      30 * 24 * time.Hour,
+		WebhookTimeout:      10 * time.Second,
+		MediaStorage:        "local",
+		MediaDir:            "media",
+		MediaS3Region:       "us-east-1",
 		TraceEndpoint:       "otlp-vaxila.mackerelio.com",
 		MetricEndpoint:      "otlp.mackerelio.com:4317",
 		ServiceName:         "blog",
@@ -56,6 +78,16 @@ func Load() (*Config, error) {
 		conf.Port = port
 	}
 
+	// BaseURL
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL != "" {
+		u, err := url.Parse(baseURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("BASE_URL is invalid: %s", baseURL)
+		}
+		conf.BaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+
 	// DatabaseDSN
 	databaseDSN := os.Getenv("DATABASE_DSN")
 	if databaseDSN == "" {
@@ -98,6 +130,97 @@ func Load() (*Config, error) {
 		conf.GracefulStopTimeout = d
 	}
 
+	// SchedulerInterval
+	schedulerInterval := os.Getenv("SCHEDULER_INTERVAL")
+	if schedulerInterval != "" {
+		d, err := time.ParseDuration(schedulerInterval)
+		if err != nil {
+			return nil, fmt.Errorf("SCHEDULER_INTERVAL is invalid: %v", err)
+		}
+		conf.SchedulerInterval = d
+	}
+
+	// TrashRetention
+	trashRetention := os.Getenv("TRASH_RETENTION")
+	if trashRetention != "" {
+		d, err := time.ParseDuration(trashRetention)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("TRASH_RETENTION is invalid: %s", trashRetention)
+		}
+		conf.TrashRetention = d
+	}
+
+	// ShareLinkSecret
+	shareLinkSecret := os.Getenv("SHARE_LINK_SECRET")
+	if shareLinkSecret == "" {
+		// 開発環境では固定の値を使い, 再起動しても共有リンクが使えるようにする
+		if conf.Mode != "development" {
+			return nil, errors.New("SHARE_LINK_SECRET is not set")
+		}
+		shareLinkSecret = "development"
+	}
+	conf.ShareLinkSecret = []byte(shareLinkSecret)
+
+	// WebhookTimeout
+	webhookTimeout := os.Getenv("WEBHOOK_TIMEOUT")
+	if webhookTimeout != "" {
+		d, err := time.ParseDuration(webhookTimeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("WEBHOOK_TIMEOUT is invalid: %s", webhookTimeout)
+		}
+		conf.WebhookTimeout = d
+	}
+
+	// WebhookAllowPrivateNetwork
+	// 開発環境ではローカルで動かしている受け取り先に送れるよう, 指定がなければプライベートなネットワークにも送る
+	conf.WebhookAllowPrivateNetwork = conf.Mode == "development"
+	webhookAllowPrivateNetwork := os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORK")
+	if webhookAllowPrivateNetwork != "" {
+		b, err := strconv.ParseBool(webhookAllowPrivateNetwork)
+		if err != nil {
+			return nil, fmt.Errorf("WEBHOOK_ALLOW_PRIVATE_NETWORK is invalid: %s", webhookAllowPrivateNetwork)
+		}
+		conf.WebhookAllowPrivateNetwork = b
+	}
+
+	// MediaStorage
+	mediaStorage := os.Getenv("MEDIA_STORAGE")
+	if mediaStorage != "" {
+		conf.MediaStorage = mediaStorage
+	}
+	switch conf.MediaStorage {
+	case "local":
+		// MediaDir
+		mediaDir := os.Getenv("MEDIA_DIR")
+		if mediaDir != "" {
+			conf.MediaDir = mediaDir
+		}
+	case "s3":
+		// MediaS3Endpoint
+		conf.MediaS3Endpoint = os.Getenv("MEDIA_S3_ENDPOINT")
+		if conf.MediaS3Endpoint == "" {
+			return nil, errors.New("MEDIA_S3_ENDPOINT is not set")
+		}
+		// MediaS3Region
+		mediaS3Region := os.Getenv("MEDIA_S3_REGION")
+		if mediaS3Region != "" {
+			conf.MediaS3Region = mediaS3Region
+		}
+		// MediaS3Bucket
+		conf.MediaS3Bucket = os.Getenv("MEDIA_S3_BUCKET")
+		if conf.MediaS3Bucket == "" {
+			return nil, errors.New("MEDIA_S3_BUCKET is not set")
+		}
+		// MediaS3AccessKeyID, MediaS3SecretKey
+		conf.MediaS3AccessKeyID = os.Getenv("MEDIA_S3_ACCESS_KEY_ID")
+		conf.MediaS3SecretKey = os.Getenv("MEDIA_S3_SECRET_ACCESS_KEY")
+		if conf.MediaS3AccessKeyID == "" || conf.MediaS3SecretKey == "" {
+			return nil, errors.New("MEDIA_S3_ACCESS_KEY_ID or MEDIA_S3_SECRET_ACCESS_KEY is not set")
+		}
+	default:
+		return nil, fmt.Errorf("MEDIA_STORAGE is invalid: %s", conf.MediaStorage)
+	}
+
 	// MackerelAPIKey
 	mackerelAPIKey := os.Getenv("MACKEREL_APIKEY")
 	if mackerelAPIKey == "" {
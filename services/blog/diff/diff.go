@@ -0,0 +1,112 @@
+package diff
+
+import "strings"
+
+// maxTableSize は最長共通部分列を求めるための表の大きさの上限
+// これを超える場合は変更前の行をすべて削除し, 変更後の行をすべて追加したものとみなす
+const maxTableSize = 4_000_000
+
+// Op は差分の各行に対する操作を表す
+type Op string
+
+const (
+	// OpEqual は両方に含まれる行
+	OpEqual Op = "equal"
+	// OpDelete は変更前にのみ含まれる行
+	OpDelete Op = "delete"
+	// OpInsert は変更後にのみ含まれる行
+	OpInsert Op = "insert"
+)
+
+// Line は差分の 1 行を表す
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines は 2 つの文字列を行単位で比較し, 差分を返す
+// 最長共通部分列を求めて, それに含まれない行を削除または追加された行とする
+func Lines(from, to string) []Line {
+	a := splitLines(from)
+	b := splitLines(to)
+
+	// 共通の先頭と末尾は比較の対象から外す
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(a)+len(b))
+	for _, text := range a[:prefix] {
+		lines = append(lines, Line{OpEqual, text})
+	}
+	lines = append(lines, lcs(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, Line{OpEqual, text})
+	}
+	return lines
+}
+
+func lcs(a, b []string) []Line {
+	if (len(a)+1)*(len(b)+1) > maxTableSize {
+		lines := make([]Line, 0, len(a)+len(b))
+		for _, text := range a {
+			lines = append(lines, Line{OpDelete, text})
+		}
+		for _, text := range b {
+			lines = append(lines, Line{OpInsert, text})
+		}
+		return lines
+	}
+
+	// table[i][j] は a[i:] と b[j:] の最長共通部分列の長さ
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]Line, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, Line{OpEqual, a[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			lines = append(lines, Line{OpDelete, a[i]})
+			i++
+		default:
+			lines = append(lines, Line{OpInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, Line{OpDelete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, Line{OpInsert, b[j]})
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
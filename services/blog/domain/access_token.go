@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessTokenID はパーソナルアクセストークンにユニークに割り当てられる ID
+type AccessTokenID uint64
+
+func (id AccessTokenID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseAccessTokenID は文字列の AccessTokenID をパースする
+func ParseAccessTokenID(str string) (AccessTokenID, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return AccessTokenID(0), err
+	}
+	return AccessTokenID(id), nil
+}
+
+// AccessTokenScope はパーソナルアクセストークンで行える操作の範囲を表す
+type AccessTokenScope string
+
+const (
+	// AccessTokenScopeRead は下書きや非公開のエントリなど, 公開されていない内容を読むスコープ
+	AccessTokenScopeRead AccessTokenScope = "read"
+	// AccessTokenScopeWriteEntries はエントリを投稿, 編集, 削除するスコープ
+	AccessTokenScopeWriteEntries AccessTokenScope = "write_entries"
+	// AccessTokenScopeManageBlogs はブログを作成し, 設定を変更するスコープ
+	AccessTokenScopeManageBlogs AccessTokenScope = "manage_blogs"
+)
+
+// AccessTokenScopes はトークンで選べるすべてのスコープ
+var AccessTokenScopes = []AccessTokenScope{AccessTokenScopeRead, AccessTokenScopeWriteEntries, AccessTokenScopeManageBlogs}
+
+// permissionScopes はブログに対する権限を使うのに必要なスコープ
+var permissionScopes = map[Permission]AccessTokenScope{
+	PermissionReadDrafts:        AccessTokenScopeRead,
+	PermissionWriteEntries:      AccessTokenScopeWriteEntries,
+	PermissionModerateComments:  AccessTokenScopeManageBlogs,
+	PermissionManageBlog:        AccessTokenScopeManageBlogs,
+	PermissionTransferOwnership: AccessTokenScopeManageBlogs,
+}
+
+// ParseAccessTokenScope は文字列の AccessTokenScope をパースする
+func ParseAccessTokenScope(str string) (AccessTokenScope, bool) {
+	s := AccessTokenScope(str)
+	return s, slices.Contains(AccessTokenScopes, s)
+}
+
+// accessTokenPrefix はトークンの先頭に付ける文字列. 漏れたトークンをシークレットスキャンで見つけやすくする
+const accessTokenPrefix = "blog_pat_"
+
+// accessTokenSize はトークンのランダムな部分の長さ
+const accessTokenSize = 40
+
+// accessTokenLastUsedInterval は最後に使った日時を記録し直す間隔
+// リクエストごとに書き込まないよう, これより短い間に使われても記録し直さない
+const accessTokenLastUsedInterval = time.Minute
+
+// AccessToken はスクリプトや CI から API を使うための, ユーザーが発行するパーソナルアクセストークンを表す
+// トークンそのものは発行したときに一度だけ見せ, リポジトリにはハッシュだけを保存する
+type AccessToken struct {
+	ID        AccessTokenID `db:"id"`
+	UserID    UserID        `db:"user_id"`
+	Name      string        `db:"name"`
+	TokenHash string        `db:"token_hash"`
+	// Scopes はカンマ区切りのスコープ
+	Scopes    string    `db:"scopes"`
+	ExpiresAt time.Time `db:"expires_at"`
+	// LastUsedAt は最後にトークンで認証した日時. 一度も使っていない場合は nil
+	LastUsedAt *time.Time `db:"last_used_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+// CreateAccessTokenInput はパーソナルアクセストークン作成時の入力
+type CreateAccessTokenInput struct {
+	UserID    UserID
+	Name      string
+	TokenHash string
+	Scopes    string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// AccessTokenRepository はパーソナルアクセストークンのリポジトリ
+type AccessTokenRepository interface {
+	Create(ctx context.Context, input *CreateAccessTokenInput) (*AccessToken, error)
+	FindByID(ctx context.Context, id AccessTokenID) (*AccessToken, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*AccessToken, error)
+	// ListByUserID はユーザーのトークンを期限切れのものも含めて新しい順に返す
+	ListByUserID(ctx context.Context, userID UserID) ([]*AccessToken, error)
+	UpdateLastUsedAt(ctx context.Context, id AccessTokenID, lastUsedAt time.Time) error
+	Delete(ctx context.Context, id AccessTokenID) error
+}
+
+// HashAccessToken はリポジトリに保存するトークンのハッシュを返す
+// トークンは十分に長いランダムな文字列なので, パスワードのように遅いハッシュ関数は使わない
+func HashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// LooksLikeAccessToken は文字列がパーソナルアクセストークンの形式かを判定する
+func LooksLikeAccessToken(token string) bool {
+	return strings.HasPrefix(token, accessTokenPrefix)
+}
+
+// ScopeList はトークンのスコープを返す
+func (t AccessToken) ScopeList() []AccessTokenScope {
+	if t.Scopes == "" {
+		return nil
+	}
+	var scopes []AccessTokenScope
+	for _, s := range strings.Split(t.Scopes, ",") {
+		scopes = append(scopes, AccessTokenScope(s))
+	}
+	return scopes
+}
+
+// HasScope はトークンがスコープを持っているかを判定する
+func (t AccessToken) HasScope(scope AccessTokenScope) bool {
+	return slices.Contains(t.ScopeList(), scope)
+}
+
+// Allows はトークンでブログに対する権限を使えるかを判定する
+// ユーザーが権限を持っていても, 必要なスコープのないトークンでは使えない
+func (t AccessToken) Allows(permission Permission) bool {
+	scope, ok := permissionScopes[permission]
+	return ok && t.HasScope(scope)
+}
+
+// IsExpired はトークンの有効期限が切れているかを判定する
+func (t AccessToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// CreateAccessToken はパーソナルアクセストークンを発行し, 保存したトークンとトークンそのものを返す
+// トークンそのものは保存しないので, 後から見ることはできない
+func (u User) CreateAccessToken(name string, scopes []AccessTokenScope, expiresAt, createdAt time.Time) func(ctx context.Context, r Repository) (*AccessToken, string, error) {
+	return func(ctx context.Context, r Repository) (*AccessToken, string, error) {
+		key, err := generateSessionKey(accessTokenSize)
+		if err != nil {
+			return nil, "", err
+		}
+		token := accessTokenPrefix + key
+		// スコープは選べる順に並べて保存する
+		var names []string
+		for _, s := range AccessTokenScopes {
+			if slices.Contains(scopes, s) {
+				names = append(names, string(s))
+			}
+		}
+		accessToken, err := r.AccessToken().Create(ctx, &CreateAccessTokenInput{
+			UserID:    u.ID,
+			Name:      name,
+			TokenHash: HashAccessToken(token),
+			Scopes:    strings.Join(names, ","),
+			ExpiresAt: expiresAt,
+			CreatedAt: createdAt,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		return accessToken, token, nil
+	}
+}
+
+// MarkUsed はトークンを使った日時を記録する. 前に記録してから間もない場合は記録し直さない
+func (t AccessToken) MarkUsed(usedAt time.Time) func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		if t.LastUsedAt != nil && usedAt.Sub(*t.LastUsedAt) < accessTokenLastUsedInterval {
+			return nil
+		}
+		return r.AccessToken().UpdateLastUsedAt(ctx, t.ID, usedAt)
+	}
+}
+
+// Revoke はトークンを取り消す. 取り消したトークンでは認証できなくなる
+func (t AccessToken) Revoke() func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		return r.AccessToken().Delete(ctx, t.ID)
+	}
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// APIKey は AtomPub API でユーザーを認証するための, ユーザーごとの API キーを表す
+// WSSE 認証では API キーそのものからダイジェストを計算して照合するので, ハッシュにせずそのまま保存する
+type APIKey struct {
+	UserID    UserID    `db:"user_id"`
+	Key       string    `db:"api_key"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// SaveAPIKeyInput は API キー保存時の入力
+type SaveAPIKeyInput struct {
+	UserID    UserID
+	Key       string
+	CreatedAt time.Time
+}
+
+// APIKeyRepository は API キーのリポジトリ
+type APIKeyRepository interface {
+	// Save はユーザーの API キーを保存する. 既にあれば置き換える
+	Save(ctx context.Context, input *SaveAPIKeyInput) (*APIKey, error)
+	FindByUserID(ctx context.Context, userID UserID) (*APIKey, error)
+}
+
+// apiKeySize は API キーの長さ
+const apiKeySize = 32
+
+// RegenerateAPIKey はユーザーの API キーを新しく作る. それまでの API キーは使えなくなる
+func (u User) RegenerateAPIKey(createdAt time.Time) func(ctx context.Context, r Repository) (*APIKey, error) {
+	return func(ctx context.Context, r Repository) (*APIKey, error) {
+		key, err := generateSessionKey(apiKeySize)
+		if err != nil {
+			return nil, err
+		}
+		return r.APIKey().Save(ctx, &SaveAPIKeyInput{
+			UserID:    u.ID,
+			Key:       key,
+			CreatedAt: createdAt,
+		})
+	}
+}
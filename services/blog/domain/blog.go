@@ -2,12 +2,26 @@ package domain
 
 import (
 	"context"
+	"strconv"
 	"time"
 )
 
 // BlogID はブログにユニークに割り当てられる ID
 type BlogID uint64
 
+func (id BlogID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseBlogID は文字列の BlogID をパースする
+func ParseBlogID(str string) (BlogID, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return BlogID(0), err
+	}
+	return BlogID(id), nil
+}
+
 // Blog はブログを表す
 type Blog struct {
 	ID          BlogID `db:"id"`
@@ -15,6 +29,13 @@ type Blog struct {
 	Path        string `db:"path"`
 	Title       string `db:"title"`
 	Description string `db:"description"`
+	// CommentsEnabled はエントリへのコメントを受け付けるかどうか
+	CommentsEnabled bool      `db:"comments_enabled"`
+	CreatedAt       time.Time `db:"created_at"`
+	// Visibility はブログの公開範囲. エントリにはブログとエントリのうち狭い方の公開範囲が適用される
+	Visibility Visibility `db:"visibility"`
+	// DeletedAt はブログをゴミ箱に移動した日時. ゴミ箱にない場合は nil
+	DeletedAt *time.Time `db:"deleted_at"`
 }
 
 // CreateBlogInput はブログ作成時の入力
@@ -23,6 +44,7 @@ type CreateBlogInput struct {
 	Path        string
 	Title       string
 	Description string
+	CreatedAt   time.Time
 }
 
 // UpdateBlogInput はブログ更新時の入力
@@ -34,16 +56,49 @@ type UpdateBlogInput struct {
 // BlogRepository はブログのリポジトリ
 type BlogRepository interface {
 	Create(ctx context.Context, input *CreateBlogInput) (*Blog, error)
+	// FindByID はゴミ箱にあるブログも返す
 	FindByID(ctx context.Context, id BlogID) (*Blog, error)
+	// FindByPath 以降の検索は, 特に断りがなければゴミ箱にあるブログを含まない
 	FindByPath(ctx context.Context, path string) (*Blog, error)
-	List(ctx context.Context, limit, offset int) ([]*Blog, error)
-	ListByUserID(ctx context.Context, userID UserID, limit, offset int) ([]*Blog, error)
+	// FindByPathIncludingTrashed はゴミ箱にあるブログも含めてパスで検索する
+	FindByPathIncludingTrashed(ctx context.Context, path string) (*Blog, error)
+	ListByIDs(ctx context.Context, ids []BlogID) ([]*Blog, error)
+	// List は公開範囲が public のブログを作成された新しい順に cursor の位置から返す. cursor が nil の場合は先頭から返す
+	List(ctx context.Context, cursor *Cursor, limit int) ([]*Blog, error)
+	// ListByUserID はユーザーが作成したブログと招待を承諾してメンバーになっているブログを
+	// タイトルの降順に cursor の位置から返す. cursor が nil の場合は先頭から返す
+	ListByUserID(ctx context.Context, userID UserID, cursor *Cursor, limit int) ([]*Blog, error)
+	// ListTrashedByUserID はユーザーがオーナーのブログのうち, ゴミ箱にあるものを新しく移動した順に返す
+	ListTrashedByUserID(ctx context.Context, userID UserID, limit int) ([]*Blog, error)
+	// ListTrashedBefore は指定した日時より前にゴミ箱に移動したブログを古い順に返す
+	ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]*Blog, error)
 	Update(ctx context.Context, id BlogID, input *UpdateBlogInput) (*Blog, error)
+	UpdatePath(ctx context.Context, id BlogID, path string) (*Blog, error)
+	UpdateCommentsEnabled(ctx context.Context, id BlogID, enabled bool) (*Blog, error)
+	UpdateVisibility(ctx context.Context, id BlogID, visibility Visibility) (*Blog, error)
+	// UpdateUserID はブログのオーナーを変更する
+	UpdateUserID(ctx context.Context, id BlogID, userID UserID) (*Blog, error)
+	// UpdateDeletedAt はブログをゴミ箱に移動した日時を更新する. nil の場合はゴミ箱から戻す
+	UpdateDeletedAt(ctx context.Context, id BlogID, deletedAt *time.Time) (*Blog, error)
 	Delete(ctx context.Context, id BlogID) error
 }
 
+// IsTrashed はブログがゴミ箱にあるかを判定する
+func (b Blog) IsTrashed() bool {
+	return b.DeletedAt != nil
+}
+
+// PurgeAt はゴミ箱にあるブログが完全に削除される日時を返す
+// retention はゴミ箱に残しておく期間で, ゴミ箱にない場合はゼロ値を返す
+func (b Blog) PurgeAt(retention time.Duration) time.Time {
+	if b.DeletedAt == nil {
+		return time.Time{}
+	}
+	return b.DeletedAt.Add(retention)
+}
+
 // Edit はブログのタイトルや説明文を更新する
-func (b Blog) Edit(title, description string) func(ctx context.Context, r Repository) (*Blog, error) {
+func (b Blog) Edit(title, description string, editedAt time.Time) func(ctx context.Context, r Repository) (*Blog, error) {
 	return func(ctx context.Context, r Repository) (*Blog, error) {
 		blog, err := r.Blog().Update(ctx, b.ID, &UpdateBlogInput{
 			Title:       title,
@@ -52,19 +107,111 @@ func (b Blog) Edit(title, description string) func(ctx context.Context, r Reposi
 		if err != nil {
 			return nil, err
 		}
+		if err := recordEvent(ctx, r, &BlogEdited{BlogID: b.ID, EditedAt: editedAt}, editedAt); err != nil {
+			return nil, err
+		}
 		return blog, nil
 	}
 }
 
-// Delete はブログを削除する
-func (b Blog) Delete() func(ctx context.Context, r Repository) error {
+// SetCommentsEnabled はブログでエントリへのコメントを受け付けるかどうかを切り替える
+func (b Blog) SetCommentsEnabled(enabled bool) func(ctx context.Context, r Repository) (*Blog, error) {
+	return func(ctx context.Context, r Repository) (*Blog, error) {
+		return r.Blog().UpdateCommentsEnabled(ctx, b.ID, enabled)
+	}
+}
+
+// SetVisibility はブログの公開範囲を変更する
+func (b Blog) SetVisibility(visibility Visibility) func(ctx context.Context, r Repository) (*Blog, error) {
+	return func(ctx context.Context, r Repository) (*Blog, error) {
+		return r.Blog().UpdateVisibility(ctx, b.ID, visibility)
+	}
+}
+
+// Trash はブログをゴミ箱に移動する
+// ゴミ箱にあるブログとそのエントリは読者から見えなくなるが, 完全に削除されるまでは元に戻せる
+func (b Blog) Trash(deletedAt time.Time) func(ctx context.Context, r Repository) (*Blog, error) {
+	return func(ctx context.Context, r Repository) (*Blog, error) {
+		if b.IsTrashed() {
+			return &b, nil
+		}
+		blog, err := r.Blog().UpdateDeletedAt(ctx, b.ID, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordEvent(ctx, r, &BlogDeleted{BlogID: b.ID, DeletedAt: deletedAt}, deletedAt); err != nil {
+			return nil, err
+		}
+		return blog, nil
+	}
+}
+
+// Restore はゴミ箱にあるブログを元に戻す
+func (b Blog) Restore() func(ctx context.Context, r Repository) (*Blog, error) {
+	return func(ctx context.Context, r Repository) (*Blog, error) {
+		if !b.IsTrashed() {
+			return &b, nil
+		}
+		return r.Blog().UpdateDeletedAt(ctx, b.ID, nil)
+	}
+}
+
+// Purge はブログをエントリやそれに付随するリビジョン, 古いスラッグ, タグ付け, コメント, 共有リンク, 閲覧数, スター, メディア, 取り込み元との対応, 書き出し, Webhook とともに完全に削除する
+// ブログの古いパスもこのときに削除し, 他のブログが使えるようにする. メディアのファイル本体や書き出した zip ファイルの削除は呼び出し側で行う
+// 途中で失敗してもエントリだけが残ることのないよう, トランザクション内で呼ぶ
+func (b Blog) Purge() func(ctx context.Context, r Repository) error {
 	return func(ctx context.Context, r Repository) error {
+		if err := r.BlogPathRedirect().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.EntrySlugRedirect().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.Media().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.EntryImport().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.BlogExport().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.WebhookDelivery().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.Webhook().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.Member().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.ShareLink().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.Comment().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.Tag().DeleteEntryTagsByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.EntryView().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.Star().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.EntryRevision().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
+		if err := r.Entry().DeleteByBlogID(ctx, b.ID); err != nil {
+			return err
+		}
 		return r.Blog().Delete(ctx, b.ID)
 	}
 }
 
-// PublishEntry は新規エントリを公開する
-func (b Blog) PublishEntry(title, body string, publishedAt time.Time) func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
+// PublishEntry は新規エントリを author が書いたものとして公開する
+func (b Blog) PublishEntry(author *User, title, body string, publishedAt time.Time) func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
 	return func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
 		bodyHTML, err := br.Render(ctx, body)
 		if err != nil {
@@ -72,15 +219,72 @@ func (b Blog) PublishEntry(title, body string, publishedAt time.Time) func(ctx c
 		}
 		entry, err := r.Entry().Create(ctx, &CreateEntryInput{
 			BlogID:      b.ID,
+			AuthorID:    author.ID,
 			Title:       title,
 			Body:        body,
 			BodyHTML:    bodyHTML,
+			Format:      EntryFormatMarkdown,
+			Status:      EntryStatusPublished,
 			PublishedAt: publishedAt,
 			EditedAt:    publishedAt,
 		})
 		if err != nil {
 			return nil, err
 		}
+		err = recordEvent(ctx, r, &EntryPublished{BlogID: b.ID, EntryID: entry.ID, PublishedAt: publishedAt}, publishedAt)
+		if err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+}
+
+// SaveDraftEntry は新規エントリを author が書いたものとして, 公開せずに下書きとして保存する
+func (b Blog) SaveDraftEntry(author *User, title, body string, savedAt time.Time) func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
+	return func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
+		bodyHTML, err := br.Render(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := r.Entry().Create(ctx, &CreateEntryInput{
+			BlogID:      b.ID,
+			AuthorID:    author.ID,
+			Title:       title,
+			Body:        body,
+			BodyHTML:    bodyHTML,
+			Format:      EntryFormatMarkdown,
+			Status:      EntryStatusDraft,
+			PublishedAt: savedAt,
+			EditedAt:    savedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+}
+
+// ScheduleEntry は新規エントリを author が書いたものとして, 指定した日時に公開されるように予約する
+func (b Blog) ScheduleEntry(author *User, title, body string, publishAt, savedAt time.Time) func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
+	return func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
+		bodyHTML, err := br.Render(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := r.Entry().Create(ctx, &CreateEntryInput{
+			BlogID:      b.ID,
+			AuthorID:    author.ID,
+			Title:       title,
+			Body:        body,
+			BodyHTML:    bodyHTML,
+			Format:      EntryFormatMarkdown,
+			Status:      EntryStatusScheduled,
+			PublishedAt: publishAt,
+			EditedAt:    savedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
 		return entry, nil
 	}
 }
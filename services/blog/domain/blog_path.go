@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// BlogPathRedirect はブログのパスを変更する前の古いパスを表す
+// 古いパスへのアクセスは新しいパスにリダイレクトし, ブログが完全に削除されるまで他のブログには使わせない
+type BlogPathRedirect struct {
+	Path      string    `db:"path"`
+	BlogID    BlogID    `db:"blog_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// CreateBlogPathRedirectInput は古いパス作成時の入力
+type CreateBlogPathRedirectInput struct {
+	Path      string
+	BlogID    BlogID
+	CreatedAt time.Time
+}
+
+// BlogPathRedirectRepository はブログの古いパスのリポジトリ
+type BlogPathRedirectRepository interface {
+	Create(ctx context.Context, input *CreateBlogPathRedirectInput) (*BlogPathRedirect, error)
+	FindByPath(ctx context.Context, path string) (*BlogPathRedirect, error)
+	// ListByBlogID はブログの古いパスを新しい順に返す
+	ListByBlogID(ctx context.Context, blogID BlogID) ([]*BlogPathRedirect, error)
+	Delete(ctx context.Context, path string) error
+	// DeleteByBlogID はブログのすべての古いパスを削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// checkBlogPathAvailable はパスを blogID のブログが使えるかを確かめる
+// 他のブログが使っているパスや, 他のブログの古いパスとして予約されているパスには ErrAlreadyExists を返す
+// ゴミ箱にあるブログのパスも完全に削除されるまでは使えない
+func checkBlogPathAvailable(ctx context.Context, r Repository, path string, blogID BlogID) error {
+	blog, err := r.Blog().FindByPathIncludingTrashed(ctx, path)
+	if err == nil && blog.ID != blogID {
+		return ErrAlreadyExists
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	redirect, err := r.BlogPathRedirect().FindByPath(ctx, path)
+	if err == nil && redirect.BlogID != blogID {
+		return ErrAlreadyExists
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Rename はブログのパスを変更し, 変更前のパスを古いパスとして残す
+// 自分の古いパスに戻す場合は, その古いパスをリダイレクトから外す
+func (b Blog) Rename(path string, renamedAt time.Time) func(ctx context.Context, r Repository) (*Blog, error) {
+	return func(ctx context.Context, r Repository) (*Blog, error) {
+		if path == b.Path {
+			return &b, nil
+		}
+		if err := checkBlogPathAvailable(ctx, r, path, b.ID); err != nil {
+			return nil, err
+		}
+		if err := r.BlogPathRedirect().Delete(ctx, path); err != nil {
+			return nil, err
+		}
+		_, err := r.BlogPathRedirect().Create(ctx, &CreateBlogPathRedirectInput{
+			Path:      b.Path,
+			BlogID:    b.ID,
+			CreatedAt: renamedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		blog, err := r.Blog().UpdatePath(ctx, b.ID, path)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordEvent(ctx, r, &BlogEdited{BlogID: b.ID, EditedAt: renamedAt}, renamedAt); err != nil {
+			return nil, err
+		}
+		return blog, nil
+	}
+}
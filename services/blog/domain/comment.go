@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// CommentID はコメントにユニークに割り当てられる ID
+type CommentID uint64
+
+func (id CommentID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseCommentID は文字列の CommentID をパースする
+func ParseCommentID(str string) (CommentID, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return CommentID(0), err
+	}
+	return CommentID(id), nil
+}
+
+// CommentStatus はコメントのモデレーションの状態を表す
+type CommentStatus string
+
+const (
+	// CommentStatusPending はブログの作者による承認を待っている状態
+	CommentStatusPending CommentStatus = "pending"
+	// CommentStatusApproved は承認されてエントリのページに表示される状態
+	CommentStatusApproved CommentStatus = "approved"
+	// CommentStatusHidden はブログの作者によって非表示にされた状態
+	CommentStatusHidden CommentStatus = "hidden"
+)
+
+// Comment はエントリに付けられたコメントを表す
+type Comment struct {
+	ID       CommentID     `db:"id"`
+	EntryID  EntryID       `db:"entry_id"`
+	BlogID   BlogID        `db:"blog_id"`
+	UserID   UserID        `db:"user_id"`
+	Body     string        `db:"body"`
+	BodyHTML string        `db:"body_html"`
+	Status   CommentStatus `db:"status"`
+	PostedAt time.Time     `db:"posted_at"`
+}
+
+// CreateCommentInput はコメント作成時の入力
+type CreateCommentInput struct {
+	EntryID  EntryID
+	BlogID   BlogID
+	UserID   UserID
+	Body     string
+	BodyHTML string
+	Status   CommentStatus
+	PostedAt time.Time
+}
+
+// CommentRepository はコメントのリポジトリ
+type CommentRepository interface {
+	Create(ctx context.Context, input *CreateCommentInput) (*Comment, error)
+	FindByID(ctx context.Context, id CommentID) (*Comment, error)
+	// ListByEntryID はエントリに付けられたコメントのうち, 指定した状態のものを投稿された順に cursor の位置から返す
+	// cursor が nil の場合は先頭から返す
+	ListByEntryID(ctx context.Context, entryID EntryID, status CommentStatus, cursor *Cursor, limit int) ([]*Comment, error)
+	// ListByBlogID はブログのエントリに付けられたコメントのうち, 指定した状態のものを新しい順に返す
+	ListByBlogID(ctx context.Context, blogID BlogID, status CommentStatus, limit, offset int) ([]*Comment, error)
+	UpdateStatus(ctx context.Context, id CommentID, status CommentStatus) (*Comment, error)
+	Delete(ctx context.Context, id CommentID) error
+	DeleteByEntryID(ctx context.Context, entryID EntryID) error
+	// DeleteByBlogID はブログのエントリに付けられたすべてのコメントを削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// PostComment はエントリにコメントを投稿する
+// コメントをモデレーションできるブログのメンバーによるコメントは承認済みとし, それ以外は承認待ちにする
+// コメントの本文は読者が書いたものなので, 機能を制限した BodyRenderer で変換する
+func (e Entry) PostComment(blog *Blog, user *User, body string, postedAt time.Time) func(ctx context.Context, r Repository, br BodyRenderer) (*Comment, error) {
+	return func(ctx context.Context, r Repository, br BodyRenderer) (*Comment, error) {
+		bodyHTML, err := br.Render(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+		role, err := blog.RoleOf(user)(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		status := CommentStatusPending
+		if role.Can(PermissionModerateComments) {
+			status = CommentStatusApproved
+		}
+		comment, err := r.Comment().Create(ctx, &CreateCommentInput{
+			EntryID:  e.ID,
+			BlogID:   e.BlogID,
+			UserID:   user.ID,
+			Body:     body,
+			BodyHTML: bodyHTML,
+			Status:   status,
+			PostedAt: postedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return comment, nil
+	}
+}
+
+// Approve はコメントを承認してエントリのページに表示する
+func (c Comment) Approve() func(ctx context.Context, r Repository) (*Comment, error) {
+	return func(ctx context.Context, r Repository) (*Comment, error) {
+		return r.Comment().UpdateStatus(ctx, c.ID, CommentStatusApproved)
+	}
+}
+
+// Hide はコメントを非表示にする
+func (c Comment) Hide() func(ctx context.Context, r Repository) (*Comment, error) {
+	return func(ctx context.Context, r Repository) (*Comment, error) {
+		return r.Comment().UpdateStatus(ctx, c.ID, CommentStatusHidden)
+	}
+}
+
+// Delete はコメントを削除する
+func (c Comment) Delete() func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		return r.Comment().Delete(ctx, c.ID)
+	}
+}
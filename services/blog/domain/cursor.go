@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Cursor はキーセットページングで一覧を読み始める位置を表す
+// 一覧の並び順のキーの値と, キーの値が等しいものを区別するための ID からなり,
+// 一覧はこの位置を含まずにその先から読む
+type Cursor struct {
+	Key string `json:"k"`
+	ID  uint64 `json:"i"`
+	// Backward が true の場合は位置より手前, つまり前のページを読む
+	Backward bool `json:"b,omitempty"`
+}
+
+// Page はキーセットページングで読んだ一覧の前後のページの位置を表す
+// 前後にページがない場合はそれぞれ nil になる
+type Page struct {
+	Prev *Cursor
+	Next *Cursor
+}
+
+// NewTimeCursor は日時を並び順のキーとする Cursor を作成する
+func NewTimeCursor(t time.Time, id uint64) *Cursor {
+	return &Cursor{Key: t.UTC().Format(time.RFC3339Nano), ID: id}
+}
+
+// Time は並び順のキーを日時として返す
+func (c *Cursor) Time() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Key)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return t, nil
+}
+
+// String は Cursor を URL のクエリパラメーターに埋め込める文字列にする
+// 利用者が中身に依存しないよう, 文字列の形式は公開しない
+func (c *Cursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseCursor は Cursor.String で作られた文字列の Cursor をパースする
+func ParseCursor(str string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -22,23 +22,61 @@ func ParseEntryID(str string) (EntryID, error) {
 	return EntryID(id), nil
 }
 
+// EntryStatus はエントリの公開状態を表す
+type EntryStatus string
+
+const (
+	// EntryStatusDraft は一度も公開されていない下書きの状態
+	EntryStatusDraft EntryStatus = "draft"
+	// EntryStatusPublished は公開されている状態
+	EntryStatusPublished EntryStatus = "published"
+	// EntryStatusUnpublished は公開後に非公開にされた状態
+	EntryStatusUnpublished EntryStatus = "unpublished"
+	// EntryStatusScheduled は公開日時になるのを待っている予約投稿の状態
+	EntryStatusScheduled EntryStatus = "scheduled"
+)
+
+// EntryFormat はエントリの本文の記法を表す
+type EntryFormat string
+
+const (
+	// EntryFormatMarkdown は Markdown で書かれた本文. レンダラで HTML に変換する
+	EntryFormatMarkdown EntryFormat = "markdown"
+	// EntryFormatHTML は他のブログサービスから取り込んだ HTML の本文. 許可したタグと属性だけを残して表示する
+	EntryFormatHTML EntryFormat = "html"
+)
+
 // Entry はブログのエントリを表す
 type Entry struct {
-	ID          EntryID   `db:"id"`
-	BlogID      BlogID    `db:"blog_id"`
-	Title       string    `db:"title"`
-	Body        string    `db:"body"`
-	BodyHTML    string    `db:"body_html"`
-	PublishedAt time.Time `db:"published_at"`
-	EditedAt    time.Time `db:"edited_at"`
+	ID     EntryID `db:"id"`
+	BlogID BlogID  `db:"blog_id"`
+	// AuthorID はエントリを書いたブログのメンバー
+	AuthorID    UserID      `db:"author_id"`
+	Title       string      `db:"title"`
+	Body        string      `db:"body"`
+	BodyHTML    string      `db:"body_html"`
+	Status      EntryStatus `db:"status"`
+	PublishedAt time.Time   `db:"published_at"`
+	EditedAt    time.Time   `db:"edited_at"`
+	// Visibility はエントリの公開範囲. 実際にはブログの公開範囲と狭い方が適用される
+	Visibility Visibility `db:"visibility"`
+	// Slug はブログの中でエントリにユニークなパーマリンクの末尾の部分. 指定されなければエントリの ID にする
+	Slug string `db:"slug"`
+	// Format は本文の記法. 編集した後も変わらない
+	Format EntryFormat `db:"format"`
+	// DeletedAt はエントリをゴミ箱に移動した日時. ゴミ箱にない場合は nil
+	DeletedAt *time.Time `db:"deleted_at"`
 }
 
 // CreateEntryInput はエントリ作成時の入力
 type CreateEntryInput struct {
 	BlogID      BlogID
+	AuthorID    UserID
 	Title       string
 	Body        string
 	BodyHTML    string
+	Format      EntryFormat
+	Status      EntryStatus
 	PublishedAt time.Time
 	EditedAt    time.Time
 }
@@ -48,6 +86,7 @@ type UpdateEntryInput struct {
 	Title       string
 	Body        string
 	BodyHTML    string
+	Status      EntryStatus
 	PublishedAt time.Time
 	EditedAt    time.Time
 }
@@ -55,41 +94,353 @@ type UpdateEntryInput struct {
 // EntryRepository はブログのエントリのリポジトリ
 type EntryRepository interface {
 	Create(ctx context.Context, input *CreateEntryInput) (*Entry, error)
+	// FindByID はゴミ箱にあるエントリも返す
 	FindByID(ctx context.Context, id EntryID) (*Entry, error)
-	ListByBlogID(ctx context.Context, blogID BlogID, limit, offset int) ([]*Entry, error)
+	// FindBySlug はブログの ID とスラッグでエントリを検索する. ゴミ箱にあるエントリも返す
+	FindBySlug(ctx context.Context, blogID BlogID, slug string) (*Entry, error)
+	// ListByIDs 以降の検索は, 特に断りがなければゴミ箱にあるエントリを含まない
+	ListByIDs(ctx context.Context, ids []EntryID) ([]*Entry, error)
+	// ListByBlogID は公開されているエントリのみを公開日時の新しい順に cursor の位置から返す
+	// listedOnly が true の場合は公開範囲が public のエントリのみを返す. cursor が nil の場合は先頭から返す
+	ListByBlogID(ctx context.Context, blogID BlogID, listedOnly bool, cursor *Cursor, limit int) ([]*Entry, error)
+	// ListLatestByBlogIDs は複数のブログそれぞれの最新の公開されている, 公開範囲が public のエントリを返す
+	// そのようなエントリがないブログは結果に含まれない
+	// 返すエントリは一覧に表示するためのもので, Body と BodyHTML を含まない
+	ListLatestByBlogIDs(ctx context.Context, blogIDs []BlogID) (map[BlogID]*Entry, error)
+	// ListPublished はすべてのブログの公開されているエントリを新しい順に返す
+	// ゴミ箱にあるブログのエントリや, エントリかブログの公開範囲が public でないものは含まない
+	ListPublished(ctx context.Context, limit, offset int) ([]*Entry, error)
+	// ListByBlogIDAndTagName はタグが付けられた公開されているエントリのみを返す
+	// listedOnly が true の場合は公開範囲が public のエントリのみを返す
+	ListByBlogIDAndTagName(ctx context.Context, blogID BlogID, tagName string, listedOnly bool, limit, offset int) ([]*Entry, error)
+	// ListDraftsByBlogID は下書きと予約投稿, 非公開にされたエントリを返す
+	ListDraftsByBlogID(ctx context.Context, blogID BlogID, limit, offset int) ([]*Entry, error)
+	// ListRecentByBlogID は公開されているかによらずブログのエントリを公開日時の新しい順に cursor の位置から返す
+	// 下書きや予約投稿のエントリの公開日時は, 予約した日時か作成した日時. cursor が nil の場合は先頭から返す
+	ListRecentByBlogID(ctx context.Context, blogID BlogID, cursor *Cursor, limit int) ([]*Entry, error)
+	// ListAllByBlogID は公開されているかによらずブログのエントリを ID の順に afterID より後から返す
+	// ブログのすべてのエントリを少しずつ読むために使う. afterID が 0 の場合は先頭から返す
+	ListAllByBlogID(ctx context.Context, blogID BlogID, afterID EntryID, limit int) ([]*Entry, error)
+	// ListDueScheduledForUpdate は公開日時を過ぎた予約投稿のエントリを行ロックを取りつつ返す
+	// 他のトランザクションがロックしているエントリは読み飛ばす
+	ListDueScheduledForUpdate(ctx context.Context, now time.Time, limit int) ([]*Entry, error)
+	// ListTrashedByUserID はユーザーがエントリを書けるブログのゴミ箱にあるエントリを新しく移動した順に返す
+	// ゴミ箱にあるブログのエントリはブログごと元に戻すので含まない
+	ListTrashedByUserID(ctx context.Context, userID UserID, limit int) ([]*Entry, error)
+	// ListTrashedBefore は指定した日時より前にゴミ箱に移動したエントリを古い順に返す
+	ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]*Entry, error)
 	Update(ctx context.Context, id EntryID, input *UpdateEntryInput) (*Entry, error)
+	UpdateVisibility(ctx context.Context, id EntryID, visibility Visibility) (*Entry, error)
+	UpdateSlug(ctx context.Context, id EntryID, slug string) (*Entry, error)
+	// UpdateDeletedAt はエントリをゴミ箱に移動した日時を更新する. nil の場合はゴミ箱から戻す
+	UpdateDeletedAt(ctx context.Context, id EntryID, deletedAt *time.Time) (*Entry, error)
 	Delete(ctx context.Context, id EntryID) error
+	// DeleteByBlogID はブログのすべてのエントリを削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
 }
 
 // BodyRenderer はエントリの本文を HTML に変換する
+// 本文の記法が HTML のエントリには, 取り込んだ HTML を表示してよい HTML に変換する BodyRenderer を渡す
 type BodyRenderer interface {
 	Render(ctx context.Context, body string) (string, error)
 }
 
 // Edit はエントリを編集する
+// 編集前の内容はリビジョンとして保存する
 func (e Entry) Edit(title, body string, editedAt time.Time) func(ctx context.Context, r Repository, t BodyRenderer) (*Entry, error) {
 	return func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
 		bodyHTML, err := br.Render(ctx, body)
 		if err != nil {
 			return nil, err
 		}
+		if title != e.Title || body != e.Body {
+			_, err := r.EntryRevision().Create(ctx, &CreateEntryRevisionInput{
+				EntryID:  e.ID,
+				Title:    e.Title,
+				Body:     e.Body,
+				EditedAt: e.EditedAt,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
 		entry, err := r.Entry().Update(ctx, e.ID, &UpdateEntryInput{
 			Title:       title,
 			Body:        body,
 			BodyHTML:    bodyHTML,
+			Status:      e.Status,
 			PublishedAt: e.PublishedAt,
 			EditedAt:    editedAt,
 		})
 		if err != nil {
 			return nil, err
 		}
+		err = recordEvent(ctx, r, &EntryEdited{BlogID: e.BlogID, EntryID: e.ID, Status: e.Status, EditedAt: editedAt}, editedAt)
+		if err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+}
+
+// IsPublished はエントリが読者に公開されているかを判定する
+func (e Entry) IsPublished() bool {
+	return e.Status == EntryStatusPublished
+}
+
+// Permalink はエントリのパーマリンクをブログのパスからの相対パスで返す
+// 公開日 (UTC) とスラッグからなり, 公開日が変わるまでは変わらない
+func (e Entry) Permalink() string {
+	return e.PublishedAt.UTC().Format("2006/01/02") + "/" + e.Slug
+}
+
+// HasCustomSlug はエントリにスラッグが指定されているかを判定する
+func (e Entry) HasCustomSlug() bool {
+	return e.Slug != e.ID.String()
+}
+
+// ChangeSlug はエントリのスラッグを変更し, 変更前のスラッグを古いスラッグとして残す. slug が空の場合はエントリの ID に戻す
+// ブログの中で他のエントリが使っているスラッグや古いスラッグには変更できず, ErrAlreadyExists を返す
+// 自分の古いスラッグに戻す場合は, その古いスラッグをリダイレクトから外す
+func (e Entry) ChangeSlug(slug string, changedAt time.Time) func(ctx context.Context, r Repository) (*Entry, error) {
+	return func(ctx context.Context, r Repository) (*Entry, error) {
+		if slug == "" {
+			slug = e.ID.String()
+		}
+		if slug == e.Slug {
+			return &e, nil
+		}
+		if err := checkSlugAvailable(ctx, r, e.BlogID, slug, e.ID); err != nil {
+			return nil, err
+		}
+		if err := r.EntrySlugRedirect().Delete(ctx, e.BlogID, slug); err != nil {
+			return nil, err
+		}
+		// 公開前のエントリのスラッグは誰にも知られていないので残さない
+		// ID のスラッグは数字だけでスラッグには使えないので, 残さなくても ID で検索してリダイレクトできる
+		if e.IsPublished() && e.HasCustomSlug() {
+			_, err := r.EntrySlugRedirect().Create(ctx, &CreateEntrySlugRedirectInput{
+				BlogID:    e.BlogID,
+				Slug:      e.Slug,
+				EntryID:   e.ID,
+				CreatedAt: changedAt,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return r.Entry().UpdateSlug(ctx, e.ID, slug)
+	}
+}
+
+// VisibilityIn はブログの公開範囲も考慮した, エントリに実際に適用される公開範囲を返す
+func (e Entry) VisibilityIn(blog *Blog) Visibility {
+	return e.Visibility.Narrower(blog.Visibility)
+}
+
+// SetVisibility はエントリの公開範囲を変更する
+func (e Entry) SetVisibility(visibility Visibility) func(ctx context.Context, r Repository) (*Entry, error) {
+	return func(ctx context.Context, r Repository) (*Entry, error) {
+		return r.Entry().UpdateVisibility(ctx, e.ID, visibility)
+	}
+}
+
+// IsScheduled はエントリが予約投稿されているかを判定する
+func (e Entry) IsScheduled() bool {
+	return e.Status == EntryStatusScheduled
+}
+
+// Publish は下書きや非公開にされたエントリを公開する
+func (e Entry) Publish(publishedAt time.Time) func(ctx context.Context, r Repository) (*Entry, error) {
+	return func(ctx context.Context, r Repository) (*Entry, error) {
+		if e.IsPublished() {
+			return &e, nil
+		}
+		occurredAt := publishedAt
+		// 一度公開したことのあるエントリは最初の公開日時を保つ
+		if e.Status == EntryStatusUnpublished {
+			publishedAt = e.PublishedAt
+		}
+		// 予約投稿は予約した日時で公開する
+		if e.IsScheduled() {
+			publishedAt = e.PublishedAt
+		}
+		entry, err := r.Entry().Update(ctx, e.ID, &UpdateEntryInput{
+			Title:       e.Title,
+			Body:        e.Body,
+			BodyHTML:    e.BodyHTML,
+			Status:      EntryStatusPublished,
+			PublishedAt: publishedAt,
+			EditedAt:    e.EditedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// ゴミ箱にあるエントリは読者から見えないので, ゴミ箱から戻したときに起こす
+		if !e.IsTrashed() {
+			err := recordEvent(ctx, r, &EntryPublished{BlogID: e.BlogID, EntryID: e.ID, PublishedAt: publishedAt}, occurredAt)
+			if err != nil {
+				return nil, err
+			}
+		}
 		return entry, nil
 	}
 }
 
-// Unpublish はエントリを削除する
-func (e Entry) Unpublish() func(ctx context.Context, r Repository) error {
+// Schedule はエントリを指定した日時に公開されるように予約する
+func (e Entry) Schedule(publishAt time.Time) func(ctx context.Context, r Repository) (*Entry, error) {
+	return func(ctx context.Context, r Repository) (*Entry, error) {
+		if e.IsPublished() {
+			return &e, nil
+		}
+		return r.Entry().Update(ctx, e.ID, &UpdateEntryInput{
+			Title:       e.Title,
+			Body:        e.Body,
+			BodyHTML:    e.BodyHTML,
+			Status:      EntryStatusScheduled,
+			PublishedAt: publishAt,
+			EditedAt:    e.EditedAt,
+		})
+	}
+}
+
+// Unpublish はエントリを非公開にする
+// 予約投稿のエントリは予約を取り消して下書きに戻す
+func (e Entry) Unpublish(unpublishedAt time.Time) func(ctx context.Context, r Repository) error {
 	return func(ctx context.Context, r Repository) error {
+		var status EntryStatus
+		switch e.Status {
+		case EntryStatusPublished:
+			status = EntryStatusUnpublished
+		case EntryStatusScheduled:
+			status = EntryStatusDraft
+		default:
+			return nil
+		}
+		_, err := r.Entry().Update(ctx, e.ID, &UpdateEntryInput{
+			Title:       e.Title,
+			Body:        e.Body,
+			BodyHTML:    e.BodyHTML,
+			Status:      status,
+			PublishedAt: e.PublishedAt,
+			EditedAt:    e.EditedAt,
+		})
+		if err != nil {
+			return err
+		}
+		// 予約を取り消しただけのものや, ゴミ箱にあるものは読者に見えていなかった
+		if !e.IsPublished() || e.IsTrashed() {
+			return nil
+		}
+		return recordEvent(ctx, r, &EntryUnpublished{BlogID: e.BlogID, EntryID: e.ID, UnpublishedAt: unpublishedAt}, unpublishedAt)
+	}
+}
+
+// PublishDueEntries は公開日時を過ぎた予約投稿のエントリを公開する
+// 複数のプロセスから同時に呼ばれても同じエントリを二重に処理しないよう, 行ロックを取るトランザクション内で呼ぶ
+func PublishDueEntries(now time.Time, limit int) func(ctx context.Context, r Repository) ([]*Entry, error) {
+	return func(ctx context.Context, r Repository) ([]*Entry, error) {
+		entries, err := r.Entry().ListDueScheduledForUpdate(ctx, now, limit)
+		if err != nil {
+			return nil, err
+		}
+		published := make([]*Entry, 0, len(entries))
+		for _, e := range entries {
+			entry, err := e.Publish(e.PublishedAt)(ctx, r)
+			if err != nil {
+				return nil, err
+			}
+			published = append(published, entry)
+		}
+		return published, nil
+	}
+}
+
+// IsTrashed はエントリがゴミ箱にあるかを判定する
+func (e Entry) IsTrashed() bool {
+	return e.DeletedAt != nil
+}
+
+// PurgeAt はゴミ箱にあるエントリが完全に削除される日時を返す
+// retention はゴミ箱に残しておく期間で, ゴミ箱にない場合はゼロ値を返す
+func (e Entry) PurgeAt(retention time.Duration) time.Time {
+	if e.DeletedAt == nil {
+		return time.Time{}
+	}
+	return e.DeletedAt.Add(retention)
+}
+
+// Trash はエントリをゴミ箱に移動する
+// ゴミ箱にあるエントリは公開状態によらず読者から見えなくなるが, 完全に削除されるまでは元に戻せる
+func (e Entry) Trash(deletedAt time.Time) func(ctx context.Context, r Repository) (*Entry, error) {
+	return func(ctx context.Context, r Repository) (*Entry, error) {
+		if e.IsTrashed() {
+			return &e, nil
+		}
+		entry, err := r.Entry().UpdateDeletedAt(ctx, e.ID, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+		// 公開されていたエントリは読者から見えなくなる
+		if e.IsPublished() {
+			err := recordEvent(ctx, r, &EntryUnpublished{BlogID: e.BlogID, EntryID: e.ID, Trashed: true, UnpublishedAt: deletedAt}, deletedAt)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return entry, nil
+	}
+}
+
+// Restore はゴミ箱にあるエントリを元に戻す
+func (e Entry) Restore(restoredAt time.Time) func(ctx context.Context, r Repository) (*Entry, error) {
+	return func(ctx context.Context, r Repository) (*Entry, error) {
+		if !e.IsTrashed() {
+			return &e, nil
+		}
+		entry, err := r.Entry().UpdateDeletedAt(ctx, e.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		// 公開されていたエントリは再び読者から見えるようになる
+		if e.IsPublished() {
+			err := recordEvent(ctx, r, &EntryPublished{BlogID: e.BlogID, EntryID: e.ID, PublishedAt: e.PublishedAt}, restoredAt)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return entry, nil
+	}
+}
+
+// Purge はエントリをリビジョン, タグ付け, コメント, 共有リンク, 取り込み元との対応, 閲覧数, スター, 古いスラッグとともに完全に削除する
+// 途中で失敗してもリビジョンやコメントだけが残ることのないよう, トランザクション内で呼ぶ
+func (e Entry) Purge() func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		if err := r.EntrySlugRedirect().DeleteByEntryID(ctx, e.ID); err != nil {
+			return err
+		}
+		if err := r.EntryImport().DeleteByEntryID(ctx, e.ID); err != nil {
+			return err
+		}
+		if err := r.EntryView().DeleteByEntryID(ctx, e.ID); err != nil {
+			return err
+		}
+		if err := r.Star().DeleteByEntryID(ctx, e.ID); err != nil {
+			return err
+		}
+		if err := r.ShareLink().DeleteByEntryID(ctx, e.ID); err != nil {
+			return err
+		}
+		if err := r.Comment().DeleteByEntryID(ctx, e.ID); err != nil {
+			return err
+		}
+		if err := r.Tag().SetEntryTags(ctx, e.ID, nil); err != nil {
+			return err
+		}
+		if err := r.EntryRevision().DeleteByEntryID(ctx, e.ID); err != nil {
+			return err
+		}
 		return r.Entry().Delete(ctx, e.ID)
 	}
 }
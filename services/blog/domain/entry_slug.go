@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// EntrySlugRedirect はスラッグを変更したエントリの古いスラッグを表す
+// 古いスラッグのパーマリンクへのアクセスは新しいパーマリンクにリダイレクトし, エントリが完全に削除されるまで他のエントリには使わせない
+type EntrySlugRedirect struct {
+	BlogID    BlogID    `db:"blog_id"`
+	Slug      string    `db:"slug"`
+	EntryID   EntryID   `db:"entry_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// CreateEntrySlugRedirectInput は古いスラッグ作成時の入力
+type CreateEntrySlugRedirectInput struct {
+	BlogID    BlogID
+	Slug      string
+	EntryID   EntryID
+	CreatedAt time.Time
+}
+
+// EntrySlugRedirectRepository はエントリの古いスラッグのリポジトリ
+type EntrySlugRedirectRepository interface {
+	Create(ctx context.Context, input *CreateEntrySlugRedirectInput) (*EntrySlugRedirect, error)
+	FindBySlug(ctx context.Context, blogID BlogID, slug string) (*EntrySlugRedirect, error)
+	Delete(ctx context.Context, blogID BlogID, slug string) error
+	// DeleteByEntryID はエントリのすべての古いスラッグを削除する
+	DeleteByEntryID(ctx context.Context, entryID EntryID) error
+	// DeleteByBlogID はブログのすべてのエントリの古いスラッグを削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// checkSlugAvailable はスラッグを entryID のエントリが使えるかを確かめる
+// ブログの中で他のエントリが使っているスラッグや, 他のエントリの古いスラッグとして予約されているスラッグには ErrAlreadyExists を返す
+func checkSlugAvailable(ctx context.Context, r Repository, blogID BlogID, slug string, entryID EntryID) error {
+	other, err := r.Entry().FindBySlug(ctx, blogID, slug)
+	if err == nil && other.ID != entryID {
+		return ErrAlreadyExists
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	redirect, err := r.EntrySlugRedirect().FindBySlug(ctx, blogID, slug)
+	if err == nil && redirect.EntryID != entryID {
+		return ErrAlreadyExists
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
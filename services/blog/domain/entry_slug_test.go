@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// entryRepositoryStub はエントリをメモリに保存する. 使わないメソッドは埋め込んだ nil のインターフェースで panic する
+type entryRepositoryStub struct {
+	EntryRepository
+	entries map[EntryID]*Entry
+}
+
+func (r *entryRepositoryStub) FindBySlug(ctx context.Context, blogID BlogID, slug string) (*Entry, error) {
+	for _, e := range r.entries {
+		if e.BlogID == blogID && e.Slug == slug {
+			return e, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *entryRepositoryStub) UpdateSlug(ctx context.Context, id EntryID, slug string) (*Entry, error) {
+	e, ok := r.entries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if other, err := r.FindBySlug(ctx, e.BlogID, slug); err == nil && other.ID != id {
+		return nil, ErrAlreadyExists
+	}
+	e.Slug = slug
+	return e, nil
+}
+
+type slugKey struct {
+	blogID BlogID
+	slug   string
+}
+
+type entrySlugRedirectRepositoryStub struct {
+	EntrySlugRedirectRepository
+	redirects map[slugKey]*EntrySlugRedirect
+}
+
+func (r *entrySlugRedirectRepositoryStub) Create(ctx context.Context, input *CreateEntrySlugRedirectInput) (*EntrySlugRedirect, error) {
+	key := slugKey{input.BlogID, input.Slug}
+	if _, ok := r.redirects[key]; ok {
+		return nil, ErrAlreadyExists
+	}
+	redirect := &EntrySlugRedirect{BlogID: input.BlogID, Slug: input.Slug, EntryID: input.EntryID, CreatedAt: input.CreatedAt}
+	r.redirects[key] = redirect
+	return redirect, nil
+}
+
+func (r *entrySlugRedirectRepositoryStub) FindBySlug(ctx context.Context, blogID BlogID, slug string) (*EntrySlugRedirect, error) {
+	redirect, ok := r.redirects[slugKey{blogID, slug}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return redirect, nil
+}
+
+func (r *entrySlugRedirectRepositoryStub) Delete(ctx context.Context, blogID BlogID, slug string) error {
+	delete(r.redirects, slugKey{blogID, slug})
+	return nil
+}
+
+func TestEntryChangeSlug(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		entryID  EntryID
+		slug     string
+		wantErr  error
+		wantSlug string
+		// wantRedirects は変更した後の古いスラッグとそのエントリ
+		wantRedirects map[string]EntryID
+	}{
+		{
+			name:          "rename a published entry",
+			entryID:       1,
+			slug:          "new",
+			wantSlug:      "new",
+			wantRedirects: map[string]EntryID{"first": 1, "old": 2},
+		},
+		{
+			name:          "same slug",
+			entryID:       1,
+			slug:          "first",
+			wantSlug:      "first",
+			wantRedirects: map[string]EntryID{"old": 2},
+		},
+		{
+			name:          "back to the ID",
+			entryID:       1,
+			slug:          "",
+			wantSlug:      "1",
+			wantRedirects: map[string]EntryID{"first": 1, "old": 2},
+		},
+		{
+			name:          "slug used by another entry",
+			entryID:       1,
+			slug:          "second",
+			wantErr:       ErrAlreadyExists,
+			wantSlug:      "first",
+			wantRedirects: map[string]EntryID{"old": 2},
+		},
+		{
+			name:          "old slug of another entry",
+			entryID:       1,
+			slug:          "old",
+			wantErr:       ErrAlreadyExists,
+			wantSlug:      "first",
+			wantRedirects: map[string]EntryID{"old": 2},
+		},
+		{
+			name:          "back to its own old slug",
+			entryID:       2,
+			slug:          "old",
+			wantSlug:      "old",
+			wantRedirects: map[string]EntryID{"second": 2},
+		},
+		{
+			name:          "slug used in another blog",
+			entryID:       1,
+			slug:          "other-blog",
+			wantSlug:      "other-blog",
+			wantRedirects: map[string]EntryID{"first": 1, "old": 2},
+		},
+		{
+			name:          "rename a draft",
+			entryID:       3,
+			slug:          "new",
+			wantSlug:      "new",
+			wantRedirects: map[string]EntryID{"old": 2},
+		},
+		{
+			name:          "rename an entry with the ID as the slug",
+			entryID:       4,
+			slug:          "new",
+			wantSlug:      "new",
+			wantRedirects: map[string]EntryID{"old": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &repositoryStub{
+				entry: &entryRepositoryStub{entries: map[EntryID]*Entry{
+					1: {ID: 1, BlogID: 1, Slug: "first", Status: EntryStatusPublished},
+					2: {ID: 2, BlogID: 1, Slug: "second", Status: EntryStatusPublished},
+					3: {ID: 3, BlogID: 1, Slug: "draft", Status: EntryStatusDraft},
+					4: {ID: 4, BlogID: 1, Slug: "4", Status: EntryStatusPublished},
+					5: {ID: 5, BlogID: 2, Slug: "other-blog", Status: EntryStatusPublished},
+				}},
+				entrySlug: &entrySlugRedirectRepositoryStub{redirects: map[slugKey]*EntrySlugRedirect{
+					{1, "old"}: {BlogID: 1, Slug: "old", EntryID: 2},
+				}},
+			}
+			entry := *repo.entry.entries[tt.entryID]
+
+			_, err := entry.ChangeSlug(tt.slug, now)(ctx, repo)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ChangeSlug() = %v, want %v", err, tt.wantErr)
+			}
+			if got := repo.entry.entries[tt.entryID].Slug; got != tt.wantSlug {
+				t.Errorf("slug = %q, want %q", got, tt.wantSlug)
+			}
+			redirects := map[string]EntryID{}
+			for key, r := range repo.entrySlug.redirects {
+				if key.blogID == 1 {
+					redirects[key.slug] = r.EntryID
+				}
+			}
+			if len(redirects) != len(tt.wantRedirects) {
+				t.Errorf("redirects = %v, want %v", redirects, tt.wantRedirects)
+			}
+			for slug, id := range tt.wantRedirects {
+				if redirects[slug] != id {
+					t.Errorf("redirects = %v, want %v", redirects, tt.wantRedirects)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// EntryViewBucket は閲覧数を数える時間の区切り. 閲覧数は UTC の日ごとに数える
+const EntryViewBucket = 24 * time.Hour
+
+// EntryViewDay は viewedAt の閲覧を数える日を返す
+func EntryViewDay(viewedAt time.Time) time.Time {
+	return viewedAt.UTC().Truncate(EntryViewBucket)
+}
+
+// EntryViewCount はエントリの閲覧数を表す
+type EntryViewCount struct {
+	EntryID EntryID `db:"entry_id"`
+	Count   int64   `db:"count"`
+}
+
+// AddEntryViewsInput はエントリの閲覧数を加えるときの入力
+type AddEntryViewsInput struct {
+	EntryID EntryID
+	BlogID  BlogID
+	// Day は閲覧を数える日. EntryViewDay で求める
+	Day   time.Time
+	Count int64
+}
+
+// EntryViewRepository はエントリの閲覧数のリポジトリ
+type EntryViewRepository interface {
+	// Add はエントリの日ごとの閲覧数に加える
+	Add(ctx context.Context, inputs []*AddEntryViewsInput) error
+	// CountByEntryIDs は複数のエントリのこれまでの閲覧数を返す. 閲覧されていないエントリは結果に含まれない
+	CountByEntryIDs(ctx context.Context, entryIDs []EntryID) (map[EntryID]int64, error)
+	// ListPopularByBlogID はブログの公開されているエントリを since の日からの閲覧数の多い順に返す
+	// listedOnly が true の場合は公開範囲が public のエントリのみを返す
+	ListPopularByBlogID(ctx context.Context, blogID BlogID, since time.Time, listedOnly bool, limit int) ([]*EntryViewCount, error)
+	// ListPopular はすべてのブログの公開されているエントリを since の日からの閲覧数の多い順に返す
+	// ゴミ箱にあるブログのエントリや, エントリかブログの公開範囲が public でないものは含まない
+	ListPopular(ctx context.Context, since time.Time, limit int) ([]*EntryViewCount, error)
+	DeleteByEntryID(ctx context.Context, entryID EntryID) error
+	// DeleteByBlogID はブログのすべてのエントリの閲覧数を削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// CountsView はエントリの閲覧を閲覧数に数えるかを判定する
+// 読者に見えていないエントリの閲覧と, 書いたユーザー自身の閲覧は数えない
+func (e Entry) CountsView(viewer *User) bool {
+	if !e.IsPublished() || e.IsTrashed() {
+		return false
+	}
+	return viewer == nil || viewer.ID != e.AuthorID
+}
@@ -7,3 +7,6 @@ var ErrNotFound = errors.New("not found")
 
 // ErrAlreadyExists はリポジトリに既に重複するエンティティが存在したときに返される
 var ErrAlreadyExists = errors.New("already exists")
+
+// ErrInvalidCursor はページングの位置が不正なときや, 一覧の並び順に合わないときに返される
+var ErrInvalidCursor = errors.New("invalid cursor")
@@ -0,0 +1,236 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EventID はドメインイベントにユニークに割り当てられる ID
+type EventID uint64
+
+func (id EventID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// EventType はドメインイベントの種類を表す
+type EventType string
+
+const (
+	// EventTypeEntryPublished はエントリが公開されたこと
+	EventTypeEntryPublished EventType = "entry.published"
+	// EventTypeEntryEdited はエントリが編集されたこと
+	EventTypeEntryEdited EventType = "entry.edited"
+	// EventTypeEntryUnpublished は公開されていたエントリが読者から見えなくなったこと
+	EventTypeEntryUnpublished EventType = "entry.unpublished"
+	// EventTypeBlogEdited はブログのタイトルや説明, パスが変更されたこと
+	EventTypeBlogEdited EventType = "blog.edited"
+	// EventTypeBlogDeleted はブログがゴミ箱に移動されたこと
+	EventTypeBlogDeleted EventType = "blog.deleted"
+	// EventTypeUserSignedUp はユーザーが登録されたこと
+	EventTypeUserSignedUp EventType = "user.signed_up"
+)
+
+// EventMaxAttempts は 1 つのイベントを購読者に渡す回数の上限. これだけ渡しても処理できなければ諦める
+const EventMaxAttempts = 10
+
+// eventRetryBaseDelay はイベントの処理に失敗してから最初に渡し直すまでの時間. 渡し直すたびに倍にする
+const eventRetryBaseDelay = 30 * time.Second
+
+// DomainEvent はドメインで起きたできごとを表す. 変更と同じトランザクションでアウトボックスに書き込む
+type DomainEvent interface {
+	EventType() EventType
+}
+
+// EntryPublished はエントリが公開されたことを表す
+// 予約投稿は公開日時になって公開したとき, ゴミ箱から戻したエントリは公開されていた場合に起きる
+type EntryPublished struct {
+	BlogID      BlogID    `json:"blog_id,string"`
+	EntryID     EntryID   `json:"entry_id,string"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// EventType は EventTypeEntryPublished を返す
+func (EntryPublished) EventType() EventType { return EventTypeEntryPublished }
+
+// EntryEdited はエントリのタイトルや本文が編集されたことを表す. Status は編集したときの公開状態
+type EntryEdited struct {
+	BlogID   BlogID      `json:"blog_id,string"`
+	EntryID  EntryID     `json:"entry_id,string"`
+	Status   EntryStatus `json:"status"`
+	EditedAt time.Time   `json:"edited_at"`
+}
+
+// EventType は EventTypeEntryEdited を返す
+func (EntryEdited) EventType() EventType { return EventTypeEntryEdited }
+
+// EntryUnpublished は公開されていたエントリが非公開にされたか, ゴミ箱に移動されたことを表す
+type EntryUnpublished struct {
+	BlogID  BlogID  `json:"blog_id,string"`
+	EntryID EntryID `json:"entry_id,string"`
+	// Trashed はゴミ箱に移動されたかどうか
+	Trashed       bool      `json:"trashed"`
+	UnpublishedAt time.Time `json:"unpublished_at"`
+}
+
+// EventType は EventTypeEntryUnpublished を返す
+func (EntryUnpublished) EventType() EventType { return EventTypeEntryUnpublished }
+
+// BlogEdited はブログのタイトルや説明, パスが変更されたことを表す
+type BlogEdited struct {
+	BlogID   BlogID    `json:"blog_id,string"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// EventType は EventTypeBlogEdited を返す
+func (BlogEdited) EventType() EventType { return EventTypeBlogEdited }
+
+// BlogDeleted はブログがゴミ箱に移動されたことを表す
+type BlogDeleted struct {
+	BlogID    BlogID    `json:"blog_id,string"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// EventType は EventTypeBlogDeleted を返す
+func (BlogDeleted) EventType() EventType { return EventTypeBlogDeleted }
+
+// UserSignedUp はユーザーが登録されたことを表す
+type UserSignedUp struct {
+	UserID     UserID    `json:"user_id,string"`
+	Name       string    `json:"name"`
+	SignedUpAt time.Time `json:"signed_up_at"`
+}
+
+// EventType は EventTypeUserSignedUp を返す
+func (UserSignedUp) EventType() EventType { return EventTypeUserSignedUp }
+
+// Event はアウトボックスに書き込んだドメインイベントを表す
+type Event struct {
+	ID   EventID   `db:"id"`
+	Type EventType `db:"type"`
+	// Payload は DomainEvent を JSON にしたもの. Decode で元に戻す
+	Payload    string    `db:"payload"`
+	OccurredAt time.Time `db:"occurred_at"`
+	// TraceParent はイベントを起こしたリクエストのトレースコンテキスト (W3C Trace Context の traceparent)
+	TraceParent string `db:"trace_parent"`
+	// Attempts はこれまでに購読者に渡した回数
+	Attempts int `db:"attempts"`
+	// Error は最後に購読者に渡したときに失敗した理由
+	Error string `db:"error"`
+	// NextAttemptAt は次に購読者に渡す日時. 処理し終わったか諦めた後は nil
+	NextAttemptAt *time.Time `db:"next_attempt_at"`
+	// DispatchedAt はすべての購読者が処理し終わった日時. 処理し終わるまでは nil
+	DispatchedAt *time.Time `db:"dispatched_at"`
+}
+
+// CreateEventInput はドメインイベント作成時の入力
+type CreateEventInput struct {
+	Type       EventType
+	Payload    string
+	OccurredAt time.Time
+}
+
+// UpdateEventInput はドメインイベント更新時の入力
+type UpdateEventInput struct {
+	Attempts      int
+	Error         string
+	NextAttemptAt *time.Time
+	DispatchedAt  *time.Time
+}
+
+// EventRepository はドメインイベントのアウトボックスのリポジトリ
+type EventRepository interface {
+	// Create はドメインイベントをアウトボックスに書き込む. ctx のトレースコンテキストもあわせて保存する
+	Create(ctx context.Context, input *CreateEventInput) (*Event, error)
+	// ListDueForUpdate は購読者に渡していないもののうち次に渡す日時を過ぎたものを, 起きた順に行ロックを取りつつ返す
+	// 他のトランザクションがロックしているものは読み飛ばす
+	ListDueForUpdate(ctx context.Context, now time.Time, limit int) ([]*Event, error)
+	Update(ctx context.Context, id EventID, input *UpdateEventInput) (*Event, error)
+	// DeleteDispatchedBefore は指定した日時より前に処理し終わったか諦めたイベントを最大 limit 件削除し, 削除した件数を返す
+	DeleteDispatchedBefore(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// EventHandler はドメインイベントを処理する購読者
+// r はイベントを処理し終わったことを記録するのと同じトランザクションのリポジトリで, 失敗した場合は r への変更もロールバックされる
+// 同じイベントが二度以上渡されることがあるので, リポジトリの外への副作用は冪等にする
+type EventHandler func(ctx context.Context, r Repository, event *Event) error
+
+// recordEvent はドメインイベントをアウトボックスに書き込む
+func recordEvent(ctx context.Context, r Repository, e DomainEvent, occurredAt time.Time) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = r.Event().Create(ctx, &CreateEventInput{
+		Type:       e.EventType(),
+		Payload:    string(payload),
+		OccurredAt: occurredAt,
+	})
+	return err
+}
+
+// Decode はアウトボックスに書き込んだイベントを DomainEvent に戻す
+func (e Event) Decode() (DomainEvent, error) {
+	var de DomainEvent
+	switch e.Type {
+	case EventTypeEntryPublished:
+		de = &EntryPublished{}
+	case EventTypeEntryEdited:
+		de = &EntryEdited{}
+	case EventTypeEntryUnpublished:
+		de = &EntryUnpublished{}
+	case EventTypeBlogEdited:
+		de = &BlogEdited{}
+	case EventTypeBlogDeleted:
+		de = &BlogDeleted{}
+	case EventTypeUserSignedUp:
+		de = &UserSignedUp{}
+	default:
+		return nil, fmt.Errorf("unknown event type: %s", e.Type)
+	}
+	if err := json.Unmarshal([]byte(e.Payload), de); err != nil {
+		return nil, err
+	}
+	return de, nil
+}
+
+// EventRetryDelay は attempts 回処理に失敗したイベントを, 次に購読者に渡すまでに待つ時間を返す
+func EventRetryDelay(attempts int) time.Duration {
+	return eventRetryBaseDelay << (attempts - 1)
+}
+
+// MarkDispatched はイベントをすべての購読者が処理し終わったことを記録する
+func (e Event) MarkDispatched(dispatchedAt time.Time) func(ctx context.Context, r Repository) (*Event, error) {
+	return func(ctx context.Context, r Repository) (*Event, error) {
+		return r.Event().Update(ctx, e.ID, &UpdateEventInput{
+			Attempts:     e.Attempts + 1,
+			DispatchedAt: &dispatchedAt,
+		})
+	}
+}
+
+// Fail はイベントの処理に失敗したことを理由とともに記録する
+// 渡す回数の上限に達していなければ, 渡した回数に応じて指数的に間隔を空けて渡し直す. 達していれば諦める
+func (e Event) Fail(reason string, failedAt time.Time) func(ctx context.Context, r Repository) (*Event, error) {
+	return func(ctx context.Context, r Repository) (*Event, error) {
+		attempts := e.Attempts + 1
+		input := &UpdateEventInput{
+			Attempts: attempts,
+			Error:    reason,
+		}
+		if attempts < EventMaxAttempts {
+			next := failedAt.Add(EventRetryDelay(attempts))
+			input.NextAttemptAt = &next
+		} else {
+			input.DispatchedAt = &failedAt
+		}
+		return r.Event().Update(ctx, e.ID, input)
+	}
+}
+
+// IsAbandoned は処理に失敗し続けたために, 購読者に渡すのを諦めたイベントかを判定する
+func (e Event) IsAbandoned() bool {
+	return e.DispatchedAt != nil && e.Error != "" && e.Attempts >= EventMaxAttempts
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{EventMaxAttempts - 1, 128 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := EventRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("EventRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+	// 渡し直すたびに待つ時間は倍になる
+	for attempts := 1; attempts < EventMaxAttempts; attempts++ {
+		if got, prev := EventRetryDelay(attempts+1), EventRetryDelay(attempts); got != 2*prev {
+			t.Errorf("EventRetryDelay(%d) = %v, want twice %v", attempts+1, got, prev)
+		}
+	}
+}
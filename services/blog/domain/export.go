@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// BlogExportID はブログの書き出しにユニークに割り当てられる ID
+type BlogExportID uint64
+
+func (id BlogExportID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseBlogExportID は文字列の BlogExportID をパースする
+func ParseBlogExportID(str string) (BlogExportID, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return BlogExportID(0), err
+	}
+	return BlogExportID(id), nil
+}
+
+// BlogExportStatus はブログの書き出しの状態を表す
+type BlogExportStatus string
+
+const (
+	// BlogExportStatusPending は書き出しを待っている状態
+	BlogExportStatusPending BlogExportStatus = "pending"
+	// BlogExportStatusRunning は書き出している途中の状態
+	BlogExportStatusRunning BlogExportStatus = "running"
+	// BlogExportStatusCompleted は書き出しが終わり, ダウンロードできる状態
+	BlogExportStatusCompleted BlogExportStatus = "completed"
+	// BlogExportStatusFailed は書き出しに失敗した状態
+	BlogExportStatusFailed BlogExportStatus = "failed"
+)
+
+// BlogExport はブログを zip ファイルに書き出すジョブを表す
+// 書き出した zip ファイルは MediaStorage に保存し, リポジトリには状態だけを保存する
+type BlogExport struct {
+	ID          BlogExportID     `db:"id"`
+	BlogID      BlogID           `db:"blog_id"`
+	RequestedBy UserID           `db:"requested_by"`
+	Status      BlogExportStatus `db:"status"`
+	// Size は書き出した zip ファイルの大きさ. 書き出しが終わるまでは 0
+	Size int64 `db:"size"`
+	// Error は書き出しに失敗した理由
+	Error     string    `db:"error"`
+	CreatedAt time.Time `db:"created_at"`
+	// StartedAt は書き出しを始めた日時. 始めるまでは nil
+	StartedAt *time.Time `db:"started_at"`
+	// CompletedAt は書き出しが終わった日時. 終わるまでは nil
+	CompletedAt *time.Time `db:"completed_at"`
+}
+
+// CreateBlogExportInput はブログの書き出し作成時の入力
+type CreateBlogExportInput struct {
+	BlogID      BlogID
+	RequestedBy UserID
+	CreatedAt   time.Time
+}
+
+// UpdateBlogExportInput はブログの書き出し更新時の入力
+type UpdateBlogExportInput struct {
+	Status      BlogExportStatus
+	Size        int64
+	Error       string
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+// BlogExportRepository はブログの書き出しのリポジトリ
+type BlogExportRepository interface {
+	Create(ctx context.Context, input *CreateBlogExportInput) (*BlogExport, error)
+	FindByID(ctx context.Context, id BlogExportID) (*BlogExport, error)
+	// ListByBlogID はブログのすべての書き出しを新しい順に返す
+	ListByBlogID(ctx context.Context, blogID BlogID) ([]*BlogExport, error)
+	// ListRunnableForUpdate は書き出しを待っているものと, staleBefore より前に始めたまま終わっていないものを
+	// 古い順に行ロックを取りつつ返す. 他のトランザクションがロックしているものは読み飛ばす
+	ListRunnableForUpdate(ctx context.Context, staleBefore time.Time, limit int) ([]*BlogExport, error)
+	// ListCreatedBefore は指定した日時より前に作成した書き出しを古い順に返す
+	ListCreatedBefore(ctx context.Context, before time.Time, limit int) ([]*BlogExport, error)
+	Update(ctx context.Context, id BlogExportID, input *UpdateBlogExportInput) (*BlogExport, error)
+	Delete(ctx context.Context, id BlogExportID) error
+	// DeleteByBlogID はブログのすべての書き出しを削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// StorageKey は書き出した zip ファイルを保存する MediaStorage のキーを返す
+func (e BlogExport) StorageKey() string {
+	return "exports/" + e.ID.String() + ".zip"
+}
+
+// IsFinished は書き出しが終わったか失敗したかを判定する
+func (e BlogExport) IsFinished() bool {
+	return e.Status == BlogExportStatusCompleted || e.Status == BlogExportStatusFailed
+}
+
+// ExpiresAt は書き出しが削除されダウンロードできなくなる日時を返す. retention は書き出しを残しておく期間
+func (e BlogExport) ExpiresAt(retention time.Duration) time.Time {
+	return e.CreatedAt.Add(retention)
+}
+
+// RequestExport はブログを zip ファイルに書き出すジョブを登録する. 書き出しはバックグラウンドで行う
+// 書き出しを待っているか書き出している途中のものがあれば, 新しく登録せずにそれを返す
+func (b Blog) RequestExport(requester *User, requestedAt time.Time) func(ctx context.Context, r Repository) (*BlogExport, error) {
+	return func(ctx context.Context, r Repository) (*BlogExport, error) {
+		exports, err := r.BlogExport().ListByBlogID(ctx, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range exports {
+			if !e.IsFinished() {
+				return e, nil
+			}
+		}
+		return r.BlogExport().Create(ctx, &CreateBlogExportInput{
+			BlogID:      b.ID,
+			RequestedBy: requester.ID,
+			CreatedAt:   requestedAt,
+		})
+	}
+}
+
+// StartBlogExports は書き出しを待っているものを書き出している途中の状態にして返す
+// staleBefore より前に始めたまま終わっていないものは, 書き出していたプロセスが止まったものとして始め直す
+// 複数のプロセスから同時に呼ばれても同じ書き出しを二重に始めないよう, 行ロックを取るトランザクション内で呼ぶ
+func StartBlogExports(now, staleBefore time.Time, limit int) func(ctx context.Context, r Repository) ([]*BlogExport, error) {
+	return func(ctx context.Context, r Repository) ([]*BlogExport, error) {
+		exports, err := r.BlogExport().ListRunnableForUpdate(ctx, staleBefore, limit)
+		if err != nil {
+			return nil, err
+		}
+		started := make([]*BlogExport, 0, len(exports))
+		for _, e := range exports {
+			export, err := r.BlogExport().Update(ctx, e.ID, &UpdateBlogExportInput{
+				Status:    BlogExportStatusRunning,
+				StartedAt: &now,
+			})
+			if err != nil {
+				return nil, err
+			}
+			started = append(started, export)
+		}
+		return started, nil
+	}
+}
+
+// Complete は書き出しが終わったことを記録する
+func (e BlogExport) Complete(size int64, completedAt time.Time) func(ctx context.Context, r Repository) (*BlogExport, error) {
+	return func(ctx context.Context, r Repository) (*BlogExport, error) {
+		return r.BlogExport().Update(ctx, e.ID, &UpdateBlogExportInput{
+			Status:      BlogExportStatusCompleted,
+			Size:        size,
+			StartedAt:   e.StartedAt,
+			CompletedAt: &completedAt,
+		})
+	}
+}
+
+// Fail は書き出しに失敗したことを理由とともに記録する
+func (e BlogExport) Fail(reason string, completedAt time.Time) func(ctx context.Context, r Repository) (*BlogExport, error) {
+	return func(ctx context.Context, r Repository) (*BlogExport, error) {
+		return r.BlogExport().Update(ctx, e.ID, &UpdateBlogExportInput{
+			Status:      BlogExportStatusFailed,
+			Error:       reason,
+			StartedAt:   e.StartedAt,
+			CompletedAt: &completedAt,
+		})
+	}
+}
+
+// Delete は書き出しをリポジトリから削除する. zip ファイルの削除は呼び出し側で行う
+func (e BlogExport) Delete() func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		return r.BlogExport().Delete(ctx, e.ID)
+	}
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// EntryImport は他のブログサービスから取り込んだエントリと, 取り込み元のデータでのエントリの識別子の対応を表す
+// 同じデータを何度取り込んでも, エントリが重複して作られないようにするために使う
+type EntryImport struct {
+	BlogID    BlogID    `db:"blog_id"`
+	SourceKey string    `db:"source_key"`
+	EntryID   EntryID   `db:"entry_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// CreateEntryImportInput は取り込み元との対応作成時の入力
+type CreateEntryImportInput struct {
+	BlogID    BlogID
+	SourceKey string
+	EntryID   EntryID
+	CreatedAt time.Time
+}
+
+// EntryImportRepository は取り込み元との対応のリポジトリ
+type EntryImportRepository interface {
+	Create(ctx context.Context, input *CreateEntryImportInput) (*EntryImport, error)
+	FindBySourceKey(ctx context.Context, blogID BlogID, sourceKey string) (*EntryImport, error)
+	DeleteByEntryID(ctx context.Context, entryID EntryID) error
+	// DeleteByBlogID はブログのすべての取り込み元との対応を削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// ImportEntryInput は他のブログサービスから取り込むエントリの内容
+type ImportEntryInput struct {
+	// SourceKey は取り込み元のデータでエントリを識別する文字列
+	SourceKey string
+	Title     string
+	Body      string
+	Format    EntryFormat
+	// Status は公開済み, 下書き, 予約投稿のいずれか
+	Status      EntryStatus
+	Visibility  Visibility
+	PublishedAt time.Time
+}
+
+// ImportEntry は他のブログサービスから取り込んだエントリを author が書いたものとして作成する
+// 公開日時は取り込み元のものを保ち, 本文は記法にあわせた BodyRenderer で変換する
+// 同じ SourceKey のエントリを既に取り込んでいる場合は ErrAlreadyExists を返す
+func (b Blog) ImportEntry(author *User, input *ImportEntryInput, importedAt time.Time) func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
+	return func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
+		_, err := r.EntryImport().FindBySourceKey(ctx, b.ID, input.SourceKey)
+		if err == nil {
+			return nil, ErrAlreadyExists
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		bodyHTML, err := br.Render(ctx, input.Body)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := r.Entry().Create(ctx, &CreateEntryInput{
+			BlogID:      b.ID,
+			AuthorID:    author.ID,
+			Title:       input.Title,
+			Body:        input.Body,
+			BodyHTML:    bodyHTML,
+			Format:      input.Format,
+			Status:      input.Status,
+			PublishedAt: input.PublishedAt,
+			EditedAt:    input.PublishedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if input.Visibility != entry.Visibility {
+			entry, err = entry.SetVisibility(input.Visibility)(ctx, r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		_, err = r.EntryImport().Create(ctx, &CreateEntryImportInput{
+			BlogID:    b.ID,
+			SourceKey: input.SourceKey,
+			EntryID:   entry.ID,
+			CreatedAt: importedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+}
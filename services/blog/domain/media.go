@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MediaID はメディアにユニークに割り当てられる ID
+type MediaID uint64
+
+func (id MediaID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseMediaID は文字列の MediaID をパースする
+func ParseMediaID(str string) (MediaID, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return MediaID(0), err
+	}
+	return MediaID(id), nil
+}
+
+// ThumbnailSize はサムネイルの幅と高さの上限
+const ThumbnailSize = 320
+
+// Media はブログにアップロードされた画像を表す
+// ファイル本体とサムネイルは MediaStorage に保存し, リポジトリには大きさなどの情報だけを保存する
+type Media struct {
+	ID          MediaID   `db:"id"`
+	BlogID      BlogID    `db:"blog_id"`
+	UploadedBy  UserID    `db:"uploaded_by"`
+	Filename    string    `db:"filename"`
+	ContentType string    `db:"content_type"`
+	Size        int64     `db:"size"`
+	Width       int       `db:"width"`
+	Height      int       `db:"height"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// CreateMediaInput はメディア作成時の入力
+type CreateMediaInput struct {
+	BlogID      BlogID
+	UploadedBy  UserID
+	Filename    string
+	ContentType string
+	Size        int64
+	Width       int
+	Height      int
+	CreatedAt   time.Time
+}
+
+// MediaRepository はメディアのリポジトリ
+type MediaRepository interface {
+	Create(ctx context.Context, input *CreateMediaInput) (*Media, error)
+	FindByID(ctx context.Context, id MediaID) (*Media, error)
+	ListByIDs(ctx context.Context, ids []MediaID) ([]*Media, error)
+	// ListByBlogID はブログのメディアをアップロードされた新しい順に cursor の位置から返す. cursor が nil の場合は先頭から返す
+	ListByBlogID(ctx context.Context, blogID BlogID, cursor *Cursor, limit int) ([]*Media, error)
+	Delete(ctx context.Context, id MediaID) error
+	// DeleteByBlogID はブログのすべてのメディアを削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// MediaStorage はメディアのファイルを保存するストレージ
+type MediaStorage interface {
+	// Put は body の先頭からのデータをファイルとして key に保存する. 既にあれば上書きする
+	// 大きなファイルをメモリに載せずに保存できるよう, 一時ファイルなども渡せる io.ReadSeeker で受け取る
+	Put(ctx context.Context, key, contentType string, body io.ReadSeeker) error
+	// Get は key に保存されたファイルを返す. ファイルがない場合は ErrNotFound を返す
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete は key に保存されたファイルを削除する. ファイルがなくてもエラーにはしない
+	Delete(ctx context.Context, key string) error
+}
+
+// URL はメディアを参照する URL のパスを返す. エントリの本文ではこの URL で画像を参照する
+func (m Media) URL() string {
+	return "/media/" + m.ID.String()
+}
+
+// ThumbnailURL はメディアのサムネイルを参照する URL のパスを返す
+func (m Media) ThumbnailURL() string {
+	return m.URL() + "/thumbnail"
+}
+
+// StorageKey はメディアのファイルを保存する MediaStorage のキーを返す
+func (m Media) StorageKey() string {
+	return "media/" + m.ID.String() + "/original"
+}
+
+// ThumbnailStorageKey はメディアのサムネイルを保存する MediaStorage のキーを返す
+func (m Media) ThumbnailStorageKey() string {
+	return "media/" + m.ID.String() + "/thumbnail"
+}
+
+// ThumbnailContentType はサムネイルの形式を返す. JPEG 以外の画像は透過を保つため PNG にする
+func (m Media) ThumbnailContentType() string {
+	if m.ContentType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// ThumbnailDimensions は縦横比を保ったまま ThumbnailSize に収めたサムネイルの幅と高さを返す
+// 元の画像が ThumbnailSize に収まる場合は拡大しない
+func (m Media) ThumbnailDimensions() (int, int) {
+	width, height := m.Width, m.Height
+	if width <= ThumbnailSize && height <= ThumbnailSize {
+		return width, height
+	}
+	if width >= height {
+		return ThumbnailSize, max(1, height*ThumbnailSize/width)
+	}
+	return max(1, width*ThumbnailSize/height), ThumbnailSize
+}
+
+// ThumbnailWidth はサムネイルの幅を返す
+func (m Media) ThumbnailWidth() int {
+	width, _ := m.ThumbnailDimensions()
+	return width
+}
+
+// ThumbnailHeight はサムネイルの高さを返す
+func (m Media) ThumbnailHeight() int {
+	_, height := m.ThumbnailDimensions()
+	return height
+}
+
+var altReplacer = strings.NewReplacer("[", "", "]", "", "\n", " ")
+
+// Markdown はエントリの本文に貼り付けて画像を参照するための記法を返す. ファイル名を代替テキストにする
+func (m Media) Markdown() string {
+	return "![" + altReplacer.Replace(m.Filename) + "](" + m.URL() + ")"
+}
+
+// UploadMedia はブログに新規メディアを登録する. ファイル本体の保存は呼び出し側で行う
+func (b Blog) UploadMedia(uploader *User, filename, contentType string, size int64, width, height int, createdAt time.Time) func(ctx context.Context, r Repository) (*Media, error) {
+	return func(ctx context.Context, r Repository) (*Media, error) {
+		return r.Media().Create(ctx, &CreateMediaInput{
+			BlogID:      b.ID,
+			UploadedBy:  uploader.ID,
+			Filename:    filename,
+			ContentType: contentType,
+			Size:        size,
+			Width:       width,
+			Height:      height,
+			CreatedAt:   createdAt,
+		})
+	}
+}
+
+// Delete はメディアをリポジトリから削除する. ファイル本体の削除は呼び出し側で行う
+func (m Media) Delete() func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		return r.Media().Delete(ctx, m.ID)
+	}
+}
@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MemberRole はブログのメンバーの役割を表す
+type MemberRole string
+
+const (
+	// MemberRoleOwner はブログの設定やメンバーの管理を含め, すべての操作ができる役割
+	// ブログを作成したユーザーかオーナーを譲り受けたユーザーだけの役割で, 招待や役割の変更では指定できない
+	MemberRoleOwner MemberRole = "owner"
+	// MemberRoleEditor はエントリの投稿や編集, コメントのモデレーションができる役割
+	MemberRoleEditor MemberRole = "editor"
+	// MemberRoleViewer は下書きや編集履歴などの公開されていない内容を読むことだけができる役割
+	MemberRoleViewer MemberRole = "viewer"
+)
+
+// ParseMemberRole は招待や役割の変更で指定された文字列の MemberRole をパースする
+// オーナーは Blog.TransferOwnership で譲るものなので, MemberRoleOwner は受け付けない
+func ParseMemberRole(str string) (MemberRole, bool) {
+	switch role := MemberRole(str); role {
+	case MemberRoleEditor, MemberRoleViewer:
+		return role, true
+	}
+	return "", false
+}
+
+// Permission はブログに対する操作の権限を表す
+type Permission string
+
+const (
+	// PermissionReadDrafts は下書きや編集履歴, コメントの一覧などの公開されていない内容を読む権限
+	PermissionReadDrafts Permission = "read_drafts"
+	// PermissionWriteEntries はエントリを投稿, 編集, 削除する権限
+	PermissionWriteEntries Permission = "write_entries"
+	// PermissionModerateComments はコメントを承認, 非表示, 削除する権限
+	PermissionModerateComments Permission = "moderate_comments"
+	// PermissionManageBlog はブログの設定を変更し, ブログを削除し, メンバーを管理する権限
+	PermissionManageBlog Permission = "manage_blog"
+	// PermissionTransferOwnership はブログのオーナーを他のメンバーに譲る権限
+	PermissionTransferOwnership Permission = "transfer_ownership"
+)
+
+var rolePermissions = map[MemberRole][]Permission{
+	MemberRoleOwner:  {PermissionReadDrafts, PermissionWriteEntries, PermissionModerateComments, PermissionManageBlog, PermissionTransferOwnership},
+	MemberRoleEditor: {PermissionReadDrafts, PermissionWriteEntries, PermissionModerateComments},
+	MemberRoleViewer: {PermissionReadDrafts},
+}
+
+// Can は役割に権限があるかを判定する. 役割がゼロ値の場合はどの権限もない
+func (r MemberRole) Can(p Permission) bool {
+	for _, q := range rolePermissions[r] {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Member はブログのメンバーを表す
+// ブログを作成したユーザーはメンバーとして保存せず, 常にオーナーとして扱う
+type Member struct {
+	BlogID    BlogID     `db:"blog_id"`
+	UserID    UserID     `db:"user_id"`
+	Role      MemberRole `db:"role"`
+	InvitedBy UserID     `db:"invited_by"`
+	InvitedAt time.Time  `db:"invited_at"`
+	// AcceptedAt は招待を承諾した日時. 承諾されるまでは nil で, 役割の権限もない
+	AcceptedAt *time.Time `db:"accepted_at"`
+}
+
+// CreateMemberInput はメンバー作成時の入力
+type CreateMemberInput struct {
+	BlogID    BlogID
+	UserID    UserID
+	Role      MemberRole
+	InvitedBy UserID
+	InvitedAt time.Time
+}
+
+// MemberRepository はブログのメンバーのリポジトリ
+type MemberRepository interface {
+	Create(ctx context.Context, input *CreateMemberInput) (*Member, error)
+	Find(ctx context.Context, blogID BlogID, userID UserID) (*Member, error)
+	// ListByBlogID はブログのメンバーを招待を承諾していないものも含めて招待した順に返す
+	ListByBlogID(ctx context.Context, blogID BlogID) ([]*Member, error)
+	// ListInvitationsByUserID はユーザーがまだ承諾していない招待を新しい順に返す
+	ListInvitationsByUserID(ctx context.Context, userID UserID) ([]*Member, error)
+	UpdateRole(ctx context.Context, blogID BlogID, userID UserID, role MemberRole) (*Member, error)
+	UpdateAcceptedAt(ctx context.Context, blogID BlogID, userID UserID, acceptedAt time.Time) (*Member, error)
+	Delete(ctx context.Context, blogID BlogID, userID UserID) error
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// IsAccepted はメンバーが招待を承諾しているかを判定する
+func (m Member) IsAccepted() bool {
+	return m.AcceptedAt != nil
+}
+
+// RoleOf はユーザーのブログでの役割を返す
+// ブログを作成したユーザーはオーナーで, メンバーでないユーザーや招待を承諾していないユーザーはゼロ値になる
+func (b Blog) RoleOf(user *User) func(ctx context.Context, r Repository) (MemberRole, error) {
+	return func(ctx context.Context, r Repository) (MemberRole, error) {
+		if user == nil {
+			return "", nil
+		}
+		if b.UserID == user.ID {
+			return MemberRoleOwner, nil
+		}
+		member, err := r.Member().Find(ctx, b.ID, user.ID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return "", nil
+			}
+			return "", err
+		}
+		if !member.IsAccepted() {
+			return "", nil
+		}
+		return member.Role, nil
+	}
+}
+
+// InviteMember はユーザーをブログのメンバーに招待する
+// ブログを作成したユーザーや, 既に招待されているユーザーは招待できない
+func (b Blog) InviteMember(inviter, invitee *User, role MemberRole, invitedAt time.Time) func(ctx context.Context, r Repository) (*Member, error) {
+	return func(ctx context.Context, r Repository) (*Member, error) {
+		if b.UserID == invitee.ID {
+			return nil, ErrAlreadyExists
+		}
+		_, err := r.Member().Find(ctx, b.ID, invitee.ID)
+		if err == nil {
+			return nil, ErrAlreadyExists
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		return r.Member().Create(ctx, &CreateMemberInput{
+			BlogID:    b.ID,
+			UserID:    invitee.ID,
+			Role:      role,
+			InvitedBy: inviter.ID,
+			InvitedAt: invitedAt,
+		})
+	}
+}
+
+// Accept はブログへの招待を承諾する
+func (m Member) Accept(acceptedAt time.Time) func(ctx context.Context, r Repository) (*Member, error) {
+	return func(ctx context.Context, r Repository) (*Member, error) {
+		if m.IsAccepted() {
+			return &m, nil
+		}
+		return r.Member().UpdateAcceptedAt(ctx, m.BlogID, m.UserID, acceptedAt)
+	}
+}
+
+// ChangeRole はメンバーの役割を変更する
+func (m Member) ChangeRole(role MemberRole) func(ctx context.Context, r Repository) (*Member, error) {
+	return func(ctx context.Context, r Repository) (*Member, error) {
+		return r.Member().UpdateRole(ctx, m.BlogID, m.UserID, role)
+	}
+}
+
+// TransferOwnership はブログのオーナーを招待を承諾したメンバーに譲る
+// 譲ったユーザーは editor のメンバーとして残り, 譲り受けたユーザーはメンバーではなくなる
+// 途中で失敗してオーナーがいなくならないよう, トランザクション内で呼ぶ
+func (b Blog) TransferOwnership(member *Member, transferredAt time.Time) func(ctx context.Context, r Repository) (*Blog, error) {
+	return func(ctx context.Context, r Repository) (*Blog, error) {
+		if err := r.Member().Delete(ctx, b.ID, member.UserID); err != nil {
+			return nil, err
+		}
+		_, err := r.Member().Create(ctx, &CreateMemberInput{
+			BlogID:    b.ID,
+			UserID:    b.UserID,
+			Role:      MemberRoleEditor,
+			InvitedBy: member.UserID,
+			InvitedAt: transferredAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.Member().UpdateAcceptedAt(ctx, b.ID, b.UserID, transferredAt); err != nil {
+			return nil, err
+		}
+		return r.Blog().UpdateUserID(ctx, b.ID, member.UserID)
+	}
+}
+
+// Remove はメンバーをブログから外す. 承諾されていない招待の取り消しや辞退にも使う
+func (m Member) Remove() func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		return r.Member().Delete(ctx, m.BlogID, m.UserID)
+	}
+}
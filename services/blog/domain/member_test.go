@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseMemberRole(t *testing.T) {
+	tests := []struct {
+		str  string
+		want MemberRole
+		ok   bool
+	}{
+		{"editor", MemberRoleEditor, true},
+		{"viewer", MemberRoleViewer, true},
+		// オーナーは招待や役割の変更では指定できない
+		{"owner", "", false},
+		{"", "", false},
+		{"Editor", "", false},
+		{"admin", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			got, ok := ParseMemberRole(tt.str)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ParseMemberRole(%q) = (%q, %v), want (%q, %v)", tt.str, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMemberRoleCan(t *testing.T) {
+	tests := []struct {
+		role MemberRole
+		p    Permission
+		want bool
+	}{
+		{MemberRoleOwner, PermissionManageBlog, true},
+		{MemberRoleEditor, PermissionManageBlog, false},
+		{MemberRoleOwner, PermissionTransferOwnership, true},
+		{MemberRoleEditor, PermissionTransferOwnership, false},
+		{MemberRoleViewer, PermissionTransferOwnership, false},
+		{MemberRoleEditor, PermissionWriteEntries, true},
+		{MemberRoleViewer, PermissionWriteEntries, false},
+		{MemberRoleViewer, PermissionReadDrafts, true},
+		{"", PermissionReadDrafts, false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.p); got != tt.want {
+			t.Errorf("%q.Can(%q) = %v, want %v", tt.role, tt.p, got, tt.want)
+		}
+	}
+}
+
+type memberKey struct {
+	blogID BlogID
+	userID UserID
+}
+
+// memberRepositoryStub はメンバーをメモリに保存する. 使わないメソッドは埋め込んだ nil のインターフェースで panic する
+type memberRepositoryStub struct {
+	MemberRepository
+	members map[memberKey]*Member
+}
+
+func (r *memberRepositoryStub) Create(ctx context.Context, input *CreateMemberInput) (*Member, error) {
+	key := memberKey{input.BlogID, input.UserID}
+	if _, ok := r.members[key]; ok {
+		return nil, ErrAlreadyExists
+	}
+	m := &Member{BlogID: input.BlogID, UserID: input.UserID, Role: input.Role, InvitedBy: input.InvitedBy, InvitedAt: input.InvitedAt}
+	r.members[key] = m
+	return m, nil
+}
+
+func (r *memberRepositoryStub) Find(ctx context.Context, blogID BlogID, userID UserID) (*Member, error) {
+	m, ok := r.members[memberKey{blogID, userID}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return m, nil
+}
+
+func (r *memberRepositoryStub) UpdateAcceptedAt(ctx context.Context, blogID BlogID, userID UserID, acceptedAt time.Time) (*Member, error) {
+	m, ok := r.members[memberKey{blogID, userID}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	m.AcceptedAt = &acceptedAt
+	return m, nil
+}
+
+func (r *memberRepositoryStub) Delete(ctx context.Context, blogID BlogID, userID UserID) error {
+	delete(r.members, memberKey{blogID, userID})
+	return nil
+}
+
+type blogRepositoryStub struct {
+	BlogRepository
+	blogs map[BlogID]*Blog
+}
+
+func (r *blogRepositoryStub) UpdateUserID(ctx context.Context, id BlogID, userID UserID) (*Blog, error) {
+	b, ok := r.blogs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	b.UserID = userID
+	return b, nil
+}
+
+func TestBlogTransferOwnership(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	blog := &Blog{ID: 1, UserID: 10}
+	stored := *blog
+	member := &Member{BlogID: 1, UserID: 20, Role: MemberRoleViewer, AcceptedAt: &now}
+	repo := &repositoryStub{
+		member: &memberRepositoryStub{members: map[memberKey]*Member{{1, 20}: member}},
+		blog:   &blogRepositoryStub{blogs: map[BlogID]*Blog{1: &stored}},
+	}
+
+	transferred, err := blog.TransferOwnership(member, now)(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transferred.UserID != 20 {
+		t.Errorf("owner = %d, want 20", transferred.UserID)
+	}
+
+	tests := []struct {
+		user UserID
+		want MemberRole
+	}{
+		{20, MemberRoleOwner},
+		{10, MemberRoleEditor},
+		{30, ""},
+	}
+	for _, tt := range tests {
+		role, err := transferred.RoleOf(&User{ID: tt.user})(ctx, repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if role != tt.want {
+			t.Errorf("role of %d = %q, want %q", tt.user, role, tt.want)
+		}
+	}
+	if _, err := repo.Member().Find(ctx, 1, 20); err != ErrNotFound {
+		t.Errorf("new owner is still a member: %v", err)
+	}
+}
@@ -6,4 +6,21 @@ type Repository interface {
 	Session() SessionRepository
 	Blog() BlogRepository
 	Entry() EntryRepository
+	EntryRevision() EntryRevisionRepository
+	Tag() TagRepository
+	EntryView() EntryViewRepository
+	Star() StarRepository
+	Comment() CommentRepository
+	Member() MemberRepository
+	ShareLink() ShareLinkRepository
+	BlogPathRedirect() BlogPathRedirectRepository
+	EntrySlugRedirect() EntrySlugRedirectRepository
+	Media() MediaRepository
+	EntryImport() EntryImportRepository
+	BlogExport() BlogExportRepository
+	APIKey() APIKeyRepository
+	AccessToken() AccessTokenRepository
+	Webhook() WebhookRepository
+	WebhookDelivery() WebhookDeliveryRepository
+	Event() EventRepository
 }
@@ -0,0 +1,15 @@
+package domain
+
+// repositoryStub はテストで使うリポジトリだけを持つ Repository. 持っていないリポジトリを使うと panic する
+type repositoryStub struct {
+	Repository
+	member    *memberRepositoryStub
+	blog      *blogRepositoryStub
+	entry     *entryRepositoryStub
+	entrySlug *entrySlugRedirectRepositoryStub
+}
+
+func (r *repositoryStub) Member() MemberRepository                       { return r.member }
+func (r *repositoryStub) Blog() BlogRepository                           { return r.blog }
+func (r *repositoryStub) Entry() EntryRepository                         { return r.entry }
+func (r *repositoryStub) EntrySlugRedirect() EntrySlugRedirectRepository { return r.entrySlug }
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// EntryRevisionID はエントリのリビジョンにユニークに割り当てられる ID
+type EntryRevisionID uint64
+
+func (id EntryRevisionID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseEntryRevisionID は文字列の EntryRevisionID をパースする
+func ParseEntryRevisionID(str string) (EntryRevisionID, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return EntryRevisionID(0), err
+	}
+	return EntryRevisionID(id), nil
+}
+
+// EntryRevision はエントリが編集される前の内容を表す
+type EntryRevision struct {
+	ID       EntryRevisionID `db:"id"`
+	EntryID  EntryID         `db:"entry_id"`
+	Title    string          `db:"title"`
+	Body     string          `db:"body"`
+	EditedAt time.Time       `db:"edited_at"`
+}
+
+// CreateEntryRevisionInput はリビジョン作成時の入力
+type CreateEntryRevisionInput struct {
+	EntryID  EntryID
+	Title    string
+	Body     string
+	EditedAt time.Time
+}
+
+// EntryRevisionRepository はエントリのリビジョンのリポジトリ
+type EntryRevisionRepository interface {
+	Create(ctx context.Context, input *CreateEntryRevisionInput) (*EntryRevision, error)
+	FindByID(ctx context.Context, id EntryRevisionID) (*EntryRevision, error)
+	ListByEntryID(ctx context.Context, entryID EntryID, limit, offset int) ([]*EntryRevision, error)
+	DeleteByEntryID(ctx context.Context, entryID EntryID) error
+	// DeleteByBlogID はブログのすべてのエントリのリビジョンを削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// Revision はエントリの現在の内容をリビジョンとして表す
+// 保存されたリビジョンと比較するために使い, ID はゼロ値になる
+func (e Entry) Revision() *EntryRevision {
+	return &EntryRevision{
+		EntryID:  e.ID,
+		Title:    e.Title,
+		Body:     e.Body,
+		EditedAt: e.EditedAt,
+	}
+}
+
+// RestoreRevision はエントリの内容をリビジョンの内容に戻す
+// 戻す前の内容も新しいリビジョンとして残る
+func (e Entry) RestoreRevision(revision *EntryRevision, editedAt time.Time) func(ctx context.Context, r Repository, br BodyRenderer) (*Entry, error) {
+	return e.Edit(revision.Title, revision.Body, editedAt)
+}
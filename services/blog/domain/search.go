@@ -0,0 +1,32 @@
+package domain
+
+import "context"
+
+// SearchHit は全文検索で見つかったエントリを表す
+type SearchHit struct {
+	EntryID EntryID `db:"entry_id"`
+	BlogID  BlogID  `db:"blog_id"`
+	Score   float64 `db:"score"`
+}
+
+// SearchIndex は公開されているエントリのタイトルと本文に対する全文検索のインデックス
+// 日本語のように単語が空白で区切られない文章も検索できるよう, n-gram で分割して索引する
+type SearchIndex interface {
+	// Index はエントリをインデックスに追加する. 既に追加されていれば内容を更新する
+	Index(ctx context.Context, entry *Entry) error
+	// Remove はエントリをインデックスから取り除く
+	Remove(ctx context.Context, entryID EntryID) error
+	// RemoveByBlogID はブログのすべてのエントリをインデックスから取り除く
+	RemoveByBlogID(ctx context.Context, blogID BlogID) error
+	// Search はクエリに一致するエントリのうち opts の範囲のものをスコアの高い順に返す
+	// インデックスに残っていても, 公開されていないエントリやゴミ箱にあるエントリ, ゴミ箱にあるブログのエントリは返さない
+	Search(ctx context.Context, query string, opts SearchOptions, limit, offset int) ([]*SearchHit, error)
+}
+
+// SearchOptions は全文検索でエントリを探す範囲を表す
+type SearchOptions struct {
+	// BlogID はエントリを探すブログ. ゼロ値の場合はすべてのブログから探す
+	BlogID BlogID
+	// ListedOnly が true の場合は, エントリとブログの公開範囲がどちらも public のエントリのみを返す
+	ListedOnly bool
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// ShareLinkID は共有リンクにユニークに割り当てられる ID
+type ShareLinkID uint64
+
+func (id ShareLinkID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseShareLinkID は文字列の ShareLinkID をパースする
+func ParseShareLinkID(str string) (ShareLinkID, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return ShareLinkID(0), err
+	}
+	return ShareLinkID(id), nil
+}
+
+// ShareLink は公開範囲が private のエントリを, ブログのメンバーでない人にも読めるようにする共有リンクを表す
+// リンクに埋め込む署名付きのトークンは保存せず, 取り消されたリンクはリポジトリから削除する
+type ShareLink struct {
+	ID        ShareLinkID `db:"id"`
+	EntryID   EntryID     `db:"entry_id"`
+	CreatedBy UserID      `db:"created_by"`
+	ExpiresAt time.Time   `db:"expires_at"`
+	CreatedAt time.Time   `db:"created_at"`
+}
+
+// CreateShareLinkInput は共有リンク作成時の入力
+type CreateShareLinkInput struct {
+	EntryID   EntryID
+	CreatedBy UserID
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// ShareLinkRepository は共有リンクのリポジトリ
+type ShareLinkRepository interface {
+	Create(ctx context.Context, input *CreateShareLinkInput) (*ShareLink, error)
+	FindByID(ctx context.Context, id ShareLinkID) (*ShareLink, error)
+	// ListByEntryID はエントリの共有リンクを期限切れのものも含めて新しい順に返す
+	ListByEntryID(ctx context.Context, entryID EntryID) ([]*ShareLink, error)
+	Delete(ctx context.Context, id ShareLinkID) error
+	// DeleteByEntryID はエントリのすべての共有リンクを削除する
+	DeleteByEntryID(ctx context.Context, entryID EntryID) error
+	// DeleteByBlogID はブログのすべてのエントリの共有リンクを削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// IsExpired は共有リンクの有効期限が切れているかを判定する
+func (l ShareLink) IsExpired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// CreateShareLink はエントリの共有リンクを作成する
+func (e Entry) CreateShareLink(creator *User, expiresAt, createdAt time.Time) func(ctx context.Context, r Repository) (*ShareLink, error) {
+	return func(ctx context.Context, r Repository) (*ShareLink, error) {
+		return r.ShareLink().Create(ctx, &CreateShareLinkInput{
+			EntryID:   e.ID,
+			CreatedBy: creator.ID,
+			ExpiresAt: expiresAt,
+			CreatedAt: createdAt,
+		})
+	}
+}
+
+// Revoke は共有リンクを取り消す. 取り消したリンクではエントリを読めなくなる
+func (l ShareLink) Revoke() func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		return r.ShareLink().Delete(ctx, l.ID)
+	}
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Star はユーザーがエントリに付けたスターを表す. 1 人のユーザーは 1 つのエントリに 1 つだけスターを付けられる
+type Star struct {
+	UserID    UserID    `db:"user_id"`
+	EntryID   EntryID   `db:"entry_id"`
+	BlogID    BlogID    `db:"blog_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// CreateStarInput はスター作成時の入力
+type CreateStarInput struct {
+	UserID    UserID
+	EntryID   EntryID
+	BlogID    BlogID
+	CreatedAt time.Time
+}
+
+// StarRepository はスターのリポジトリ
+// エントリごとのスターの数はスターを付け外しするときに数え直さずに増減させ, 一覧で集計しなくても読めるようにしておく
+type StarRepository interface {
+	// Create はスターを作成する. すでに同じユーザーが同じエントリにスターを付けている場合は ErrAlreadyExists を返す
+	Create(ctx context.Context, input *CreateStarInput) (*Star, error)
+	// Delete はスターを削除する. スターを付けていない場合は ErrNotFound を返す
+	Delete(ctx context.Context, userID UserID, entryID EntryID) error
+	// ListByUserID はユーザーが付けたスターを, 公開されていてゴミ箱にないエントリのものだけ新しい順に cursor の位置から返す
+	// cursor が nil の場合は先頭から返す
+	ListByUserID(ctx context.Context, userID UserID, cursor *Cursor, limit int) ([]*Star, error)
+	// ListStarredEntryIDs は複数のエントリのうち, ユーザーがスターを付けているものを返す
+	ListStarredEntryIDs(ctx context.Context, userID UserID, entryIDs []EntryID) (map[EntryID]bool, error)
+	// CountByEntryIDs は複数のエントリのスターの数を返す. スターが付いていないエントリは結果に含まれない
+	CountByEntryIDs(ctx context.Context, entryIDs []EntryID) (map[EntryID]int, error)
+	// AddCount はエントリのスターの数を delta だけ増減させる
+	AddCount(ctx context.Context, entryID EntryID, blogID BlogID, delta int) error
+	// DeleteByEntryID はエントリに付けられたすべてのスターとスターの数を削除する
+	DeleteByEntryID(ctx context.Context, entryID EntryID) error
+	// DeleteByBlogID はブログのエントリに付けられたすべてのスターとスターの数を削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// Star はユーザーがエントリにスターを付ける. すでに付けている場合は何もしない
+// スターの数と食い違わないよう, トランザクション内で呼ぶ
+func (e Entry) Star(user *User, starredAt time.Time) func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		_, err := r.Star().Create(ctx, &CreateStarInput{
+			UserID:    user.ID,
+			EntryID:   e.ID,
+			BlogID:    e.BlogID,
+			CreatedAt: starredAt,
+		})
+		if err != nil {
+			if errors.Is(err, ErrAlreadyExists) {
+				return nil
+			}
+			return err
+		}
+		return r.Star().AddCount(ctx, e.ID, e.BlogID, 1)
+	}
+}
+
+// Unstar はユーザーがエントリに付けたスターを外す. 付けていない場合は何もしない
+// スターの数と食い違わないよう, トランザクション内で呼ぶ
+func (e Entry) Unstar(user *User) func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		if err := r.Star().Delete(ctx, user.ID, e.ID); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		return r.Star().AddCount(ctx, e.ID, e.BlogID, -1)
+	}
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+)
+
+// TagID はタグにユニークに割り当てられる ID
+type TagID uint64
+
+// Tag はエントリを話題ごとにまとめるためのタグを表す
+type Tag struct {
+	ID   TagID  `db:"id"`
+	Name string `db:"name"`
+}
+
+// TagCount はブログの中でタグが付けられているエントリの数を表す
+type TagCount struct {
+	Tag
+	Count int `db:"count"`
+}
+
+// TagRepository はタグのリポジトリ
+type TagRepository interface {
+	// FindOrCreateByNames は名前でタグを検索し, 存在しないタグは作成する
+	FindOrCreateByNames(ctx context.Context, names []string) ([]*Tag, error)
+	ListByEntryID(ctx context.Context, entryID EntryID) ([]*Tag, error)
+	ListByEntryIDs(ctx context.Context, entryIDs []EntryID) (map[EntryID][]*Tag, error)
+	// ListCountsByBlogID はブログの公開されているエントリに付けられたタグをエントリの数とともに返す
+	// listedOnly が true の場合は公開範囲が public のエントリのみを数える
+	ListCountsByBlogID(ctx context.Context, blogID BlogID, listedOnly bool) ([]*TagCount, error)
+	// SetEntryTags はエントリに付けられたタグを置き換える
+	SetEntryTags(ctx context.Context, entryID EntryID, tagIDs []TagID) error
+	// DeleteEntryTagsByBlogID はブログのすべてのエントリからタグを外す. タグ自体は他のブログと共有しているので残す
+	DeleteEntryTagsByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// SetTags はエントリに付けるタグを名前で指定して置き換える
+func (e Entry) SetTags(names []string) func(ctx context.Context, r Repository) ([]*Tag, error) {
+	return func(ctx context.Context, r Repository) ([]*Tag, error) {
+		tags := []*Tag{}
+		if len(names) > 0 {
+			var err error
+			tags, err = r.Tag().FindOrCreateByNames(ctx, names)
+			if err != nil {
+				return nil, err
+			}
+		}
+		tagIDs := make([]TagID, 0, len(tags))
+		for _, t := range tags {
+			tagIDs = append(tagIDs, t.ID)
+		}
+		if err := r.Tag().SetEntryTags(ctx, e.ID, tagIDs); err != nil {
+			return nil, err
+		}
+		return tags, nil
+	}
+}
@@ -12,6 +12,15 @@ import (
 // UserID はユーザーにユニークに割り当てられる ID
 type UserID uint64
 
+// ParseUserID は文字列の UserID をパースする
+func ParseUserID(str string) (UserID, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return UserID(0), err
+	}
+	return UserID(id), nil
+}
+
 // AccountID はアカウントサービス側でユーザーにユニークに割り当てられる ID
 type AccountID uint64
 
@@ -41,11 +50,13 @@ type CreateUserInput struct {
 type UserRepository interface {
 	Create(ctx context.Context, input *CreateUserInput) (*User, error)
 	FindByID(ctx context.Context, id UserID) (*User, error)
+	ListByIDs(ctx context.Context, ids []UserID) ([]*User, error)
 	FindByAccountID(ctx context.Context, accountID AccountID) (*User, error)
+	FindByName(ctx context.Context, name string) (*User, error)
 }
 
 // CreateUser は新規ユーザーを作成する
-func CreateUser(accountID AccountID, name string) func(ctx context.Context, r Repository) (*User, error) {
+func CreateUser(accountID AccountID, name string, createdAt time.Time) func(ctx context.Context, r Repository) (*User, error) {
 	return func(ctx context.Context, r Repository) (*User, error) {
 		_, err := r.User().FindByAccountID(ctx, accountID)
 		if !errors.Is(err, ErrNotFound) {
@@ -54,10 +65,17 @@ func CreateUser(accountID AccountID, name string) func(ctx context.Context, r Re
 			}
 			return nil, ErrAlreadyExists
 		}
-		return r.User().Create(ctx, &CreateUserInput{
+		user, err := r.User().Create(ctx, &CreateUserInput{
 			AccountID: accountID,
 			Name:      name,
 		})
+		if err != nil {
+			return nil, err
+		}
+		if err := recordEvent(ctx, r, &UserSignedUp{UserID: user.ID, Name: user.Name, SignedUpAt: createdAt}, createdAt); err != nil {
+			return nil, err
+		}
+		return user, nil
 	}
 }
 
@@ -76,14 +94,18 @@ func (u User) StartSession(expiresAt time.Time) func(ctx context.Context, r Repo
 	}
 }
 
-// CreateBlog は新規ブログを作成する
-func (u User) CreateBlog(path, title, description string) func(ctx context.Context, r Repository) (*Blog, error) {
+// CreateBlog は新規ブログを作成する. 他のブログが使っているパスや古いパスとして予約されているパスは使えない
+func (u User) CreateBlog(path, title, description string, createdAt time.Time) func(ctx context.Context, r Repository) (*Blog, error) {
 	return func(ctx context.Context, r Repository) (*Blog, error) {
+		if err := checkBlogPathAvailable(ctx, r, path, 0); err != nil {
+			return nil, err
+		}
 		return r.Blog().Create(ctx, &CreateBlogInput{
 			UserID:      u.ID,
 			Path:        path,
 			Title:       title,
 			Description: description,
+			CreatedAt:   createdAt,
 		})
 	}
 }
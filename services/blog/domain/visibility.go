@@ -0,0 +1,42 @@
+package domain
+
+// Visibility はブログやエントリの公開範囲を表す
+type Visibility string
+
+const (
+	// VisibilityPublic はトップページやブログのエントリ一覧, フィード, 検索に載せる公開範囲
+	VisibilityPublic Visibility = "public"
+	// VisibilityUnlisted は URL を知っていれば読めるが, 一覧やフィード, 検索には載せない公開範囲
+	VisibilityUnlisted Visibility = "unlisted"
+	// VisibilityPrivate は下書きを読めるブログのメンバーと, 共有リンクを持っている人だけが読める公開範囲
+	VisibilityPrivate Visibility = "private"
+)
+
+// visibilityLevels は公開範囲の狭さを表す. 値が大きいほど狭い
+var visibilityLevels = map[Visibility]int{
+	VisibilityPublic:   0,
+	VisibilityUnlisted: 1,
+	VisibilityPrivate:  2,
+}
+
+// ParseVisibility は文字列の Visibility をパースする
+func ParseVisibility(str string) (Visibility, bool) {
+	v := Visibility(str)
+	if _, ok := visibilityLevels[v]; !ok {
+		return "", false
+	}
+	return v, true
+}
+
+// IsListed は一覧やフィード, 検索に載せる公開範囲かを判定する
+func (v Visibility) IsListed() bool {
+	return v == VisibilityPublic
+}
+
+// Narrower は v と w のうち狭い方の公開範囲を返す
+func (v Visibility) Narrower(w Visibility) Visibility {
+	if visibilityLevels[w] > visibilityLevels[v] {
+		return w
+	}
+	return v
+}
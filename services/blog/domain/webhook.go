@@ -0,0 +1,376 @@
+package domain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// WebhookID はブログの Webhook にユニークに割り当てられる ID
+type WebhookID uint64
+
+func (id WebhookID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseWebhookID は文字列の WebhookID をパースする
+func ParseWebhookID(str string) (WebhookID, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return WebhookID(0), err
+	}
+	return WebhookID(id), nil
+}
+
+// WebhookDeliveryID は Webhook の配信にユニークに割り当てられる ID
+type WebhookDeliveryID uint64
+
+func (id WebhookDeliveryID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseWebhookDeliveryID は文字列の WebhookDeliveryID をパースする
+func ParseWebhookDeliveryID(str string) (WebhookDeliveryID, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return WebhookDeliveryID(0), err
+	}
+	return WebhookDeliveryID(id), nil
+}
+
+// WebhookEvent は Webhook で通知するできごとの種類を表す
+type WebhookEvent string
+
+const (
+	// WebhookEventEntryPublished はエントリが公開されたこと. 予約投稿は公開日時になったときに通知する
+	WebhookEventEntryPublished WebhookEvent = "entry.published"
+	// WebhookEventEntryEdited は公開されているエントリが編集されたこと
+	WebhookEventEntryEdited WebhookEvent = "entry.edited"
+	// WebhookEventEntryUnpublished は公開されていたエントリが非公開にされたか, ゴミ箱に移動されたこと
+	WebhookEventEntryUnpublished WebhookEvent = "entry.unpublished"
+	// WebhookEventBlogEdited はブログのタイトルや説明, パスが変更されたこと
+	WebhookEventBlogEdited WebhookEvent = "blog.edited"
+	// WebhookEventBlogDeleted はブログがゴミ箱に移動されたこと
+	WebhookEventBlogDeleted WebhookEvent = "blog.deleted"
+)
+
+// webhookSecretSize は Webhook の署名に使う秘密鍵の長さ
+const webhookSecretSize = 32
+
+// WebhookMaxAttempts は 1 つの配信を送る回数の上限. これだけ送っても成功しなければ配信に失敗したものとする
+const WebhookMaxAttempts = 8
+
+// webhookRetryBaseDelay は配信に失敗してから最初に送り直すまでの時間. 送り直すたびに倍にする
+const webhookRetryBaseDelay = time.Minute
+
+// Webhook はブログでできごとがあったときにペイロードを送る URL を表す
+type Webhook struct {
+	ID     WebhookID `db:"id"`
+	BlogID BlogID    `db:"blog_id"`
+	URL    string    `db:"url"`
+	// Secret はペイロードの署名に使う秘密鍵. 受け取る側が署名を検証できるよう, オーナーには表示する
+	Secret    string    `db:"secret"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// CreateWebhookInput は Webhook 作成時の入力
+type CreateWebhookInput struct {
+	BlogID    BlogID
+	URL       string
+	Secret    string
+	CreatedAt time.Time
+}
+
+// WebhookRepository は Webhook のリポジトリ
+type WebhookRepository interface {
+	Create(ctx context.Context, input *CreateWebhookInput) (*Webhook, error)
+	FindByID(ctx context.Context, id WebhookID) (*Webhook, error)
+	// ListByBlogID はブログのすべての Webhook を作成した順に返す
+	ListByBlogID(ctx context.Context, blogID BlogID) ([]*Webhook, error)
+	Delete(ctx context.Context, id WebhookID) error
+	// DeleteByBlogID はブログのすべての Webhook を削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// WebhookDeliveryStatus は Webhook の配信の状態を表す
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryStatusPending は送るのを待っているか, 失敗して送り直すのを待っている状態
+	WebhookDeliveryStatusPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryStatusSucceeded は 2xx のレスポンスが返ってきた状態
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryStatusFailed は送る回数の上限まで送っても成功しなかった状態
+	WebhookDeliveryStatusFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery は Webhook の URL に 1 つのペイロードを送るジョブを表す
+type WebhookDelivery struct {
+	ID        WebhookDeliveryID `db:"id"`
+	WebhookID WebhookID         `db:"webhook_id"`
+	BlogID    BlogID            `db:"blog_id"`
+	Event     WebhookEvent      `db:"event"`
+	// Payload は送る JSON. できごとがあったときの内容を保存しておき, 送り直すときも同じものを送る
+	Payload string                `db:"payload"`
+	Status  WebhookDeliveryStatus `db:"status"`
+	// Attempts はこれまでに送った回数
+	Attempts int `db:"attempts"`
+	// StatusCode は最後に送ったときのレスポンスのステータスコード. レスポンスが返ってこなかった場合は 0
+	StatusCode int `db:"status_code"`
+	// Error は最後に送ったときに失敗した理由
+	Error string `db:"error"`
+	// TraceParent はできごとがあったリクエストのトレースコンテキスト (W3C Trace Context の traceparent)
+	TraceParent string `db:"trace_parent"`
+	// NextAttemptAt は次に送る日時. 配信が終わった後は nil
+	NextAttemptAt *time.Time `db:"next_attempt_at"`
+	// LastAttemptedAt は最後に送った日時. まだ送っていない場合は nil
+	LastAttemptedAt *time.Time `db:"last_attempted_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+}
+
+// CreateWebhookDeliveryInput は Webhook の配信作成時の入力
+type CreateWebhookDeliveryInput struct {
+	WebhookID   WebhookID
+	BlogID      BlogID
+	Event       WebhookEvent
+	Payload     string
+	TraceParent string
+	CreatedAt   time.Time
+}
+
+// UpdateWebhookDeliveryInput は Webhook の配信更新時の入力
+type UpdateWebhookDeliveryInput struct {
+	Status          WebhookDeliveryStatus
+	Attempts        int
+	StatusCode      int
+	Error           string
+	NextAttemptAt   *time.Time
+	LastAttemptedAt *time.Time
+}
+
+// WebhookDeliveryRepository は Webhook の配信のリポジトリ
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, input *CreateWebhookDeliveryInput) (*WebhookDelivery, error)
+	FindByID(ctx context.Context, id WebhookDeliveryID) (*WebhookDelivery, error)
+	// ListByWebhookID は Webhook の配信を新しい順に返す
+	ListByWebhookID(ctx context.Context, webhookID WebhookID, limit int) ([]*WebhookDelivery, error)
+	// ListDueForUpdate は送るのを待っているもののうち次に送る日時を過ぎたものを, 古い順に行ロックを取りつつ返す
+	// 他のトランザクションがロックしているものは読み飛ばす
+	ListDueForUpdate(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error)
+	Update(ctx context.Context, id WebhookDeliveryID, input *UpdateWebhookDeliveryInput) (*WebhookDelivery, error)
+	// DeleteCreatedBefore は指定した日時より前に作成した配信を最大 limit 件削除し, 削除した件数を返す
+	DeleteCreatedBefore(ctx context.Context, before time.Time, limit int) (int, error)
+	// DeleteByWebhookID は Webhook のすべての配信を削除する
+	DeleteByWebhookID(ctx context.Context, webhookID WebhookID) error
+	// DeleteByBlogID はブログのすべての Webhook の配信を削除する
+	DeleteByBlogID(ctx context.Context, blogID BlogID) error
+}
+
+// WebhookSender は Webhook の URL にペイロードを送る
+type WebhookSender interface {
+	// Send は body を JSON として url に POST し, レスポンスのステータスコードを返す. header はリクエストに付けるヘッダ
+	Send(ctx context.Context, url string, header map[string]string, body []byte) (int, error)
+}
+
+// WebhookPayload は Webhook で送る JSON
+type WebhookPayload struct {
+	Event      WebhookEvent         `json:"event"`
+	OccurredAt time.Time            `json:"occurred_at"`
+	Blog       WebhookPayloadBlog   `json:"blog"`
+	Entry      *WebhookPayloadEntry `json:"entry,omitempty"`
+}
+
+// WebhookPayloadBlog は Webhook で送るブログの内容. ID は JavaScript で扱えるよう文字列にする
+type WebhookPayloadBlog struct {
+	ID          BlogID     `json:"id,string"`
+	Path        string     `json:"path"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Visibility  Visibility `json:"visibility"`
+}
+
+// WebhookPayloadEntry は Webhook で送るエントリの内容. 本文は送らない
+type WebhookPayloadEntry struct {
+	ID          EntryID     `json:"id,string"`
+	Title       string      `json:"title"`
+	Slug        string      `json:"slug"`
+	Permalink   string      `json:"permalink"`
+	Status      EntryStatus `json:"status"`
+	Visibility  Visibility  `json:"visibility"`
+	PublishedAt time.Time   `json:"published_at"`
+	EditedAt    time.Time   `json:"edited_at"`
+}
+
+// CreateWebhook はブログに Webhook を登録する. 署名に使う秘密鍵はここで生成する
+func (b Blog) CreateWebhook(url string, createdAt time.Time) func(ctx context.Context, r Repository) (*Webhook, error) {
+	return func(ctx context.Context, r Repository) (*Webhook, error) {
+		secret, err := generateSessionKey(webhookSecretSize)
+		if err != nil {
+			return nil, err
+		}
+		return r.Webhook().Create(ctx, &CreateWebhookInput{
+			BlogID:    b.ID,
+			URL:       url,
+			Secret:    secret,
+			CreatedAt: createdAt,
+		})
+	}
+}
+
+// Delete は Webhook をその配信とともに削除する
+func (w Webhook) Delete() func(ctx context.Context, r Repository) error {
+	return func(ctx context.Context, r Repository) error {
+		if err := r.WebhookDelivery().DeleteByWebhookID(ctx, w.ID); err != nil {
+			return err
+		}
+		return r.Webhook().Delete(ctx, w.ID)
+	}
+}
+
+// Sign はペイロードの HMAC-SHA256 による署名を "sha256=<16 進数>" の形で返す
+func (w Webhook) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.Secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NotifyWebhooks はブログのすべての Webhook に, できごとを通知する配信を登録する. 送るのはバックグラウンドで行う
+// entry はエントリについてのできごとの場合に渡し, ブログについてのできごとの場合は nil にする
+// できごとを起こした変更がロールバックされたときに通知しないよう, 変更と同じトランザクション内で呼ぶ
+func (b Blog) NotifyWebhooks(event WebhookEvent, entry *Entry, occurredAt time.Time, traceParent string) func(ctx context.Context, r Repository) ([]*WebhookDelivery, error) {
+	return func(ctx context.Context, r Repository) ([]*WebhookDelivery, error) {
+		webhooks, err := r.Webhook().ListByBlogID(ctx, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(webhooks) == 0 {
+			return nil, nil
+		}
+		payload := &WebhookPayload{
+			Event:      event,
+			OccurredAt: occurredAt,
+			Blog: WebhookPayloadBlog{
+				ID:          b.ID,
+				Path:        b.Path,
+				Title:       b.Title,
+				Description: b.Description,
+				Visibility:  b.Visibility,
+			},
+		}
+		if entry != nil {
+			payload.Entry = &WebhookPayloadEntry{
+				ID:          entry.ID,
+				Title:       entry.Title,
+				Slug:        entry.Slug,
+				Permalink:   entry.Permalink(),
+				Status:      entry.Status,
+				Visibility:  entry.VisibilityIn(&b),
+				PublishedAt: entry.PublishedAt,
+				EditedAt:    entry.EditedAt,
+			}
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		deliveries := make([]*WebhookDelivery, 0, len(webhooks))
+		for _, w := range webhooks {
+			d, err := r.WebhookDelivery().Create(ctx, &CreateWebhookDeliveryInput{
+				WebhookID:   w.ID,
+				BlogID:      b.ID,
+				Event:       event,
+				Payload:     string(body),
+				TraceParent: traceParent,
+				CreatedAt:   occurredAt,
+			})
+			if err != nil {
+				return nil, err
+			}
+			deliveries = append(deliveries, d)
+		}
+		return deliveries, nil
+	}
+}
+
+// WebhookRetryDelay は attempts 回送って失敗した配信を, 次に送るまでに待つ時間を返す
+func WebhookRetryDelay(attempts int) time.Duration {
+	return webhookRetryBaseDelay << (attempts - 1)
+}
+
+// StartWebhookDeliveries は次に送る日時を過ぎた配信を, 送った回数を増やしてから返す
+// 送っている途中でプロセスが止まっても leaseUntil を過ぎれば送り直されるよう, 次に送る日時を leaseUntil にしておく
+// 複数のプロセスから同時に呼ばれても同じ配信を二重に送らないよう, 行ロックを取るトランザクション内で呼ぶ
+func StartWebhookDeliveries(now, leaseUntil time.Time, limit int) func(ctx context.Context, r Repository) ([]*WebhookDelivery, error) {
+	return func(ctx context.Context, r Repository) ([]*WebhookDelivery, error) {
+		deliveries, err := r.WebhookDelivery().ListDueForUpdate(ctx, now, limit)
+		if err != nil {
+			return nil, err
+		}
+		started := make([]*WebhookDelivery, 0, len(deliveries))
+		for _, d := range deliveries {
+			delivery, err := r.WebhookDelivery().Update(ctx, d.ID, &UpdateWebhookDeliveryInput{
+				Status:          WebhookDeliveryStatusPending,
+				Attempts:        d.Attempts + 1,
+				StatusCode:      d.StatusCode,
+				Error:           d.Error,
+				NextAttemptAt:   &leaseUntil,
+				LastAttemptedAt: &now,
+			})
+			if err != nil {
+				return nil, err
+			}
+			started = append(started, delivery)
+		}
+		return started, nil
+	}
+}
+
+// Succeed は配信に成功したことを記録する
+func (d WebhookDelivery) Succeed(statusCode int) func(ctx context.Context, r Repository) (*WebhookDelivery, error) {
+	return func(ctx context.Context, r Repository) (*WebhookDelivery, error) {
+		return r.WebhookDelivery().Update(ctx, d.ID, &UpdateWebhookDeliveryInput{
+			Status:          WebhookDeliveryStatusSucceeded,
+			Attempts:        d.Attempts,
+			StatusCode:      statusCode,
+			LastAttemptedAt: d.LastAttemptedAt,
+		})
+	}
+}
+
+// Fail は配信に失敗したことを理由とともに記録する
+// 送る回数の上限に達していなければ, 送った回数に応じて指数的に間隔を空けて送り直す
+func (d WebhookDelivery) Fail(statusCode int, reason string, failedAt time.Time) func(ctx context.Context, r Repository) (*WebhookDelivery, error) {
+	return func(ctx context.Context, r Repository) (*WebhookDelivery, error) {
+		input := &UpdateWebhookDeliveryInput{
+			Status:          WebhookDeliveryStatusFailed,
+			Attempts:        d.Attempts,
+			StatusCode:      statusCode,
+			Error:           reason,
+			LastAttemptedAt: d.LastAttemptedAt,
+		}
+		if d.Attempts < WebhookMaxAttempts {
+			next := failedAt.Add(WebhookRetryDelay(d.Attempts))
+			input.Status = WebhookDeliveryStatusPending
+			input.NextAttemptAt = &next
+		}
+		return r.WebhookDelivery().Update(ctx, d.ID, input)
+	}
+}
+
+// Redeliver は配信と同じペイロードを送り直す新しい配信を登録する
+func (d WebhookDelivery) Redeliver(traceParent string, createdAt time.Time) func(ctx context.Context, r Repository) (*WebhookDelivery, error) {
+	return func(ctx context.Context, r Repository) (*WebhookDelivery, error) {
+		return r.WebhookDelivery().Create(ctx, &CreateWebhookDeliveryInput{
+			WebhookID:   d.WebhookID,
+			BlogID:      d.BlogID,
+			Event:       d.Event,
+			Payload:     d.Payload,
+			TraceParent: traceParent,
+			CreatedAt:   createdAt,
+		})
+	}
+}
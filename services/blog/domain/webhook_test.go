@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebhookSign(t *testing.T) {
+	// RFC 4231 のテストケース 2 の HMAC-SHA256
+	w := Webhook{Secret: "Jefe"}
+	got := w.Sign([]byte("what do ya want for nothing?"))
+	want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+	if other := (Webhook{Secret: "jefe"}).Sign([]byte("what do ya want for nothing?")); other == got {
+		t.Error("Sign() does not depend on the secret")
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{WebhookMaxAttempts - 1, 64 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := WebhookRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("WebhookRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// manifestVersion は manifest.json の形式のバージョン. 形式を互換性なく変えたら上げる
+const manifestVersion = 1
+
+// Entry は書き出すエントリを表す
+type Entry struct {
+	*domain.Entry
+	// AuthorName はエントリを書いたユーザーの名前
+	AuthorName string
+	Tags       []string
+}
+
+// EntryIterator はブログのエントリを 1 件ずつ fn に渡す. fn がエラーを返したらそこで止めてエラーを返す
+// Write は一度だけ呼び, 渡されたエントリからすべてのファイルを作る
+type EntryIterator func(fn func(e *Entry) error) error
+
+// Write はブログを zip ファイルとして w に書き出す. zip ファイルにはブログのパスのディレクトリの下に次のファイルを入れる
+//   - entries/<スラッグ>.md: エントリごとの本文. タイトルや日時, タグは先頭の front matter に書く
+//   - export.mt: すべてのエントリを Movable Type の書き出し形式にしたもの. 他のブログサービスに取り込むために使う
+//   - manifest.json: ブログとエントリの一覧
+//
+// zip ファイルには一度に 1 つのファイルしか書けないので, エントリを読みながら export.mt と manifest.json は一時ファイルに書いておき, 最後に zip ファイルに入れる
+func Write(w io.Writer, blog *domain.Blog, exportedAt time.Time, each EntryIterator) error {
+	zw := zip.NewWriter(w)
+	dir := blog.Path + "/"
+
+	mt, err := newSpool()
+	if err != nil {
+		return err
+	}
+	defer mt.remove()
+	manifest, err := newSpool()
+	if err != nil {
+		return err
+	}
+	defer manifest.remove()
+
+	if err := writeManifestHeader(manifest, blog, exportedAt); err != nil {
+		return err
+	}
+	first := true
+	err = each(func(e *Entry) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     dir + entryFilename(e),
+			Method:   zip.Deflate,
+			Modified: e.EditedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeMarkdown(f, e); err != nil {
+			return err
+		}
+		if err := writeMT(mt, blog, e); err != nil {
+			return err
+		}
+		if err := writeManifestEntry(manifest, e, first); err != nil {
+			return err
+		}
+		first = false
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeManifestFooter(manifest); err != nil {
+		return err
+	}
+
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: dir + mtFilename, Method: zip.Deflate, Modified: exportedAt})
+	if err != nil {
+		return err
+	}
+	if err := mt.copyTo(f); err != nil {
+		return err
+	}
+	f, err = zw.CreateHeader(&zip.FileHeader{Name: dir + "manifest.json", Method: zip.Deflate, Modified: exportedAt})
+	if err != nil {
+		return err
+	}
+	if err := manifest.copyTo(f); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// spool は zip ファイルに入れる前のファイルの中身を一時ファイルに溜めておく
+type spool struct {
+	*bufio.Writer
+	f *os.File
+}
+
+func newSpool() (*spool, error) {
+	f, err := os.CreateTemp("", "blog-export-*")
+	if err != nil {
+		return nil, err
+	}
+	return &spool{bufio.NewWriter(f), f}, nil
+}
+
+// copyTo は溜めた中身を w に書き出す
+func (s *spool) copyTo(w io.Writer) error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.Copy(w, s.f)
+	return err
+}
+
+// remove は一時ファイルを削除する
+func (s *spool) remove() {
+	_ = s.f.Close()
+	_ = os.Remove(s.f.Name())
+}
+
+// entryFilename はエントリを書き出すファイルの zip ファイルの中でのパスを返す. スラッグはブログの中でユニークなので重ならない
+func entryFilename(e *Entry) string {
+	return "entries/" + e.Slug + ".md"
+}
+
+type manifestBlog struct {
+	ID              domain.BlogID     `json:"id"`
+	Path            string            `json:"path"`
+	Title           string            `json:"title"`
+	Description     string            `json:"description"`
+	Visibility      domain.Visibility `json:"visibility"`
+	CommentsEnabled bool              `json:"comments_enabled"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+type manifestMT struct {
+	File string `json:"file"`
+	// Timezone は MT 形式のファイルの日時のタイムゾーン. MT 形式には日時のタイムゾーンを書けないのでここに書く
+	Timezone string `json:"timezone"`
+}
+
+type manifestEntry struct {
+	ID          domain.EntryID     `json:"id"`
+	File        string             `json:"file"`
+	Title       string             `json:"title"`
+	Slug        string             `json:"slug"`
+	Author      string             `json:"author"`
+	Tags        []string           `json:"tags"`
+	Status      domain.EntryStatus `json:"status"`
+	Visibility  domain.Visibility  `json:"visibility"`
+	Format      domain.EntryFormat `json:"format"`
+	PublishedAt time.Time          `json:"published_at"`
+	EditedAt    time.Time          `json:"edited_at"`
+}
+
+// writeManifestHeader はブログの情報を JSON で書き出す
+// エントリの一覧は writeManifestEntry で 1 件ずつ書き出すので, ブログの情報を書いた JSON の最後の } を外して entries を続ける
+func writeManifestHeader(w io.Writer, blog *domain.Blog, exportedAt time.Time) error {
+	header, err := json.Marshal(struct {
+		Version    int          `json:"version"`
+		ExportedAt time.Time    `json:"exported_at"`
+		Blog       manifestBlog `json:"blog"`
+		MT         manifestMT   `json:"mt"`
+	}{
+		Version:    manifestVersion,
+		ExportedAt: exportedAt.UTC(),
+		Blog: manifestBlog{
+			ID:              blog.ID,
+			Path:            blog.Path,
+			Title:           blog.Title,
+			Description:     blog.Description,
+			Visibility:      blog.Visibility,
+			CommentsEnabled: blog.CommentsEnabled,
+			CreatedAt:       blog.CreatedAt.UTC(),
+		},
+		MT: manifestMT{File: mtFilename, Timezone: "+00:00"},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(header[:len(header)-1]); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, `,"entries":[`)
+	return err
+}
+
+// writeManifestEntry はエントリの一覧の 1 件を書き出す. first は一覧の最初のエントリかどうか
+func writeManifestEntry(w io.Writer, e *Entry, first bool) error {
+	tags := e.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	data, err := json.Marshal(&manifestEntry{
+		ID:          e.ID,
+		File:        entryFilename(e),
+		Title:       e.Title,
+		Slug:        e.Slug,
+		Author:      e.AuthorName,
+		Tags:        tags,
+		Status:      e.Status,
+		Visibility:  e.Visibility,
+		Format:      e.Format,
+		PublishedAt: e.PublishedAt.UTC(),
+		EditedAt:    e.EditedAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	if !first {
+		if _, err := io.WriteString(w, ","); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// writeManifestFooter はエントリの一覧と JSON を閉じる
+func writeManifestFooter(w io.Writer) error {
+	_, err := io.WriteString(w, "]}\n")
+	return err
+}
@@ -0,0 +1,154 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func TestWrite(t *testing.T) {
+	exportedAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	blog := &domain.Blog{ID: 1, Path: "my-blog", Title: "ブログ", CommentsEnabled: true}
+	published := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		entries []*Entry
+	}{
+		{
+			name: "no entries",
+		},
+		{
+			name: "entries",
+			entries: []*Entry{
+				{
+					Entry:      &domain.Entry{ID: 10, BlogID: 1, Title: "最初", Body: "本文", Slug: "first", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic, Format: domain.EntryFormatMarkdown, PublishedAt: published, EditedAt: published},
+					AuthorName: "alice",
+					Tags:       []string{"go"},
+				},
+				{
+					Entry:      &domain.Entry{ID: 11, BlogID: 1, Title: "下書き", Body: "<p>html</p>", Slug: "11", Status: domain.EntryStatusDraft, Visibility: domain.VisibilityPublic, Format: domain.EntryFormatHTML, PublishedAt: published, EditedAt: published},
+					AuthorName: "bob",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			each := func(fn func(e *Entry) error) error {
+				calls++
+				for _, e := range tt.entries {
+					if err := fn(e); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			var buf bytes.Buffer
+			if err := Write(&buf, blog, exportedAt, each); err != nil {
+				t.Fatal(err)
+			}
+			// エントリは一度だけ読む
+			if calls != 1 {
+				t.Errorf("iterated %d times, want 1", calls)
+			}
+
+			files := readZip(t, buf.Bytes())
+			var names []string
+			for _, e := range tt.entries {
+				names = append(names, "my-blog/entries/"+e.Slug+".md")
+			}
+			names = append(names, "my-blog/export.mt", "my-blog/manifest.json")
+			if len(files) != len(names) {
+				t.Errorf("got %d files, want %v", len(files), names)
+			}
+			for _, name := range names {
+				if _, ok := files[name]; !ok {
+					t.Errorf("%s is missing", name)
+				}
+			}
+
+			var manifest struct {
+				Version int `json:"version"`
+				Blog    struct {
+					Path string `json:"path"`
+				} `json:"blog"`
+				Entries []struct {
+					ID   domain.EntryID `json:"id"`
+					File string         `json:"file"`
+					Tags []string       `json:"tags"`
+				} `json:"entries"`
+			}
+			if err := json.Unmarshal([]byte(files["my-blog/manifest.json"]), &manifest); err != nil {
+				t.Fatalf("manifest.json is not valid JSON: %v\n%s", err, files["my-blog/manifest.json"])
+			}
+			if manifest.Version != manifestVersion || manifest.Blog.Path != "my-blog" {
+				t.Errorf("manifest = %+v", manifest)
+			}
+			if len(manifest.Entries) != len(tt.entries) {
+				t.Fatalf("manifest has %d entries, want %d", len(manifest.Entries), len(tt.entries))
+			}
+			for i, e := range tt.entries {
+				if m := manifest.Entries[i]; m.ID != e.ID || m.File != "entries/"+e.Slug+".md" || m.Tags == nil {
+					t.Errorf("manifest entry %d = %+v", i, m)
+				}
+			}
+
+			mt := files["my-blog/export.mt"]
+			if got := strings.Count(mt, "\n--------\n"); got != len(tt.entries) {
+				t.Errorf("export.mt has %d entries, want %d", got, len(tt.entries))
+			}
+			for _, e := range tt.entries {
+				if !strings.Contains(mt, "BASENAME: "+e.Slug+"\n") {
+					t.Errorf("export.mt does not have %s", e.Slug)
+				}
+				if md := files["my-blog/entries/"+e.Slug+".md"]; !strings.HasSuffix(md, "---\n\n"+e.Body+"\n") {
+					t.Errorf("entries/%s.md = %q", e.Slug, md)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteIteratorError(t *testing.T) {
+	errFetch := errors.New("failed to fetch")
+	each := func(fn func(e *Entry) error) error {
+		if err := fn(&Entry{Entry: &domain.Entry{ID: 1, Slug: "a"}}); err != nil {
+			return err
+		}
+		return errFetch
+	}
+	err := Write(io.Discard, &domain.Blog{Path: "my-blog"}, time.Now(), each)
+	if !errors.Is(err, errFetch) {
+		t.Errorf("Write() = %v, want %v", err, errFetch)
+	}
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string, len(zr.File))
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(b)
+	}
+	return files
+}
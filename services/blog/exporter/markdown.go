@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// writeMarkdown はエントリを front matter 付きの Markdown として書き出す
+// front matter の文字列は JSON の文字列として書く. JSON の文字列は YAML の文字列としてもそのまま読める
+// 他のブログサービスから取り込んだ HTML の本文は HTML のまま書き出し, front matter の format で区別する
+func writeMarkdown(w io.Writer, e *Entry) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("---\n")
+	writeField(bw, "title", e.Title)
+	writeField(bw, "date", e.PublishedAt.UTC().Format(time.RFC3339))
+	writeField(bw, "updated", e.EditedAt.UTC().Format(time.RFC3339))
+	writeField(bw, "slug", e.Slug)
+	writeField(bw, "author", e.AuthorName)
+	tags := e.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	writeField(bw, "tags", tags)
+	writeField(bw, "status", e.Status)
+	writeField(bw, "visibility", e.Visibility)
+	writeField(bw, "format", e.Format)
+	bw.WriteString("---\n\n")
+	bw.WriteString(e.Body)
+	if len(e.Body) > 0 && e.Body[len(e.Body)-1] != '\n' {
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+func writeField(bw *bufio.Writer, name string, value any) {
+	// string とそのスライスしか渡さないので失敗しない
+	data, _ := json.Marshal(value)
+	bw.WriteString(name + ": ")
+	bw.Write(data)
+	bw.WriteString("\n")
+}
@@ -0,0 +1,58 @@
+package exporter
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// mtFilename は Movable Type の書き出し形式のファイルの名前
+const mtFilename = "export.mt"
+
+// mtDateLayout は MT 形式の DATE の書式. 日時は UTC で書く
+const mtDateLayout = "01/02/2006 15:04:05"
+
+var mtLineReplacer = strings.NewReplacer("\r", "", "\n", " ")
+
+// writeMT はエントリを Movable Type の書き出し形式で書き出す. MT 形式を読めるサービスであれば取り込める
+// Markdown の本文は CONVERT BREAKS を markdown にして, HTML の本文は CONVERT BREAKS を 0 にしてそのまま書く
+func writeMT(w io.Writer, blog *domain.Blog, e *Entry) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("AUTHOR: " + mtLineReplacer.Replace(e.AuthorName) + "\n")
+	bw.WriteString("TITLE: " + mtLineReplacer.Replace(e.Title) + "\n")
+	bw.WriteString("BASENAME: " + e.Slug + "\n")
+	bw.WriteString("STATUS: " + mtStatus(e) + "\n")
+	if blog.CommentsEnabled {
+		bw.WriteString("ALLOW COMMENTS: 1\n")
+	} else {
+		bw.WriteString("ALLOW COMMENTS: 0\n")
+	}
+	if e.Format == domain.EntryFormatHTML {
+		bw.WriteString("CONVERT BREAKS: 0\n")
+	} else {
+		bw.WriteString("CONVERT BREAKS: markdown\n")
+	}
+	bw.WriteString("DATE: " + e.PublishedAt.UTC().Format(mtDateLayout) + "\n")
+	for _, t := range e.Tags {
+		bw.WriteString("CATEGORY: " + mtLineReplacer.Replace(t) + "\n")
+	}
+	bw.WriteString("-----\nBODY:\n")
+	bw.WriteString(strings.ReplaceAll(e.Body, "\r\n", "\n"))
+	bw.WriteString("\n-----\n--------\n")
+	return bw.Flush()
+}
+
+// mtStatus はエントリの公開状態を MT 形式の STATUS にする
+// MT 形式には公開範囲がないので, 公開範囲が public でないエントリは取り込んだ先で公開されないよう Draft にする
+func mtStatus(e *Entry) string {
+	switch {
+	case e.Status == domain.EntryStatusPublished && e.Visibility == domain.VisibilityPublic:
+		return "Publish"
+	case e.Status == domain.EntryStatusScheduled && e.Visibility == domain.VisibilityPublic:
+		return "Future"
+	default:
+		return "Draft"
+	}
+}
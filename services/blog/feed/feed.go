@@ -0,0 +1,45 @@
+// Package feed はブログのエントリを Atom や RSS のフィードとして書き出す
+package feed
+
+import (
+	"time"
+)
+
+// Feed はフィード全体を表す
+type Feed struct {
+	// ID はフィードを一意に識別する IRI
+	ID          string
+	Title       string
+	Description string
+	// Link はフィードに対応する HTML ページの URL
+	Link string
+	// Self はフィード自身の URL
+	Self string
+	// Author はフィード全体の著者. エントリごとに著者が異なる場合は空にする
+	Author  string
+	Updated time.Time
+	Items   []*Item
+}
+
+// Item はフィードに含まれるエントリを表す
+type Item struct {
+	// ID はエントリを一意に識別する IRI. エントリの URL が変わっても変わらないようにする
+	ID          string
+	Title       string
+	Link        string
+	Author      string
+	ContentHTML string
+	Published   time.Time
+	Updated     time.Time
+}
+
+// latestUpdated はアイテムの中で最も新しい更新日時を返す
+func latestUpdated(items []*Item) time.Time {
+	var updated time.Time
+	for _, item := range items {
+		if item.Updated.After(updated) {
+			updated = item.Updated
+		}
+	}
+	return updated
+}
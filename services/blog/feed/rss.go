@@ -0,0 +1,87 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// RSSContentType は RSS フィードの Content-Type
+const RSSContentType = "application/rss+xml; charset=UTF-8"
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	LastBuildDate string      `xml:"lastBuildDate"`
+	Items         []*rssItem  `xml:"item"`
+}
+
+type rssAtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title   string   `xml:"title"`
+	Link    string   `xml:"link"`
+	GUID    rssGUID  `xml:"guid"`
+	PubDate string   `xml:"pubDate"`
+	Encoded rssCDATA `xml:"content:encoded"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssCDATA struct {
+	Value string `xml:",cdata"`
+}
+
+// WriteRSS はフィードを RSS 2.0 の形式で書き出す
+// RSS には更新日時を表す要素がないため, エントリの更新日時は lastBuildDate にのみ反映される
+func (f *Feed) WriteRSS(w io.Writer) error {
+	updated := f.Updated
+	if updated.IsZero() {
+		updated = latestUpdated(f.Items)
+	}
+	feed := &rssFeed{
+		Version: "2.0",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			AtomLink:      rssAtomLink{Rel: "self", Type: "application/rss+xml", Href: f.Self},
+			LastBuildDate: updated.UTC().Format(time.RFC1123Z),
+			Items:         make([]*rssItem, 0, len(f.Items)),
+		},
+	}
+	for _, item := range f.Items {
+		feed.Channel.Items = append(feed.Channel.Items, &rssItem{
+			Title:   item.Title,
+			Link:    item.Link,
+			GUID:    rssGUID{IsPermaLink: false, Value: item.ID},
+			PubDate: item.Published.UTC().Format(time.RFC1123Z),
+			Encoded: rssCDATA{item.ContentHTML},
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(feed)
+}
@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// FileFormat は他のブログサービスから書き出したファイルの形式を表す
+type FileFormat string
+
+const (
+	// FileFormatMT は Movable Type の書き出し形式. はてなブログもこの形式で書き出す
+	FileFormatMT FileFormat = "mt"
+	// FileFormatWXR は WordPress の書き出し形式 (WordPress eXtended RSS)
+	FileFormatWXR FileFormat = "wxr"
+)
+
+// ErrUnknownFileFormat はファイルの形式がわからないときに返される
+var ErrUnknownFileFormat = errors.New("unknown file format")
+
+// Entry は書き出したファイルから読み取ったエントリを表す
+type Entry struct {
+	// Key は書き出し元でエントリを識別する文字列. 同じエントリを二度取り込まないために使う
+	Key   string
+	Title string
+	Body  string
+	// Format は本文の記法. 書き出し元で HTML に変換済みの本文は HTML のまま取り込む
+	Format domain.EntryFormat
+	// Slug は書き出し元でのスラッグ. このブログのスラッグとして使えない場合は使わない
+	Slug string
+	Tags []string
+	// Status は公開済みか下書きのいずれか. 公開日時が未来のものは取り込むときに予約投稿にする
+	Status      domain.EntryStatus
+	Visibility  domain.Visibility
+	PublishedAt time.Time
+	// Err はエントリを読み取れなかった理由. あるエントリを読み取れなくても他のエントリは読み取る
+	Err error
+}
+
+// DetectFileFormat はファイルの中身から形式を判定する
+func DetectFileFormat(data []byte) (FileFormat, error) {
+	head := bytes.TrimSpace(data[:min(len(data), 4096)])
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	if bytes.HasPrefix(head, []byte("<?xml")) || bytes.HasPrefix(head, []byte("<rss")) {
+		return FileFormatWXR, nil
+	}
+	if mtFieldRE.Match(head) {
+		return FileFormatMT, nil
+	}
+	return "", ErrUnknownFileFormat
+}
+
+// Parse はファイルからエントリを読み取る. format が空の場合はファイルの中身から形式を判定する
+// loc は日時にタイムゾーンが書かれていない場合に使う
+// ファイル全体を読み取れない場合はエラーを返し, 個々のエントリを読み取れない場合は Entry.Err に理由を入れる
+func Parse(data []byte, format FileFormat, loc *time.Location) ([]*Entry, error) {
+	if format == "" {
+		var err error
+		format, err = DetectFileFormat(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch format {
+	case FileFormatMT:
+		return ParseMT(data, loc)
+	case FileFormatWXR:
+		return ParseWXR(data, loc)
+	default:
+		return nil, ErrUnknownFileFormat
+	}
+}
+
+// ParseTimezone は "+09:00" のような UTC からの時差をタイムゾーンとしてパースする. 空文字列は UTC とする
+// 書き出したファイルの日時にタイムゾーンが書かれていない場合に, 書き出し元のブログのタイムゾーンとして使う
+func ParseTimezone(s string) (*time.Location, error) {
+	if s == "" || s == "Z" || s == "UTC" {
+		return time.UTC, nil
+	}
+	t, err := time.Parse("-07:00", s)
+	if err != nil {
+		return nil, err
+	}
+	_, offset := t.Zone()
+	return time.FixedZone(s, offset), nil
+}
+
+// blockRE は段落で囲まなくてよいブロック要素で始まる行
+var blockRE = regexp.MustCompile(`(?i)^<(?:p|div|h[1-6]|ul|ol|li|dl|blockquote|pre|table|figure|hr|section|article|aside|address|details)[\s/>]`)
+
+var paragraphSepRE = regexp.MustCompile(`\n[ \t]*\n+`)
+
+// convertBreaks は改行で段落を区切ったテキストを HTML にする
+// 空行で区切られた部分を段落にし, 段落の中の改行は <br> にする. ブロック要素で始まる部分はそのまま残す
+func convertBreaks(s string) string {
+	var paragraphs []string
+	for _, p := range paragraphSepRE.Split(strings.TrimSpace(s), -1) {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if blockRE.MatchString(p) {
+			paragraphs = append(paragraphs, p)
+			continue
+		}
+		paragraphs = append(paragraphs, "<p>"+strings.ReplaceAll(p, "\n", "<br>\n")+"</p>")
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
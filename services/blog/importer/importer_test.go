@@ -0,0 +1,31 @@
+package importer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantTitle string
+		wantErr   error
+	}{
+		{"mt", mtExport, "はじめての記事", nil},
+		{"wxr", wxrExport, "Hello world", nil},
+		{"mt with a byte order mark", "\ufeff" + mtExport, "はじめての記事", nil},
+		{"unknown", "hello", "", ErrUnknownFileFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Parse([]byte(tt.data), "", time.UTC)
+			if err != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (len(entries) == 0 || entries[0].Title != tt.wantTitle) {
+				t.Errorf("Parse() = %v, want the first title %q", entries, tt.wantTitle)
+			}
+		})
+	}
+}
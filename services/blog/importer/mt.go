@@ -0,0 +1,152 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// mtFieldRE は MT 形式のファイルの先頭の行
+var mtFieldRE = regexp.MustCompile(`^(?:AUTHOR|TITLE|BASENAME|STATUS|DATE|ALLOW COMMENTS|CONVERT BREAKS|PRIMARY CATEGORY|CATEGORY):`)
+
+// mtDateLayouts は MT 形式の DATE の書式. 元の Movable Type は 12 時間制で, はてなブログは 24 時間制で書き出す
+var mtDateLayouts = []string{
+	"01/02/2006 03:04:05 PM",
+	"01/02/2006 15:04:05",
+}
+
+const (
+	// mtEntrySeparator は MT 形式でエントリを区切る行
+	mtEntrySeparator = "--------"
+	// mtSectionSeparator は MT 形式でエントリの中の本文などのセクションを区切る行
+	mtSectionSeparator = "-----"
+)
+
+// ParseMT は Movable Type の書き出し形式のファイルからエントリを読み取る
+// 本文は CONVERT BREAKS が markdown であれば Markdown として, それ以外は HTML として扱う
+// コメントやトラックバックは取り込まない
+func ParseMT(data []byte, loc *time.Location) ([]*Entry, error) {
+	text := strings.TrimPrefix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\ufeff")
+	var entries []*Entry
+	for _, block := range splitByLine(text, mtEntrySeparator) {
+		if strings.TrimSpace(block) == "" {
+			continue
+		}
+		entries = append(entries, parseMTEntry(block, loc))
+	}
+	return entries, nil
+}
+
+func parseMTEntry(block string, loc *time.Location) *Entry {
+	sections := splitByLine(block, mtSectionSeparator)
+	fields := map[string]string{}
+	var categories []string
+	for _, line := range strings.Split(sections[0], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "CATEGORY", "PRIMARY CATEGORY":
+			categories = append(categories, value)
+		default:
+			fields[key] = value
+		}
+	}
+	bodies := map[string]string{}
+	for _, section := range sections[1:] {
+		section = strings.TrimLeft(section, "\n")
+		name, content, _ := strings.Cut(section, "\n")
+		name = strings.TrimSuffix(strings.TrimSpace(name), ":")
+		bodies[name] = strings.TrimSpace(content)
+	}
+
+	e := &Entry{
+		Title:      fields["TITLE"],
+		Slug:       fields["BASENAME"],
+		Tags:       categories,
+		Visibility: domain.VisibilityPublic,
+	}
+	if basename := fields["BASENAME"]; basename != "" {
+		e.Key = "mt:basename:" + basename
+	} else {
+		e.Key = "mt:" + fields["DATE"] + "\n" + fields["TITLE"]
+	}
+
+	switch strings.ToLower(fields["CONVERT BREAKS"]) {
+	case "markdown", "markdown_with_smartypants":
+		e.Format = domain.EntryFormatMarkdown
+		e.Body = joinNonEmpty([]string{bodies["BODY"], bodies["EXTENDED BODY"]}, "\n\n")
+	case "1", "__default__":
+		e.Format = domain.EntryFormatHTML
+		e.Body = convertBreaks(joinNonEmpty([]string{bodies["BODY"], bodies["EXTENDED BODY"]}, "\n\n"))
+	default:
+		e.Format = domain.EntryFormatHTML
+		e.Body = joinNonEmpty([]string{bodies["BODY"], bodies["EXTENDED BODY"]}, "\n")
+	}
+
+	switch strings.ToLower(fields["STATUS"]) {
+	case "publish", "future", "":
+		e.Status = domain.EntryStatusPublished
+	case "draft":
+		e.Status = domain.EntryStatusDraft
+	default:
+		e.Err = fmt.Errorf("unknown STATUS %q", fields["STATUS"])
+		return e
+	}
+
+	date, ok := fields["DATE"]
+	if !ok {
+		e.Err = errors.New("DATE is missing")
+		return e
+	}
+	publishedAt, err := parseMTDate(date, loc)
+	if err != nil {
+		e.Err = fmt.Errorf("invalid DATE %q", date)
+		return e
+	}
+	e.PublishedAt = publishedAt
+	return e
+}
+
+func parseMTDate(s string, loc *time.Location) (time.Time, error) {
+	var err error
+	for _, layout := range mtDateLayouts {
+		var t time.Time
+		t, err = time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// splitByLine は text を separator だけからなる行で分割する
+func splitByLine(text, separator string) []string {
+	var blocks []string
+	var current []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimRight(line, " \t") == separator {
+			blocks = append(blocks, strings.Join(current, "\n"))
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	return append(blocks, strings.Join(current, "\n"))
+}
+
+func joinNonEmpty(parts []string, sep string) string {
+	var nonEmpty []string
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
@@ -0,0 +1,224 @@
+package importer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// mtExport ははてなブログや Movable Type の書き出しを短くしたもの
+const mtExport = `AUTHOR: alice
+TITLE: はじめての記事
+BASENAME: 2026/10/01/093000
+STATUS: Publish
+ALLOW COMMENTS: 1
+CONVERT BREAKS: 0
+DATE: 10/01/2026 09:30:00
+CATEGORY: Go
+CATEGORY: 日記
+-----
+BODY:
+<p>本文です</p>
+-----
+EXTENDED BODY:
+<p>続き</p>
+-----
+COMMENT:
+AUTHOR: bob
+DATE: 10/01/2026 10:00:00
+コメントは取り込まない
+-----
+--------
+AUTHOR: alice
+TITLE: Markdown の下書き
+STATUS: Draft
+CONVERT BREAKS: markdown
+DATE: 10/02/2026 01:15:00 PM
+-----
+BODY:
+# 見出し
+
+本文
+-----
+--------
+AUTHOR: alice
+TITLE: 改行を段落にする記事
+STATUS: Publish
+CONVERT BREAKS: 1
+DATE: 10/03/2026 08:00:00
+-----
+BODY:
+一行目
+二行目
+
+<ul><li>リスト</li></ul>
+
+段落
+-----
+--------
+AUTHOR: alice
+TITLE: 日付が壊れた記事
+BASENAME: broken
+STATUS: Publish
+DATE: yesterday
+-----
+BODY:
+<p>壊れている</p>
+-----
+--------
+AUTHOR: alice
+TITLE: 状態がわからない記事
+BASENAME: unknown-status
+STATUS: Secret
+DATE: 10/04/2026 08:00:00
+-----
+BODY:
+<p>?</p>
+-----
+--------
+AUTHOR: alice
+TITLE: 壊れた記事の後の記事
+BASENAME: after-broken
+DATE: 10/05/2026 12:00:00
+-----
+BODY:
+<p>読み取れる</p>
+-----
+--------
+`
+
+func TestParseMT(t *testing.T) {
+	jst := time.FixedZone("+09:00", 9*60*60)
+	entries, err := ParseMT([]byte(mtExport), jst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		want    Entry
+		wantErr bool
+	}{
+		{
+			want: Entry{
+				Key:         "mt:basename:2026/10/01/093000",
+				Title:       "はじめての記事",
+				Body:        "<p>本文です</p>\n<p>続き</p>",
+				Format:      domain.EntryFormatHTML,
+				Slug:        "2026/10/01/093000",
+				Tags:        []string{"Go", "日記"},
+				Status:      domain.EntryStatusPublished,
+				Visibility:  domain.VisibilityPublic,
+				PublishedAt: time.Date(2026, 10, 1, 9, 30, 0, 0, jst),
+			},
+		},
+		{
+			// BASENAME がない場合は日時とタイトルで識別する
+			want: Entry{
+				Key:         "mt:10/02/2026 01:15:00 PM\nMarkdown の下書き",
+				Title:       "Markdown の下書き",
+				Body:        "# 見出し\n\n本文",
+				Format:      domain.EntryFormatMarkdown,
+				Status:      domain.EntryStatusDraft,
+				Visibility:  domain.VisibilityPublic,
+				PublishedAt: time.Date(2026, 10, 2, 13, 15, 0, 0, jst),
+			},
+		},
+		{
+			want: Entry{
+				Key:         "mt:10/03/2026 08:00:00\n改行を段落にする記事",
+				Title:       "改行を段落にする記事",
+				Body:        "<p>一行目<br>\n二行目</p>\n\n<ul><li>リスト</li></ul>\n\n<p>段落</p>",
+				Format:      domain.EntryFormatHTML,
+				Status:      domain.EntryStatusPublished,
+				Visibility:  domain.VisibilityPublic,
+				PublishedAt: time.Date(2026, 10, 3, 8, 0, 0, 0, jst),
+			},
+		},
+		{want: Entry{Key: "mt:basename:broken", Title: "日付が壊れた記事"}, wantErr: true},
+		{want: Entry{Key: "mt:basename:unknown-status", Title: "状態がわからない記事"}, wantErr: true},
+		{
+			// 読み取れないエントリがあっても, 後のエントリは読み取る
+			want: Entry{
+				Key:         "mt:basename:after-broken",
+				Title:       "壊れた記事の後の記事",
+				Body:        "<p>読み取れる</p>",
+				Format:      domain.EntryFormatHTML,
+				Slug:        "after-broken",
+				Status:      domain.EntryStatusPublished,
+				Visibility:  domain.VisibilityPublic,
+				PublishedAt: time.Date(2026, 10, 5, 12, 0, 0, 0, jst),
+			},
+		},
+	}
+	if len(entries) != len(tests) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.want.Title, func(t *testing.T) {
+			assertEntry(t, entries[i], &tt.want, tt.wantErr)
+		})
+	}
+}
+
+func TestParseMTKeysAreStable(t *testing.T) {
+	// 改行コードや BOM が違っても, 同じファイルを二度取り込んだときに同じエントリだとわかるようにする
+	crlf := "\ufeff" + strings.ReplaceAll(mtExport, "\n", "\r\n")
+	first, err := ParseMT([]byte(mtExport), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ParseMT([]byte(crlf), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.EqualFunc(first, second, func(a, b *Entry) bool { return a.Key == b.Key }) {
+		t.Errorf("keys differ between parses")
+	}
+	keys := map[string]bool{}
+	for _, e := range first {
+		if keys[e.Key] {
+			t.Errorf("duplicate key %q", e.Key)
+		}
+		keys[e.Key] = true
+	}
+}
+
+// assertEntry は読み取ったエントリを比べる. wantErr の場合は Key と Title と Err だけを比べる
+func assertEntry(t *testing.T, got, want *Entry, wantErr bool) {
+	t.Helper()
+	if got.Key != want.Key {
+		t.Errorf("Key = %q, want %q", got.Key, want.Key)
+	}
+	if got.Title != want.Title {
+		t.Errorf("Title = %q, want %q", got.Title, want.Title)
+	}
+	if wantErr {
+		if got.Err == nil {
+			t.Errorf("Err = nil, want an error")
+		}
+		return
+	}
+	if got.Err != nil {
+		t.Fatalf("Err = %v", got.Err)
+	}
+	if got.Body != want.Body {
+		t.Errorf("Body = %q, want %q", got.Body, want.Body)
+	}
+	if got.Format != want.Format {
+		t.Errorf("Format = %q, want %q", got.Format, want.Format)
+	}
+	if got.Slug != want.Slug {
+		t.Errorf("Slug = %q, want %q", got.Slug, want.Slug)
+	}
+	if !slices.Equal(got.Tags, want.Tags) {
+		t.Errorf("Tags = %q, want %q", got.Tags, want.Tags)
+	}
+	if got.Status != want.Status || got.Visibility != want.Visibility {
+		t.Errorf("Status, Visibility = %q, %q, want %q, %q", got.Status, got.Visibility, want.Status, want.Visibility)
+	}
+	if !got.PublishedAt.Equal(want.PublishedAt) {
+		t.Errorf("PublishedAt = %v, want %v", got.PublishedAt, want.PublishedAt)
+	}
+}
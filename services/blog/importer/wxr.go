@@ -0,0 +1,151 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// wxrItem は WXR の item 要素
+// WordPress 独自の要素は書き出した WordPress のバージョンによって名前空間が異なるので, 名前空間を指定せずに読む
+type wxrItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        string        `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Content     string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostID      string        `xml:"post_id"`
+	PostDate    string        `xml:"post_date"`
+	PostDateGMT string        `xml:"post_date_gmt"`
+	PostName    string        `xml:"post_name"`
+	Status      string        `xml:"status"`
+	PostType    string        `xml:"post_type"`
+	Categories  []wxrCategory `xml:"category"`
+}
+
+type wxrCategory struct {
+	Domain string `xml:"domain,attr"`
+	Name   string `xml:",chardata"`
+}
+
+// wxrDateLayout は wp:post_date と wp:post_date_gmt の書式
+const wxrDateLayout = "2006-01-02 15:04:05"
+
+// wxrZeroDate は下書きなどで日時が決まっていないときの wp:post_date_gmt の値
+const wxrZeroDate = "0000-00-00 00:00:00"
+
+// ParseWXR は WordPress の書き出し形式のファイルからエントリを読み取る
+// 投稿 (post_type が post のもの) だけを読み取り, 固定ページや添付ファイル, ゴミ箱にある投稿は読み飛ばす
+// 本文は HTML として扱い, 段落が p 要素になっていない本文は WordPress と同じように改行から段落を作る
+func ParseWXR(data []byte, loc *time.Location) ([]*Entry, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// WordPress は XML として正しくない文字参照を書き出すことがあるので厳密には読まない
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	var entries []*Entry
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "item" {
+			continue
+		}
+		var item wxrItem
+		if err := decoder.DecodeElement(&item, &start); err != nil {
+			return nil, err
+		}
+		if item.PostType != "" && item.PostType != "post" {
+			continue
+		}
+		if item.Status == "trash" || item.Status == "auto-draft" || item.Status == "inherit" {
+			continue
+		}
+		entries = append(entries, parseWXRItem(&item, loc))
+	}
+	return entries, nil
+}
+
+func parseWXRItem(item *wxrItem, loc *time.Location) *Entry {
+	e := &Entry{
+		Title:      strings.TrimSpace(item.Title),
+		Format:     domain.EntryFormatHTML,
+		Slug:       item.PostName,
+		Visibility: domain.VisibilityPublic,
+	}
+	switch {
+	case strings.TrimSpace(item.GUID) != "":
+		e.Key = "wxr:guid:" + strings.TrimSpace(item.GUID)
+	case item.PostID != "":
+		e.Key = "wxr:post_id:" + item.PostID
+	default:
+		e.Key = "wxr:" + item.PostDate + "\n" + e.Title
+	}
+
+	e.Body = item.Content
+	if !strings.Contains(e.Body, "<p") {
+		e.Body = convertBreaks(e.Body)
+	}
+	for _, c := range item.Categories {
+		if c.Domain == "category" || c.Domain == "post_tag" {
+			e.Tags = append(e.Tags, strings.TrimSpace(c.Name))
+		}
+	}
+
+	switch item.Status {
+	case "publish", "future", "":
+		e.Status = domain.EntryStatusPublished
+	case "private":
+		e.Status = domain.EntryStatusPublished
+		e.Visibility = domain.VisibilityPrivate
+	case "draft", "pending":
+		e.Status = domain.EntryStatusDraft
+	default:
+		e.Err = fmt.Errorf("unknown status %q", item.Status)
+		return e
+	}
+
+	publishedAt, err := parseWXRDate(item, loc)
+	if err != nil {
+		e.Err = err
+		return e
+	}
+	e.PublishedAt = publishedAt
+	return e
+}
+
+// parseWXRDate は投稿の日時を読み取る. UTC の日時があればそれを使い, なければ loc の日時として読む
+func parseWXRDate(item *wxrItem, loc *time.Location) (time.Time, error) {
+	if item.PostDateGMT != "" && item.PostDateGMT != wxrZeroDate {
+		t, err := time.ParseInLocation(wxrDateLayout, item.PostDateGMT, time.UTC)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid post_date_gmt %q", item.PostDateGMT)
+		}
+		return t, nil
+	}
+	if item.PostDate != "" && item.PostDate != wxrZeroDate {
+		t, err := time.ParseInLocation(wxrDateLayout, item.PostDate, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid post_date %q", item.PostDate)
+		}
+		return t, nil
+	}
+	if item.PubDate != "" {
+		t, err := time.Parse(time.RFC1123Z, item.PubDate)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid pubDate %q", item.PubDate)
+		}
+		return t, nil
+	}
+	return time.Time{}, errors.New("post_date is missing")
+}
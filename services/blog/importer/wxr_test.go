@@ -0,0 +1,178 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// wxrExport は WordPress の書き出しを短くしたもの
+const wxrExport = `<?xml version="1.0" encoding="UTF-8" ?>
+<rss version="2.0"
+	xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>My WordPress</title>
+	<link>https://example.com</link>
+	<wp:wxr_version>1.2</wp:wxr_version>
+	<item>
+		<title>Hello world</title>
+		<link>https://example.com/2026/10/01/hello/</link>
+		<pubDate>Thu, 01 Oct 2026 00:30:00 +0000</pubDate>
+		<dc:creator><![CDATA[alice]]></dc:creator>
+		<guid isPermaLink="false">https://example.com/?p=1</guid>
+		<content:encoded><![CDATA[<p>Hello &amp; welcome</p>]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_date><![CDATA[2026-10-01 09:30:00]]></wp:post_date>
+		<wp:post_date_gmt><![CDATA[2026-10-01 00:30:00]]></wp:post_date_gmt>
+		<wp:post_name><![CDATA[hello]]></wp:post_name>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<category domain="category" nicename="go"><![CDATA[Go]]></category>
+		<category domain="post_tag" nicename="diary"><![CDATA[日記]]></category>
+		<category domain="post_format" nicename="post-format-aside"><![CDATA[Aside]]></category>
+	</item>
+	<item>
+		<title>下書き</title>
+		<guid isPermaLink="false"></guid>
+		<content:encoded><![CDATA[一行目
+二行目
+
+段落]]></content:encoded>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_date><![CDATA[2026-10-02 18:00:00]]></wp:post_date>
+		<wp:post_date_gmt><![CDATA[0000-00-00 00:00:00]]></wp:post_date_gmt>
+		<wp:status><![CDATA[draft]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+	</item>
+	<item>
+		<title>About</title>
+		<guid isPermaLink="false">https://example.com/?page_id=3</guid>
+		<wp:post_id>3</wp:post_id>
+		<wp:post_date><![CDATA[2026-10-01 00:00:00]]></wp:post_date>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[page]]></wp:post_type>
+	</item>
+	<item>
+		<title>Trashed</title>
+		<guid isPermaLink="false">https://example.com/?p=4</guid>
+		<wp:post_date><![CDATA[2026-10-01 00:00:00]]></wp:post_date>
+		<wp:status><![CDATA[trash]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+	</item>
+	<item>
+		<title>Private</title>
+		<content:encoded><![CDATA[<p>秘密</p>]]></content:encoded>
+		<wp:post_date><![CDATA[2026-10-03 08:00:00]]></wp:post_date>
+		<wp:status><![CDATA[private]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+	</item>
+	<item>
+		<title>Broken date</title>
+		<guid isPermaLink="false">https://example.com/?p=6</guid>
+		<wp:post_date><![CDATA[not a date]]></wp:post_date>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+	</item>
+	<item>
+		<title>Unknown status</title>
+		<guid isPermaLink="false">https://example.com/?p=7</guid>
+		<wp:post_date><![CDATA[2026-10-04 08:00:00]]></wp:post_date>
+		<wp:status><![CDATA[weird]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+	</item>
+	<item>
+		<title>Only pubDate</title>
+		<pubDate>Mon, 05 Oct 2026 03:00:00 +0000</pubDate>
+		<guid isPermaLink="false">https://example.com/?p=8</guid>
+		<content:encoded><![CDATA[<p>after the broken ones</p>]]></content:encoded>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+	</item>
+</channel>
+</rss>
+`
+
+func TestParseWXR(t *testing.T) {
+	jst := time.FixedZone("+09:00", 9*60*60)
+	entries, err := ParseWXR([]byte(wxrExport), jst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		want    Entry
+		wantErr bool
+	}{
+		{
+			// UTC の日時があればそちらを使う. カテゴリーとタグだけをタグにする
+			want: Entry{
+				Key:         "wxr:guid:https://example.com/?p=1",
+				Title:       "Hello world",
+				Body:        "<p>Hello &amp; welcome</p>",
+				Format:      domain.EntryFormatHTML,
+				Slug:        "hello",
+				Tags:        []string{"Go", "日記"},
+				Status:      domain.EntryStatusPublished,
+				Visibility:  domain.VisibilityPublic,
+				PublishedAt: time.Date(2026, 10, 1, 0, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			// GUID が空の場合は post_id で識別する. 段落のない本文は改行から段落を作る
+			want: Entry{
+				Key:         "wxr:post_id:2",
+				Title:       "下書き",
+				Body:        "<p>一行目<br>\n二行目</p>\n\n<p>段落</p>",
+				Format:      domain.EntryFormatHTML,
+				Status:      domain.EntryStatusDraft,
+				Visibility:  domain.VisibilityPublic,
+				PublishedAt: time.Date(2026, 10, 2, 18, 0, 0, 0, jst),
+			},
+		},
+		{
+			// GUID も post_id もない場合は日時とタイトルで識別する
+			want: Entry{
+				Key:         "wxr:2026-10-03 08:00:00\nPrivate",
+				Title:       "Private",
+				Body:        "<p>秘密</p>",
+				Format:      domain.EntryFormatHTML,
+				Status:      domain.EntryStatusPublished,
+				Visibility:  domain.VisibilityPrivate,
+				PublishedAt: time.Date(2026, 10, 3, 8, 0, 0, 0, jst),
+			},
+		},
+		{want: Entry{Key: "wxr:guid:https://example.com/?p=6", Title: "Broken date"}, wantErr: true},
+		{want: Entry{Key: "wxr:guid:https://example.com/?p=7", Title: "Unknown status"}, wantErr: true},
+		{
+			// 読み取れない投稿があっても, 後の投稿は読み取る
+			want: Entry{
+				Key:         "wxr:guid:https://example.com/?p=8",
+				Title:       "Only pubDate",
+				Body:        "<p>after the broken ones</p>",
+				Format:      domain.EntryFormatHTML,
+				Status:      domain.EntryStatusPublished,
+				Visibility:  domain.VisibilityPublic,
+				PublishedAt: time.Date(2026, 10, 5, 3, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	// 固定ページとゴミ箱にある投稿は読み飛ばす
+	if len(entries) != len(tests) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.want.Title, func(t *testing.T) {
+			assertEntry(t, entries[i], &tt.want, tt.wantErr)
+		})
+	}
+}
+
+func TestParseWXRMalformedFile(t *testing.T) {
+	_, err := ParseWXR([]byte(`<?xml version="1.0"?><rss><channel><item><title>unterminated`), time.UTC)
+	if err == nil {
+		t.Error("ParseWXR() = nil, want an error")
+	}
+}
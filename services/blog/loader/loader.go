@@ -0,0 +1,167 @@
+// Package loader はキーごとの検索を短い時間だけ待ってまとめ, 1 回のバッチ検索にする (DataLoader)
+package loader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var tracer = otel.Tracer("loader")
+
+// FetchFunc は複数のキーをまとめて検索する
+// 見つからなかったキーは結果に含めなくてよい
+type FetchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader はキーごとの検索をまとめて FetchFunc で検索し, 結果をキャッシュする
+// キャッシュは Loader ごとに持つので, リクエストごとに Loader を作成して使う
+type Loader[K comparable, V any] struct {
+	name     string
+	fetch    FetchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*result[V]
+	batch *batch[K, V]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	found bool
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	keys    []K
+	results map[K]*result[V]
+	timer   *time.Timer
+}
+
+const (
+	defaultWait     = 2 * time.Millisecond
+	defaultMaxBatch = 100
+)
+
+// New は Loader を作成する
+// name はトレースのスパンの名前に使う
+func New[K comparable, V any](name string, fetch FetchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		name:     name,
+		fetch:    fetch,
+		wait:     defaultWait,
+		maxBatch: defaultMaxBatch,
+		cache:    make(map[K]*result[V]),
+	}
+}
+
+// Load はキーで検索する
+// 同時に呼ばれた Load はまとめて検索され, 見つからなかった場合は V のゼロ値を返す
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	r := l.enqueue(ctx, key)
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadMany は複数のキーで検索する
+// 見つからなかったキーは結果に含まれない
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) (map[K]V, error) {
+	results := make(map[K]*result[V], len(keys))
+	for _, key := range keys {
+		results[key] = l.enqueue(ctx, key)
+	}
+	values := make(map[K]V, len(keys))
+	for key, r := range results {
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		if r.found {
+			values[key] = r.value
+		}
+	}
+	return values, nil
+}
+
+// Prime は検索済みの値をキャッシュに入れる
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+	r := &result[V]{done: make(chan struct{}), value: value, found: true}
+	close(r.done)
+	l.cache[key] = r
+}
+
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K) *result[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r, ok := l.cache[key]; ok {
+		return r
+	}
+	r := &result[V]{done: make(chan struct{})}
+	l.cache[key] = r
+	if l.batch == nil {
+		b := &batch[K, V]{results: make(map[K]*result[V])}
+		// 検索はリクエストの処理が終わっても完了させる
+		b.timer = time.AfterFunc(l.wait, func() { l.dispatch(context.WithoutCancel(ctx), b) })
+		l.batch = b
+	}
+	l.batch.keys = append(l.batch.keys, key)
+	l.batch.results[key] = r
+	if len(l.batch.keys) >= l.maxBatch {
+		// 待たずに検索し, 以降のキーは次のバッチにまとめる
+		b := l.batch
+		l.batch = nil
+		if b.timer.Stop() {
+			go l.dispatch(context.WithoutCancel(ctx), b)
+		}
+	}
+	return r
+}
+
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	l.mu.Lock()
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	ctx, span := tracer.Start(ctx, "loader."+l.name)
+	defer span.End()
+	span.SetAttributes(attribute.Int("loader.batch_size", len(b.keys)))
+
+	values, err := l.fetch(ctx, b.keys)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to fetch")
+		span.RecordError(err)
+	}
+	l.mu.Lock()
+	for key, r := range b.results {
+		if err != nil {
+			r.err = err
+			// 失敗した検索はキャッシュせず, 次の Load で検索し直す
+			delete(l.cache, key)
+		} else {
+			r.value, r.found = values[key]
+		}
+		close(r.done)
+	}
+	l.mu.Unlock()
+}
@@ -0,0 +1,162 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// recorder は FetchFunc に渡されたキーを記録する
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (r *recorder) fetch(ctx context.Context, keys []int) (map[int]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sorted := slices.Clone(keys)
+	slices.Sort(sorted)
+	r.batches = append(r.batches, sorted)
+	if r.err != nil {
+		return nil, r.err
+	}
+	values := make(map[int]string, len(keys))
+	for _, k := range keys {
+		// 負のキーは見つからないものとする
+		if k >= 0 {
+			values[k] = string(rune('a' + k))
+		}
+	}
+	return values, nil
+}
+
+func TestLoaderLoadMany(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        []int
+		want        map[int]string
+		wantBatches [][]int
+	}{
+		{"one batch", []int{2, 0, 1}, map[int]string{0: "a", 1: "b", 2: "c"}, [][]int{{0, 1, 2}}},
+		{"duplicate keys", []int{1, 1, 1}, map[int]string{1: "b"}, [][]int{{1}}},
+		{"not found", []int{-1, 3}, map[int]string{3: "d"}, [][]int{{-1, 3}}},
+		{"no keys", nil, map[int]string{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			l := New("test", r.fetch)
+			got, err := l.LoadMany(context.Background(), tt.keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("LoadMany() = %v, want %v", got, tt.want)
+			}
+			if !slices.EqualFunc(r.batches, tt.wantBatches, slices.Equal) {
+				t.Errorf("batches = %v, want %v", r.batches, tt.wantBatches)
+			}
+		})
+	}
+}
+
+func TestLoaderLoadConcurrently(t *testing.T) {
+	r := &recorder{}
+	l := New("test", r.fetch)
+	var wg sync.WaitGroup
+	got := make([]string, 10)
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := l.Load(context.Background(), i%5)
+			if err != nil {
+				t.Error(err)
+			}
+			got[i] = v
+		}()
+	}
+	wg.Wait()
+	for i, v := range got {
+		if want := string(rune('a' + i%5)); v != want {
+			t.Errorf("Load(%d) = %q, want %q", i%5, v, want)
+		}
+	}
+	// 同時に呼ばれた Load はまとめて 1 回で検索する
+	if len(r.batches) != 1 || !slices.Equal(r.batches[0], []int{0, 1, 2, 3, 4}) {
+		t.Errorf("batches = %v, want [[0 1 2 3 4]]", r.batches)
+	}
+
+	// 検索した結果はキャッシュする
+	if v, err := l.Load(context.Background(), 3); err != nil || v != "d" {
+		t.Errorf("Load(3) = (%q, %v)", v, err)
+	}
+	if len(r.batches) != 1 {
+		t.Errorf("fetched again: %v", r.batches)
+	}
+}
+
+func TestLoaderMaxBatch(t *testing.T) {
+	r := &recorder{}
+	l := New("test", r.fetch)
+	l.maxBatch = 2
+	if _, err := l.LoadMany(context.Background(), []int{0, 1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, b := range r.batches {
+		if len(b) > 2 {
+			t.Errorf("batch %v is larger than 2", b)
+		}
+		n += len(b)
+	}
+	if n != 5 {
+		t.Errorf("batches = %v, want 5 keys in total", r.batches)
+	}
+}
+
+func TestLoaderPrime(t *testing.T) {
+	r := &recorder{}
+	l := New("test", r.fetch)
+	l.Prime(1, "primed")
+	got, err := l.LoadMany(context.Background(), []int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(got, map[int]string{1: "primed", 2: "c"}) {
+		t.Errorf("LoadMany() = %v", got)
+	}
+	if !slices.EqualFunc(r.batches, [][]int{{2}}, slices.Equal) {
+		t.Errorf("batches = %v, want [[2]]", r.batches)
+	}
+}
+
+func TestLoaderError(t *testing.T) {
+	errFetch := errors.New("failed to fetch")
+	r := &recorder{err: errFetch}
+	l := New("test", r.fetch)
+	if _, err := l.Load(context.Background(), 1); !errors.Is(err, errFetch) {
+		t.Fatalf("Load() = %v, want %v", err, errFetch)
+	}
+	// 失敗した検索はキャッシュしない
+	r.mu.Lock()
+	r.err = nil
+	r.mu.Unlock()
+	if v, err := l.Load(context.Background(), 1); err != nil || v != "b" {
+		t.Errorf("Load() after the error = (%q, %v), want (\"b\", nil)", v, err)
+	}
+}
+
+func TestLoaderCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l := New("test", (&recorder{}).fetch)
+	if _, err := l.Load(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Load() = %v, want %v", err, context.Canceled)
+	}
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,10 +17,16 @@ import (
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/config"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/db"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/importer"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/log"
 	pb_account "github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/pb/account"
 	pb_renderer "github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/pb/renderer"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/scheduler"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/search"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/storage"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/web"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/webhook"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
@@ -106,8 +114,26 @@ func run(args []string) error {
 	}()
 	rendererCli := pb_renderer.NewRendererClient(rendererConn)
 
+	// メディアのストレージを初期化
+	var mediaStorage domain.MediaStorage
+	if conf.MediaStorage == "s3" {
+		mediaStorage, err = storage.NewS3Storage(conf.MediaS3Endpoint, conf.MediaS3Region, conf.MediaS3Bucket, conf.MediaS3AccessKeyID, conf.MediaS3SecretKey)
+		if err != nil {
+			return fmt.Errorf("failed to create media storage: %+v", err)
+		}
+	} else {
+		mediaStorage = storage.NewLocalStorage(conf.MediaDir)
+	}
+
 	// アプリケーションを初期化
-	app := app.NewApp(db, accountCli, conf.AccountECDSAPublicKey, rendererCli)
+	searchIndex := search.NewMySQLIndex(db)
+	webhookSender := webhook.NewHTTPSender(conf.WebhookTimeout, conf.WebhookAllowPrivateNetwork)
+	app := app.NewApp(db, accountCli, conf.AccountECDSAPublicKey, rendererCli, searchIndex, mediaStorage, conf.TrashRetention, conf.ShareLinkSecret, webhookSender)
+
+	// import サブコマンドではサーバーを起動せず, ファイルからエントリを取り込んで終了する
+	if len(args) > 1 && args[1] == "import" {
+		return runImport(ctx, app, args[2:], os.Stdout)
+	}
 
 	// ロガーを初期化
 	logger, err := log.NewLogger(log.Config{Mode: conf.Mode})
@@ -118,17 +144,28 @@ func run(args []string) error {
 		_ = logger.Sync()
 	}()
 
+	// 予約投稿の公開やゴミ箱の削除を行うスケジューラーを起動
+	sched := scheduler.NewScheduler(app, conf.SchedulerInterval, logger)
+	logger.Info(fmt.Sprintf("starting scheduler (interval = %v)", conf.SchedulerInterval))
+	sched.Start()
+
 	// サーバーを起動
 	// TODO: logger をサーバーでも使う
-	server, err := web.NewServer(app, conf.ServiceName)
+	server, err := web.NewServer(app, conf.ServiceName, conf.BaseURL)
 	if err != nil {
 		return fmt.Errorf("failed to create server: %+v", err)
 	}
 	logger.Info(fmt.Sprintf("starting web server (port = %v)", conf.Port))
-	go stop(server, conf.GracefulStopTimeout, logger)
+	stopped := make(chan struct{})
+	go func() {
+		stop(app, server, sched, conf.GracefulStopTimeout, logger)
+		close(stopped)
+	}()
 	if err := server.Start(":" + strconv.Itoa(conf.Port)); !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
+	// 停止するときに書き込むエントリの閲覧を書き込み終わるまで待つ
+	<-stopped
 
 	if err := mp.Shutdown(ctx); err != nil {
 		return fmt.Errorf("failed to shutdown meter provider: %+v", err)
@@ -141,16 +178,90 @@ func run(args []string) error {
 	return nil
 }
 
-func stop(server *web.Server, timeout time.Duration, logger *zap.Logger) {
+func stop(a *app.App, server *web.Server, sched *scheduler.Scheduler, timeout time.Duration, logger *zap.Logger) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	sig := <-sigChan
 	logger.Info(fmt.Sprintf("gracefully stopping server (sig = %v)", sig))
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+	if err := sched.Shutdown(ctx); err != nil {
+		logger.Warn(fmt.Sprintf("failed to stop scheduler: %+v", err))
+	}
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Warn(fmt.Sprintf("failed to stop server: %+v", err))
 	}
+	// サーバーを停止した後は閲覧が増えないので, メモリに残っている閲覧をすべて書き込む
+	// サーバーの停止で ctx の期限を使い切っていても書き込めるよう, 別に期限を設ける
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), timeout)
+	defer flushCancel()
+	if _, err := a.FlushEntryViews(flushCtx); err != nil {
+		logger.Warn(fmt.Sprintf("failed to flush entry views: %+v", err))
+	}
+}
+
+// runImport は import サブコマンドを実行する
+// 他のブログサービスから書き出したファイルのエントリをブログに取り込み, エントリごとの結果を出力する
+//
+//	go run main.go import -blog <ブログのパス> -user <オーナーのユーザー名> [-format mt|wxr] [-timezone +09:00] <ファイル>
+func runImport(ctx context.Context, a *app.App, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	blogPath := flags.String("blog", "", "path of the blog to import entries into")
+	userName := flags.String("user", "", "name of the blog owner")
+	format := flags.String("format", "", "file format (mt or wxr). detected from the file if omitted")
+	timezone := flags.String("timezone", "+09:00", "UTC offset of dates without time zone in the file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *blogPath == "" || *userName == "" || flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("-blog, -user and a file are required")
+	}
+	loc, err := importer.ParseTimezone(*timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %+v", err)
+	}
+
+	user, err := a.FindUserByName(ctx, *userName)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %+v", err)
+	}
+	blog, err := a.FindBlogByPath(ctx, *blogPath)
+	if err != nil {
+		return fmt.Errorf("failed to find blog: %+v", err)
+	}
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	entries, err := importer.Parse(data, importer.FileFormat(*format), loc)
+	if err != nil {
+		return fmt.Errorf("failed to parse file: %+v", err)
+	}
+	results, err := a.ImportEntries(ctx, user, blog, entries)
+	if err != nil {
+		return fmt.Errorf("failed to import entries: %+v", err)
+	}
+
+	var imported, skipped, failed int
+	for i, r := range results {
+		switch {
+		case r.Entry != nil:
+			imported++
+			fmt.Fprintf(out, "%d\timported\t%s\t%s\n", i+1, r.Entry.ID, r.Title)
+		case r.Skipped:
+			skipped++
+			fmt.Fprintf(out, "%d\tskipped\t-\t%s\n", i+1, r.Title)
+		default:
+			failed++
+			fmt.Fprintf(out, "%d\tfailed\t-\t%s\t%v\n", i+1, r.Title, r.Err)
+		}
+	}
+	fmt.Fprintf(out, "imported: %d, skipped: %d, failed: %d\n", imported, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d entries failed to import", failed)
+	}
+	return nil
 }
 
 func newResource(ctx context.Context, conf config.Config) (*resource.Resource, error) {
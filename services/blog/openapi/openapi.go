@@ -0,0 +1,11 @@
+// Package openapi は JSON API の OpenAPI ドキュメントを埋め込む
+package openapi
+
+import (
+	_ "embed"
+)
+
+// Document は /api/v1 の OpenAPI ドキュメント (YAML)
+//
+//go:embed openapi.yaml
+var Document []byte
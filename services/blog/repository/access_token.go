@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// AccessTokenRepository は domain.AccessTokenRepository に対するデータベースを使った実装
+type AccessTokenRepository struct {
+	db DB
+}
+
+func newAccessTokenRepository(db DB) *AccessTokenRepository {
+	return &AccessTokenRepository{db}
+}
+
+// Create は新規パーソナルアクセストークンを作成し, リポジトリに保存する
+func (r *AccessTokenRepository) Create(ctx context.Context, input *domain.CreateAccessTokenInput) (*domain.AccessToken, error) {
+	id, err := generateID(r.db)
+	if err != nil {
+		return nil, err
+	}
+	token := &domain.AccessToken{
+		ID:        domain.AccessTokenID(id),
+		UserID:    input.UserID,
+		Name:      input.Name,
+		TokenHash: input.TokenHash,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+		CreatedAt: input.CreatedAt,
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO access_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+		`,
+		token.ID, token.UserID, token.Name, token.TokenHash, token.Scopes, token.ExpiresAt, token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// FindByID はリポジトリから ID でパーソナルアクセストークンを検索する
+func (r *AccessTokenRepository) FindByID(ctx context.Context, id domain.AccessTokenID) (*domain.AccessToken, error) {
+	var token domain.AccessToken
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&token,
+		`
+			SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at FROM access_tokens
+				WHERE id = ? LIMIT 1
+		`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindByTokenHash はリポジトリからトークンのハッシュでパーソナルアクセストークンを検索する
+func (r *AccessTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*domain.AccessToken, error) {
+	var token domain.AccessToken
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&token,
+		`
+			SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at FROM access_tokens
+				WHERE token_hash = ? LIMIT 1
+		`,
+		tokenHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListByUserID はユーザーのパーソナルアクセストークンを期限切れのものも含めて新しい順に返す
+func (r *AccessTokenRepository) ListByUserID(ctx context.Context, userID domain.UserID) ([]*domain.AccessToken, error) {
+	tokens := []*domain.AccessToken{}
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&tokens,
+		`
+			SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at FROM access_tokens
+				WHERE user_id = ?
+				ORDER BY created_at DESC, id DESC
+		`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// UpdateLastUsedAt はパーソナルアクセストークンを最後に使った日時を更新する
+func (r *AccessTokenRepository) UpdateLastUsedAt(ctx context.Context, id domain.AccessTokenID, lastUsedAt time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE access_tokens SET last_used_at = ? WHERE id = ?
+		`,
+		lastUsedAt, id,
+	)
+	return err
+}
+
+// Delete はパーソナルアクセストークンをリポジトリから削除する
+func (r *AccessTokenRepository) Delete(ctx context.Context, id domain.AccessTokenID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM access_tokens WHERE id = ?
+		`,
+		id,
+	)
+	return err
+}
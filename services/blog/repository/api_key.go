@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// APIKeyRepository は domain.APIKeyRepository に対するデータベースを使った実装
+type APIKeyRepository struct {
+	db DB
+}
+
+func newAPIKeyRepository(db DB) *APIKeyRepository {
+	return &APIKeyRepository{db}
+}
+
+// Save はユーザーの API キーをリポジトリに保存する. 既にあれば置き換える
+func (r *APIKeyRepository) Save(ctx context.Context, input *domain.SaveAPIKeyInput) (*domain.APIKey, error) {
+	apiKey := &domain.APIKey{
+		UserID:    input.UserID,
+		Key:       input.Key,
+		CreatedAt: input.CreatedAt,
+	}
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO api_keys (user_id, api_key, created_at)
+				VALUES (?, ?, ?) AS new
+				ON DUPLICATE KEY UPDATE api_key = new.api_key, created_at = new.created_at
+		`,
+		apiKey.UserID, apiKey.Key, apiKey.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// FindByUserID はリポジトリからユーザーの ID で API キーを検索する
+func (r *APIKeyRepository) FindByUserID(ctx context.Context, userID domain.UserID) (*domain.APIKey, error) {
+	var apiKey domain.APIKey
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&apiKey,
+		`
+			SELECT user_id, api_key, created_at FROM api_keys
+				WHERE user_id = ? LIMIT 1
+		`,
+		userID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &apiKey, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
@@ -30,14 +31,18 @@ func (r *BlogRepository) Create(ctx context.Context, input *domain.CreateBlogInp
 		Path:        input.Path,
 		Title:       input.Title,
 		Description: input.Description,
+		// コメントはブログを作成した時点では受け付ける
+		CommentsEnabled: true,
+		CreatedAt:       input.CreatedAt,
+		Visibility:      domain.VisibilityPublic,
 	}
 	_, err = r.db.ExecContext(
 		ctx,
 		`
-			INSERT INTO blogs (id, user_id, path, title, description)
-				VALUES (?, ?, ?, ?, ?)
+			INSERT INTO blogs (id, user_id, path, title, description, visibility, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
 		`,
-		blog.ID, blog.UserID, blog.Path, blog.Title, blog.Description,
+		blog.ID, blog.UserID, blog.Path, blog.Title, blog.Description, blog.Visibility, blog.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -53,7 +58,7 @@ func (r *BlogRepository) FindByID(ctx context.Context, id domain.BlogID) (*domai
 		r.db,
 		&blog,
 		`
-			SELECT id, user_id, path, title, description FROM blogs
+			SELECT id, user_id, path, title, description, comments_enabled, created_at, visibility, deleted_at FROM blogs
 				WHERE id = ? LIMIT 1
 		`,
 		id,
@@ -75,7 +80,29 @@ func (r *BlogRepository) FindByPath(ctx context.Context, path string) (*domain.B
 		r.db,
 		&blog,
 		`
-			SELECT id, user_id, path, title, description FROM blogs
+			SELECT id, user_id, path, title, description, comments_enabled, created_at, visibility, deleted_at FROM blogs
+				WHERE path = ? AND deleted_at IS NULL LIMIT 1
+		`,
+		path,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &blog, nil
+}
+
+// FindByPathIncludingTrashed はリポジトリからゴミ箱にあるブログも含めてブログをパスで検索する
+func (r *BlogRepository) FindByPathIncludingTrashed(ctx context.Context, path string) (*domain.Blog, error) {
+	var blog domain.Blog
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&blog,
+		`
+			SELECT id, user_id, path, title, description, comments_enabled, created_at, visibility, deleted_at FROM blogs
 				WHERE path = ? LIMIT 1
 		`,
 		path,
@@ -89,18 +116,104 @@ func (r *BlogRepository) FindByPath(ctx context.Context, path string) (*domain.B
 	return &blog, nil
 }
 
-// List はリポジトリから全ユーザーのブログを検索する
-func (r *BlogRepository) List(ctx context.Context, limit, offset int) ([]*domain.Blog, error) {
+// ListByIDs はリポジトリから複数の ID でブログを検索する
+func (r *BlogRepository) ListByIDs(ctx context.Context, ids []domain.BlogID) ([]*domain.Blog, error) {
+	blogs := make([]*domain.Blog, 0, len(ids))
+	if len(ids) == 0 {
+		return blogs, nil
+	}
+	query, args, err := sqlx.In(
+		`
+			SELECT id, user_id, path, title, description, comments_enabled, created_at, visibility, deleted_at FROM blogs
+				WHERE id IN (?) AND deleted_at IS NULL
+		`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.SelectContext(ctx, r.db, &blogs, query, args...); err != nil {
+		return nil, err
+	}
+	return blogs, nil
+}
+
+// List はリポジトリから全ユーザーの公開範囲が public のブログを作成された新しい順に検索する
+func (r *BlogRepository) List(ctx context.Context, cursor *domain.Cursor, limit int) ([]*domain.Blog, error) {
+	op, order := keysetDirection(cursor)
+	cond := "TRUE"
+	args := []interface{}{domain.VisibilityPublic}
+	if cursor != nil {
+		createdAt, err := cursor.Time()
+		if err != nil {
+			return nil, err
+		}
+		cond = "(created_at " + op + " ? OR (created_at = ? AND id " + op + " ?))"
+		args = append(args, createdAt, createdAt, cursor.ID)
+	}
+	args = append(args, limit)
+	blogs := make([]*domain.Blog, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&blogs,
+		`
+			SELECT id, user_id, path, title, description, comments_enabled, created_at, visibility, deleted_at FROM blogs
+				WHERE visibility = ? AND deleted_at IS NULL AND `+cond+`
+				ORDER BY created_at `+order+`, id `+order+` LIMIT ?
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reverseKeyset(cursor, blogs)
+	return blogs, nil
+}
+
+// ListByUserID はリポジトリからユーザーが作成したブログとメンバーになっているブログをタイトルの降順に検索する
+func (r *BlogRepository) ListByUserID(ctx context.Context, userID domain.UserID, cursor *domain.Cursor, limit int) ([]*domain.Blog, error) {
+	op, order := keysetDirection(cursor)
+	cond := "TRUE"
+	args := []interface{}{userID, userID}
+	if cursor != nil {
+		cond = "(title " + op + " ? OR (title = ? AND id " + op + " ?))"
+		args = append(args, cursor.Key, cursor.Key, cursor.ID)
+	}
+	args = append(args, limit)
+	blogs := make([]*domain.Blog, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&blogs,
+		`
+			SELECT id, user_id, path, title, description, comments_enabled, created_at, visibility, deleted_at FROM blogs
+				WHERE (user_id = ? OR id IN (SELECT blog_id FROM blog_members WHERE user_id = ? AND accepted_at IS NOT NULL))
+					AND deleted_at IS NULL AND `+cond+`
+				ORDER BY title `+order+`, id `+order+` LIMIT ?
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reverseKeyset(cursor, blogs)
+	return blogs, nil
+}
+
+// ListTrashedByUserID はリポジトリからユーザーがオーナーのブログのうちゴミ箱にあるものを検索する
+func (r *BlogRepository) ListTrashedByUserID(ctx context.Context, userID domain.UserID, limit int) ([]*domain.Blog, error) {
 	blogs := make([]*domain.Blog, 0, limit)
 	err := sqlx.SelectContext(
 		ctx,
 		r.db,
 		&blogs,
 		`
-			SELECT id, user_id, path, title, description FROM blogs
-				ORDER BY created_at DESC LIMIT ? OFFSET ?
+			SELECT id, user_id, path, title, description, comments_enabled, created_at, visibility, deleted_at FROM blogs
+				WHERE user_id = ? AND deleted_at IS NOT NULL
+				ORDER BY deleted_at DESC, id DESC LIMIT ?
 		`,
-		limit, offset,
+		userID, limit,
 	)
 	if err != nil {
 		return nil, err
@@ -108,19 +221,19 @@ func (r *BlogRepository) List(ctx context.Context, limit, offset int) ([]*domain
 	return blogs, nil
 }
 
-// ListByUserID はリポジトリからユーザーのブログを検索する
-func (r *BlogRepository) ListByUserID(ctx context.Context, userID domain.UserID, limit, offset int) ([]*domain.Blog, error) {
+// ListTrashedBefore はリポジトリから指定した日時より前にゴミ箱に移動したブログを検索する
+func (r *BlogRepository) ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]*domain.Blog, error) {
 	blogs := make([]*domain.Blog, 0, limit)
 	err := sqlx.SelectContext(
 		ctx,
 		r.db,
 		&blogs,
 		`
-			SELECT id, user_id, path, title, description FROM blogs
-				WHERE user_id = ?
-				ORDER BY title DESC LIMIT ? OFFSET ?
+			SELECT id, user_id, path, title, description, comments_enabled, created_at, visibility, deleted_at FROM blogs
+				WHERE deleted_at < ?
+				ORDER BY deleted_at ASC, id ASC LIMIT ?
 		`,
-		userID, limit, offset,
+		before, limit,
 	)
 	if err != nil {
 		return nil, err
@@ -144,6 +257,86 @@ func (r *BlogRepository) Update(ctx context.Context, id domain.BlogID, input *do
 	return r.FindByID(ctx, id)
 }
 
+// UpdatePath はブログのパスを更新する
+func (r *BlogRepository) UpdatePath(ctx context.Context, id domain.BlogID, path string) (*domain.Blog, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE blogs SET path = ?
+				WHERE id = ?
+		`,
+		path, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// UpdateCommentsEnabled はブログでコメントを受け付けるかどうかを更新する
+func (r *BlogRepository) UpdateCommentsEnabled(ctx context.Context, id domain.BlogID, enabled bool) (*domain.Blog, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE blogs SET comments_enabled = ?
+				WHERE id = ?
+		`,
+		enabled, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// UpdateVisibility はブログの公開範囲を更新する
+func (r *BlogRepository) UpdateVisibility(ctx context.Context, id domain.BlogID, visibility domain.Visibility) (*domain.Blog, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE blogs SET visibility = ?
+				WHERE id = ?
+		`,
+		visibility, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// UpdateUserID はブログのオーナーを変更する
+func (r *BlogRepository) UpdateUserID(ctx context.Context, id domain.BlogID, userID domain.UserID) (*domain.Blog, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE blogs SET user_id = ?
+				WHERE id = ?
+		`,
+		userID, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// UpdateDeletedAt はブログをゴミ箱に移動した日時を更新する
+func (r *BlogRepository) UpdateDeletedAt(ctx context.Context, id domain.BlogID, deletedAt *time.Time) (*domain.Blog, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE blogs SET deleted_at = ?
+				WHERE id = ?
+		`,
+		deletedAt, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
 // Delete はブログを削除する
 func (r *BlogRepository) Delete(ctx context.Context, id domain.BlogID) error {
 	_, err := r.db.ExecContext(
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// BlogPathRedirectRepository は domain.BlogPathRedirectRepository に対するデータベースを使った実装
+type BlogPathRedirectRepository struct {
+	db DB
+}
+
+func newBlogPathRedirectRepository(db DB) *BlogPathRedirectRepository {
+	return &BlogPathRedirectRepository{db}
+}
+
+// Create はブログの古いパスを作成し, リポジトリに保存する
+func (r *BlogPathRedirectRepository) Create(ctx context.Context, input *domain.CreateBlogPathRedirectInput) (*domain.BlogPathRedirect, error) {
+	redirect := &domain.BlogPathRedirect{
+		Path:      input.Path,
+		BlogID:    input.BlogID,
+		CreatedAt: input.CreatedAt,
+	}
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO blog_path_redirects (path, blog_id, created_at)
+				VALUES (?, ?, ?)
+		`,
+		redirect.Path, redirect.BlogID, redirect.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return redirect, nil
+}
+
+// FindByPath はリポジトリからブログの古いパスを検索する
+func (r *BlogPathRedirectRepository) FindByPath(ctx context.Context, path string) (*domain.BlogPathRedirect, error) {
+	var redirect domain.BlogPathRedirect
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&redirect,
+		`
+			SELECT path, blog_id, created_at FROM blog_path_redirects
+				WHERE path = ? LIMIT 1
+		`,
+		path,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &redirect, nil
+}
+
+// ListByBlogID はリポジトリからブログの ID で古いパスを検索する
+func (r *BlogPathRedirectRepository) ListByBlogID(ctx context.Context, blogID domain.BlogID) ([]*domain.BlogPathRedirect, error) {
+	redirects := []*domain.BlogPathRedirect{}
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&redirects,
+		`
+			SELECT path, blog_id, created_at FROM blog_path_redirects
+				WHERE blog_id = ?
+				ORDER BY created_at DESC
+		`,
+		blogID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return redirects, nil
+}
+
+// Delete はブログの古いパスをリポジトリから削除する
+func (r *BlogPathRedirectRepository) Delete(ctx context.Context, path string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM blog_path_redirects WHERE path = ?
+		`,
+		path,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべての古いパスを削除する
+func (r *BlogPathRedirectRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM blog_path_redirects WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// CommentRepository は domain.CommentRepository に対するデータベースを使った実装
+type CommentRepository struct {
+	db DB
+}
+
+func newCommentRepository(db DB) *CommentRepository {
+	return &CommentRepository{db}
+}
+
+// Create は新規コメントを作成し, リポジトリに保存する
+func (r *CommentRepository) Create(ctx context.Context, input *domain.CreateCommentInput) (*domain.Comment, error) {
+	id, err := generateID(r.db)
+	if err != nil {
+		return nil, err
+	}
+	comment := &domain.Comment{
+		ID:       domain.CommentID(id),
+		EntryID:  input.EntryID,
+		BlogID:   input.BlogID,
+		UserID:   input.UserID,
+		Body:     input.Body,
+		BodyHTML: input.BodyHTML,
+		Status:   input.Status,
+		PostedAt: input.PostedAt,
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO comments (id, entry_id, blog_id, user_id, body, body_html, status, posted_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+		comment.ID, comment.EntryID, comment.BlogID, comment.UserID, comment.Body, comment.BodyHTML, comment.Status, comment.PostedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// FindByID はリポジトリから ID でコメントを検索する
+func (r *CommentRepository) FindByID(ctx context.Context, id domain.CommentID) (*domain.Comment, error) {
+	var comment domain.Comment
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&comment,
+		`
+			SELECT id, entry_id, blog_id, user_id, body, body_html, status, posted_at FROM comments
+				WHERE id = ? LIMIT 1
+		`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListByEntryID はリポジトリからエントリの ID と状態でコメントを投稿された順に cursor の位置から検索する
+func (r *CommentRepository) ListByEntryID(ctx context.Context, entryID domain.EntryID, status domain.CommentStatus, cursor *domain.Cursor, limit int) ([]*domain.Comment, error) {
+	op, order := ascendingKeysetDirection(cursor)
+	cond := "TRUE"
+	args := []interface{}{entryID, status}
+	if cursor != nil {
+		postedAt, err := cursor.Time()
+		if err != nil {
+			return nil, err
+		}
+		cond = "(posted_at " + op + " ? OR (posted_at = ? AND id " + op + " ?))"
+		args = append(args, postedAt, postedAt, cursor.ID)
+	}
+	args = append(args, limit)
+	comments := make([]*domain.Comment, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&comments,
+		`
+			SELECT id, entry_id, blog_id, user_id, body, body_html, status, posted_at FROM comments
+				WHERE entry_id = ? AND status = ? AND `+cond+`
+				ORDER BY posted_at `+order+`, id `+order+` LIMIT ?
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reverseKeyset(cursor, comments)
+	return comments, nil
+}
+
+// ListByBlogID はリポジトリからブログの ID と状態でコメントを新しい順に検索する
+func (r *CommentRepository) ListByBlogID(ctx context.Context, blogID domain.BlogID, status domain.CommentStatus, limit, offset int) ([]*domain.Comment, error) {
+	comments := make([]*domain.Comment, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&comments,
+		`
+			SELECT id, entry_id, blog_id, user_id, body, body_html, status, posted_at FROM comments
+				WHERE blog_id = ? AND status = ?
+				ORDER BY posted_at DESC, id DESC LIMIT ? OFFSET ?
+		`,
+		blogID, status, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// UpdateStatus はコメントの状態を更新する
+func (r *CommentRepository) UpdateStatus(ctx context.Context, id domain.CommentID, status domain.CommentStatus) (*domain.Comment, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE comments SET status = ?
+				WHERE id = ?
+		`,
+		status, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// Delete はコメントを削除する
+func (r *CommentRepository) Delete(ctx context.Context, id domain.CommentID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM comments WHERE id = ?
+		`,
+		id,
+	)
+	return err
+}
+
+// DeleteByEntryID はエントリに付けられたすべてのコメントを削除する
+func (r *CommentRepository) DeleteByEntryID(ctx context.Context, entryID domain.EntryID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM comments WHERE entry_id = ?
+		`,
+		entryID,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのエントリに付けられたすべてのコメントを削除する
+func (r *CommentRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM comments WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
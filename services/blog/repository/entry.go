@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
@@ -27,19 +28,25 @@ func (r *EntryRepository) Create(ctx context.Context, input *domain.CreateEntryI
 	entry := &domain.Entry{
 		ID:          domain.EntryID(id),
 		BlogID:      input.BlogID,
+		AuthorID:    input.AuthorID,
 		Title:       input.Title,
 		Body:        input.Body,
 		BodyHTML:    input.BodyHTML,
+		Status:      input.Status,
 		PublishedAt: input.PublishedAt,
 		EditedAt:    input.EditedAt,
+		Visibility:  domain.VisibilityPublic,
+		// スラッグは作成した時点ではエントリの ID にしておく
+		Slug:   domain.EntryID(id).String(),
+		Format: input.Format,
 	}
 	_, err = r.db.ExecContext(
 		ctx,
 		`
-			INSERT INTO entries (id, blog_id, title, body, body_html, published_at, edited_at)
-				VALUES (?, ?, ?, ?, ?, ?, ?)
+			INSERT INTO entries (id, blog_id, author_id, title, body, body_html, status, published_at, edited_at, visibility, slug, format)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`,
-		entry.ID, entry.BlogID, entry.Title, entry.Body, entry.BodyHTML, entry.PublishedAt, entry.EditedAt,
+		entry.ID, entry.BlogID, entry.AuthorID, entry.Title, entry.Body, entry.BodyHTML, entry.Status, entry.PublishedAt, entry.EditedAt, entry.Visibility, entry.Slug, entry.Format,
 	)
 	if err != nil {
 		return nil, err
@@ -55,7 +62,7 @@ func (r *EntryRepository) FindByID(ctx context.Context, id domain.EntryID) (*dom
 		r.db,
 		&entry,
 		`
-			SELECT id, blog_id, title, body, body_html, published_at, edited_at FROM entries
+			SELECT id, blog_id, author_id, title, body, body_html, status, published_at, edited_at, visibility, slug, format, deleted_at FROM entries
 				WHERE id = ? LIMIT 1
 		`,
 		id,
@@ -69,19 +76,300 @@ func (r *EntryRepository) FindByID(ctx context.Context, id domain.EntryID) (*dom
 	return &entry, nil
 }
 
-// ListByBlogID はリポジトリからブログの ID でエントリを検索する
-func (r *EntryRepository) ListByBlogID(ctx context.Context, blogID domain.BlogID, limit, offset int) ([]*domain.Entry, error) {
+// FindBySlug はリポジトリからブログの ID とスラッグでエントリを検索する
+func (r *EntryRepository) FindBySlug(ctx context.Context, blogID domain.BlogID, slug string) (*domain.Entry, error) {
+	var entry domain.Entry
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&entry,
+		`
+			SELECT id, blog_id, author_id, title, body, body_html, status, published_at, edited_at, visibility, slug, format, deleted_at FROM entries
+				WHERE blog_id = ? AND slug = ? LIMIT 1
+		`,
+		blogID, slug,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListByIDs はリポジトリから複数の ID でエントリを検索する
+func (r *EntryRepository) ListByIDs(ctx context.Context, ids []domain.EntryID) ([]*domain.Entry, error) {
+	entries := make([]*domain.Entry, 0, len(ids))
+	if len(ids) == 0 {
+		return entries, nil
+	}
+	query, args, err := sqlx.In(
+		`
+			SELECT id, blog_id, author_id, title, body, body_html, status, published_at, edited_at, visibility, slug, format, deleted_at FROM entries
+				WHERE id IN (?) AND deleted_at IS NULL
+		`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.SelectContext(ctx, r.db, &entries, query, args...); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListByBlogID はリポジトリからブログの ID で公開されているエントリを検索する
+// (blog_id, published_at) のインデックスを使って, cursor の位置から読む
+func (r *EntryRepository) ListByBlogID(ctx context.Context, blogID domain.BlogID, listedOnly bool, cursor *domain.Cursor, limit int) ([]*domain.Entry, error) {
+	op, order := keysetDirection(cursor)
+	cond := "TRUE"
+	args := []interface{}{blogID, domain.EntryStatusPublished}
+	if listedOnly {
+		cond = "visibility = ?"
+		args = append(args, domain.VisibilityPublic)
+	}
+	if cursor != nil {
+		publishedAt, err := cursor.Time()
+		if err != nil {
+			return nil, err
+		}
+		cond += " AND (published_at " + op + " ? OR (published_at = ? AND id " + op + " ?))"
+		args = append(args, publishedAt, publishedAt, cursor.ID)
+	}
+	args = append(args, limit)
+	entries := make([]*domain.Entry, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&entries,
+		`
+			SELECT id, blog_id, author_id, title, body, body_html, status, published_at, edited_at, visibility, slug, format, deleted_at FROM entries
+				WHERE blog_id = ? AND status = ? AND published_at <= CURRENT_TIMESTAMP(6) AND deleted_at IS NULL AND `+cond+`
+				ORDER BY published_at `+order+`, id `+order+` LIMIT ?
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reverseKeyset(cursor, entries)
+	return entries, nil
+}
+
+// ListLatestByBlogIDs はリポジトリから複数のブログの ID でそれぞれの最新の公開されているエントリを検索する
+// ブログの数によらず 1 回のクエリで検索する. 一覧に表示するだけなので本文は読まない
+func (r *EntryRepository) ListLatestByBlogIDs(ctx context.Context, blogIDs []domain.BlogID) (map[domain.BlogID]*domain.Entry, error) {
+	latest := make(map[domain.BlogID]*domain.Entry, len(blogIDs))
+	if len(blogIDs) == 0 {
+		return latest, nil
+	}
+	query, args, err := sqlx.In(
+		`
+			SELECT id, blog_id, author_id, title, status, published_at, edited_at, visibility, slug, format, deleted_at FROM (
+				SELECT id, blog_id, author_id, title, status, published_at, edited_at, visibility, slug, format, deleted_at,
+						ROW_NUMBER() OVER (PARTITION BY blog_id ORDER BY published_at DESC, id DESC) AS n
+					FROM entries
+					WHERE blog_id IN (?) AND status = ? AND visibility = ? AND published_at <= CURRENT_TIMESTAMP(6) AND deleted_at IS NULL
+			) AS e
+				WHERE n = 1
+		`,
+		blogIDs, domain.EntryStatusPublished, domain.VisibilityPublic,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*domain.Entry, 0, len(blogIDs))
+	if err := sqlx.SelectContext(ctx, r.db, &entries, query, args...); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		latest[e.BlogID] = e
+	}
+	return latest, nil
+}
+
+// ListPublished はリポジトリからすべてのブログの公開されている, 一覧に載せるエントリを検索する
+func (r *EntryRepository) ListPublished(ctx context.Context, limit, offset int) ([]*domain.Entry, error) {
+	entries := make([]*domain.Entry, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&entries,
+		`
+			SELECT e.id, e.blog_id, e.author_id, e.title, e.body, e.body_html, e.status, e.published_at, e.edited_at, e.visibility, e.slug, e.format, e.deleted_at FROM entries AS e
+				INNER JOIN blogs AS b ON b.id = e.blog_id
+				WHERE e.status = ? AND e.published_at <= CURRENT_TIMESTAMP(6) AND e.deleted_at IS NULL AND b.deleted_at IS NULL
+					AND e.visibility = ? AND b.visibility = ?
+				ORDER BY e.published_at DESC LIMIT ? OFFSET ?
+		`,
+		domain.EntryStatusPublished, domain.VisibilityPublic, domain.VisibilityPublic, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListByBlogIDAndTagName はリポジトリからブログの ID とタグの名前で公開されているエントリを検索する
+func (r *EntryRepository) ListByBlogIDAndTagName(ctx context.Context, blogID domain.BlogID, tagName string, listedOnly bool, limit, offset int) ([]*domain.Entry, error) {
+	cond := "TRUE"
+	args := []interface{}{blogID, tagName, domain.EntryStatusPublished}
+	if listedOnly {
+		cond = "e.visibility = ?"
+		args = append(args, domain.VisibilityPublic)
+	}
+	args = append(args, limit, offset)
+	entries := make([]*domain.Entry, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&entries,
+		`
+			SELECT e.id, e.blog_id, e.author_id, e.title, e.body, e.body_html, e.status, e.published_at, e.edited_at, e.visibility, e.slug, e.format, e.deleted_at FROM entries AS e
+				INNER JOIN entry_tags AS et ON et.entry_id = e.id
+				INNER JOIN tags AS t ON t.id = et.tag_id
+				WHERE e.blog_id = ? AND t.name = ? AND e.status = ? AND e.published_at <= CURRENT_TIMESTAMP(6) AND e.deleted_at IS NULL AND `+cond+`
+				ORDER BY e.published_at DESC LIMIT ? OFFSET ?
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListDraftsByBlogID はリポジトリからブログの ID で下書きと予約投稿, 非公開のエントリを検索する
+func (r *EntryRepository) ListDraftsByBlogID(ctx context.Context, blogID domain.BlogID, limit, offset int) ([]*domain.Entry, error) {
+	entries := make([]*domain.Entry, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&entries,
+		`
+			SELECT id, blog_id, author_id, title, body, body_html, status, published_at, edited_at, visibility, slug, format, deleted_at FROM entries
+				WHERE blog_id = ? AND status IN (?, ?, ?) AND deleted_at IS NULL
+				ORDER BY edited_at DESC LIMIT ? OFFSET ?
+		`,
+		blogID, domain.EntryStatusDraft, domain.EntryStatusScheduled, domain.EntryStatusUnpublished, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListRecentByBlogID はリポジトリからブログの ID で公開されているかによらずエントリを公開日時の新しい順に検索する
+func (r *EntryRepository) ListRecentByBlogID(ctx context.Context, blogID domain.BlogID, cursor *domain.Cursor, limit int) ([]*domain.Entry, error) {
+	op, order := keysetDirection(cursor)
+	cond := "TRUE"
+	args := []interface{}{blogID}
+	if cursor != nil {
+		publishedAt, err := cursor.Time()
+		if err != nil {
+			return nil, err
+		}
+		cond = "(published_at " + op + " ? OR (published_at = ? AND id " + op + " ?))"
+		args = append(args, publishedAt, publishedAt, cursor.ID)
+	}
+	args = append(args, limit)
+	entries := make([]*domain.Entry, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&entries,
+		`
+			SELECT id, blog_id, author_id, title, body, body_html, status, published_at, edited_at, visibility, slug, format, deleted_at FROM entries
+				WHERE blog_id = ? AND deleted_at IS NULL AND `+cond+`
+				ORDER BY published_at `+order+`, id `+order+` LIMIT ?
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reverseKeyset(cursor, entries)
+	return entries, nil
+}
+
+// ListAllByBlogID はリポジトリからブログの ID でエントリを ID の順に afterID より後から検索する
+func (r *EntryRepository) ListAllByBlogID(ctx context.Context, blogID domain.BlogID, afterID domain.EntryID, limit int) ([]*domain.Entry, error) {
+	entries := make([]*domain.Entry, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&entries,
+		`
+			SELECT id, blog_id, author_id, title, body, body_html, status, published_at, edited_at, visibility, slug, format, deleted_at FROM entries
+				WHERE blog_id = ? AND id > ? AND deleted_at IS NULL
+				ORDER BY id ASC LIMIT ?
+		`,
+		blogID, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListDueScheduledForUpdate はリポジトリから公開日時を過ぎた予約投稿のエントリを行ロックを取って検索する
+func (r *EntryRepository) ListDueScheduledForUpdate(ctx context.Context, now time.Time, limit int) ([]*domain.Entry, error) {
+	entries := make([]*domain.Entry, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&entries,
+		`
+			SELECT id, blog_id, author_id, title, body, body_html, status, published_at, edited_at, visibility, slug, format, deleted_at FROM entries
+				WHERE status = ? AND published_at <= ? AND deleted_at IS NULL
+				ORDER BY published_at ASC LIMIT ?
+				FOR UPDATE SKIP LOCKED
+		`,
+		domain.EntryStatusScheduled, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListTrashedByUserID はリポジトリからユーザーがエントリを書けるブログのゴミ箱にあるエントリを検索する
+func (r *EntryRepository) ListTrashedByUserID(ctx context.Context, userID domain.UserID, limit int) ([]*domain.Entry, error) {
+	entries := make([]*domain.Entry, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&entries,
+		`
+			SELECT e.id, e.blog_id, e.author_id, e.title, e.body, e.body_html, e.status, e.published_at, e.edited_at, e.visibility, e.slug, e.format, e.deleted_at FROM entries AS e
+				INNER JOIN blogs AS b ON b.id = e.blog_id
+				WHERE (b.user_id = ? OR b.id IN (SELECT blog_id FROM blog_members WHERE user_id = ? AND accepted_at IS NOT NULL AND role = ?))
+					AND b.deleted_at IS NULL AND e.deleted_at IS NOT NULL
+				ORDER BY e.deleted_at DESC, e.id DESC LIMIT ?
+		`,
+		userID, userID, domain.MemberRoleEditor, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListTrashedBefore はリポジトリから指定した日時より前にゴミ箱に移動したエントリを検索する
+func (r *EntryRepository) ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]*domain.Entry, error) {
 	entries := make([]*domain.Entry, 0, limit)
 	err := sqlx.SelectContext(
 		ctx,
 		r.db,
 		&entries,
 		`
-			SELECT id, blog_id, title, body, body_html, published_at, edited_at FROM entries
-				WHERE blog_id = ?
-				ORDER BY published_at DESC LIMIT ? OFFSET ?
+			SELECT id, blog_id, author_id, title, body, body_html, status, published_at, edited_at, visibility, slug, format, deleted_at FROM entries
+				WHERE deleted_at < ?
+				ORDER BY deleted_at ASC, id ASC LIMIT ?
 		`,
-		blogID, limit, offset,
+		before, limit,
 	)
 	if err != nil {
 		return nil, err
@@ -94,10 +382,62 @@ func (r *EntryRepository) Update(ctx context.Context, id domain.EntryID, input *
 	_, err := r.db.ExecContext(
 		ctx,
 		`
-			UPDATE entries SET title = ?, body = ?, body_html = ?, published_at = ?, edited_at = ?
+			UPDATE entries SET title = ?, body = ?, body_html = ?, status = ?, published_at = ?, edited_at = ?
+				WHERE id = ?
+		`,
+		input.Title, input.Body, input.BodyHTML, input.Status, input.PublishedAt, input.EditedAt, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// UpdateVisibility はエントリの公開範囲を更新する
+func (r *EntryRepository) UpdateVisibility(ctx context.Context, id domain.EntryID, visibility domain.Visibility) (*domain.Entry, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE entries SET visibility = ?
 				WHERE id = ?
 		`,
-		input.Title, input.Body, input.BodyHTML, input.PublishedAt, input.EditedAt, id,
+		visibility, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// UpdateSlug はエントリのスラッグを更新する
+func (r *EntryRepository) UpdateSlug(ctx context.Context, id domain.EntryID, slug string) (*domain.Entry, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE entries SET slug = ?
+				WHERE id = ?
+		`,
+		slug, id,
+	)
+	if err != nil {
+		// 同時に同じスラッグに変更された場合は UNIQUE KEY (blog_id, slug) で失敗する
+		if isDuplicateEntry(err) {
+			return nil, domain.ErrAlreadyExists
+		}
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// UpdateDeletedAt はエントリをゴミ箱に移動した日時を更新する
+func (r *EntryRepository) UpdateDeletedAt(ctx context.Context, id domain.EntryID, deletedAt *time.Time) (*domain.Entry, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE entries SET deleted_at = ?
+				WHERE id = ?
+		`,
+		deletedAt, id,
 	)
 	if err != nil {
 		return nil, err
@@ -116,3 +456,15 @@ func (r *EntryRepository) Delete(ctx context.Context, id domain.EntryID) error {
 	)
 	return err
 }
+
+// DeleteByBlogID はブログのすべてのエントリを削除する
+func (r *EntryRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entries WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
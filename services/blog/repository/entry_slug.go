@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// EntrySlugRedirectRepository は domain.EntrySlugRedirectRepository に対するデータベースを使った実装
+type EntrySlugRedirectRepository struct {
+	db DB
+}
+
+func newEntrySlugRedirectRepository(db DB) *EntrySlugRedirectRepository {
+	return &EntrySlugRedirectRepository{db}
+}
+
+// Create はエントリの古いスラッグを作成し, リポジトリに保存する
+// 他のエントリの古いスラッグとして既に保存されている場合は domain.ErrAlreadyExists を返す
+func (r *EntrySlugRedirectRepository) Create(ctx context.Context, input *domain.CreateEntrySlugRedirectInput) (*domain.EntrySlugRedirect, error) {
+	redirect := &domain.EntrySlugRedirect{
+		BlogID:    input.BlogID,
+		Slug:      input.Slug,
+		EntryID:   input.EntryID,
+		CreatedAt: input.CreatedAt,
+	}
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO entry_slug_redirects (blog_id, slug, entry_id, created_at)
+				VALUES (?, ?, ?, ?)
+		`,
+		redirect.BlogID, redirect.Slug, redirect.EntryID, redirect.CreatedAt,
+	)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return nil, domain.ErrAlreadyExists
+		}
+		return nil, err
+	}
+	return redirect, nil
+}
+
+// FindBySlug はリポジトリからブログの ID とスラッグでエントリの古いスラッグを検索する
+func (r *EntrySlugRedirectRepository) FindBySlug(ctx context.Context, blogID domain.BlogID, slug string) (*domain.EntrySlugRedirect, error) {
+	var redirect domain.EntrySlugRedirect
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&redirect,
+		`
+			SELECT blog_id, slug, entry_id, created_at FROM entry_slug_redirects
+				WHERE blog_id = ? AND slug = ? LIMIT 1
+		`,
+		blogID, slug,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &redirect, nil
+}
+
+// Delete はエントリの古いスラッグをリポジトリから削除する
+func (r *EntrySlugRedirectRepository) Delete(ctx context.Context, blogID domain.BlogID, slug string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_slug_redirects WHERE blog_id = ? AND slug = ?
+		`,
+		blogID, slug,
+	)
+	return err
+}
+
+// DeleteByEntryID はエントリのすべての古いスラッグを削除する
+func (r *EntrySlugRedirectRepository) DeleteByEntryID(ctx context.Context, entryID domain.EntryID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_slug_redirects WHERE entry_id = ?
+		`,
+		entryID,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべてのエントリの古いスラッグを削除する
+func (r *EntrySlugRedirectRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_slug_redirects WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
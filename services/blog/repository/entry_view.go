@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// EntryViewRepository は domain.EntryViewRepository に対するデータベースを使った実装
+type EntryViewRepository struct {
+	db DB
+}
+
+func newEntryViewRepository(db DB) *EntryViewRepository {
+	return &EntryViewRepository{db}
+}
+
+// Add はエントリの日ごとの閲覧数に加える. まだその日の閲覧数がない場合は作成する
+func (r *EntryViewRepository) Add(ctx context.Context, inputs []*domain.AddEntryViewsInput) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(inputs))
+	args := make([]interface{}, 0, len(inputs)*4)
+	for _, input := range inputs {
+		placeholders = append(placeholders, "(?, ?, ?, ?)")
+		args = append(args, input.EntryID, input.BlogID, input.Day, input.Count)
+	}
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO entry_views (entry_id, blog_id, day, count)
+				VALUES `+strings.Join(placeholders, ", ")+` AS new
+				ON DUPLICATE KEY UPDATE count = entry_views.count + new.count
+		`,
+		args...,
+	)
+	return err
+}
+
+// CountByEntryIDs はリポジトリから複数のエントリのこれまでの閲覧数をまとめて検索する
+func (r *EntryViewRepository) CountByEntryIDs(ctx context.Context, entryIDs []domain.EntryID) (map[domain.EntryID]int64, error) {
+	countsByEntryID := make(map[domain.EntryID]int64, len(entryIDs))
+	if len(entryIDs) == 0 {
+		return countsByEntryID, nil
+	}
+	query, args, err := sqlx.In(
+		`
+			SELECT entry_id, SUM(count) AS count FROM entry_views
+				WHERE entry_id IN (?)
+				GROUP BY entry_id
+		`,
+		entryIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]*domain.EntryViewCount, 0, len(entryIDs))
+	if err := sqlx.SelectContext(ctx, r.db, &counts, query, args...); err != nil {
+		return nil, err
+	}
+	for _, c := range counts {
+		countsByEntryID[c.EntryID] = c.Count
+	}
+	return countsByEntryID, nil
+}
+
+// ListPopularByBlogID はリポジトリからブログの公開されているエントリを since の日からの閲覧数の多い順に検索する
+// (blog_id, day) のインデックスを使って, 期間内の閲覧数だけを読む
+func (r *EntryViewRepository) ListPopularByBlogID(ctx context.Context, blogID domain.BlogID, since time.Time, listedOnly bool, limit int) ([]*domain.EntryViewCount, error) {
+	cond := "TRUE"
+	args := []interface{}{blogID, domain.EntryViewDay(since), domain.EntryStatusPublished}
+	if listedOnly {
+		cond = "e.visibility = ?"
+		args = append(args, domain.VisibilityPublic)
+	}
+	args = append(args, limit)
+	counts := make([]*domain.EntryViewCount, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&counts,
+		`
+			SELECT v.entry_id, SUM(v.count) AS count FROM entry_views AS v
+				INNER JOIN entries AS e ON e.id = v.entry_id
+				WHERE v.blog_id = ? AND v.day >= ?
+					AND e.status = ? AND e.published_at <= CURRENT_TIMESTAMP(6) AND e.deleted_at IS NULL AND `+cond+`
+				GROUP BY v.entry_id
+				ORDER BY count DESC, v.entry_id DESC LIMIT ?
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ListPopular はリポジトリからすべてのブログの公開されているエントリを since の日からの閲覧数の多い順に検索する
+func (r *EntryViewRepository) ListPopular(ctx context.Context, since time.Time, limit int) ([]*domain.EntryViewCount, error) {
+	counts := make([]*domain.EntryViewCount, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&counts,
+		`
+			SELECT v.entry_id, SUM(v.count) AS count FROM entry_views AS v
+				INNER JOIN entries AS e ON e.id = v.entry_id
+				INNER JOIN blogs AS b ON b.id = v.blog_id
+				WHERE v.day >= ?
+					AND e.status = ? AND e.published_at <= CURRENT_TIMESTAMP(6) AND e.deleted_at IS NULL AND b.deleted_at IS NULL
+					AND e.visibility = ? AND b.visibility = ?
+				GROUP BY v.entry_id
+				ORDER BY count DESC, v.entry_id DESC LIMIT ?
+		`,
+		domain.EntryViewDay(since), domain.EntryStatusPublished, domain.VisibilityPublic, domain.VisibilityPublic, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// DeleteByEntryID はエントリの閲覧数を削除する
+func (r *EntryViewRepository) DeleteByEntryID(ctx context.Context, entryID domain.EntryID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_views WHERE entry_id = ?
+		`,
+		entryID,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべてのエントリの閲覧数を削除する
+func (r *EntryViewRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_views WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// EventRepository は domain.EventRepository に対するデータベースを使った実装
+type EventRepository struct {
+	db DB
+}
+
+func newEventRepository(db DB) *EventRepository {
+	return &EventRepository{db}
+}
+
+// Create は新規ドメインイベントを作成し, アウトボックスに保存する. 作成した日時にすぐ購読者に渡すようにする
+func (r *EventRepository) Create(ctx context.Context, input *domain.CreateEventInput) (*domain.Event, error) {
+	id, err := generateID(r.db)
+	if err != nil {
+		return nil, err
+	}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	nextAttemptAt := input.OccurredAt
+	event := &domain.Event{
+		ID:            domain.EventID(id),
+		Type:          input.Type,
+		Payload:       input.Payload,
+		OccurredAt:    input.OccurredAt,
+		TraceParent:   carrier.Get("traceparent"),
+		NextAttemptAt: &nextAttemptAt,
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO events (id, type, payload, occurred_at, trace_parent, next_attempt_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+		`,
+		event.ID, event.Type, event.Payload, event.OccurredAt, event.TraceParent, event.NextAttemptAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// findByID はアウトボックスから ID でドメインイベントを検索する
+func (r *EventRepository) findByID(ctx context.Context, id domain.EventID) (*domain.Event, error) {
+	var event domain.Event
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&event,
+		`
+			SELECT id, type, payload, occurred_at, trace_parent, attempts, error, next_attempt_at, dispatched_at FROM events
+				WHERE id = ? LIMIT 1
+		`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// ListDueForUpdate はアウトボックスから次に購読者に渡す日時を過ぎたドメインイベントを行ロックを取って検索する
+func (r *EventRepository) ListDueForUpdate(ctx context.Context, now time.Time, limit int) ([]*domain.Event, error) {
+	events := make([]*domain.Event, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&events,
+		`
+			SELECT id, type, payload, occurred_at, trace_parent, attempts, error, next_attempt_at, dispatched_at FROM events
+				WHERE dispatched_at IS NULL AND next_attempt_at <= ?
+				ORDER BY next_attempt_at ASC, occurred_at ASC, id ASC LIMIT ?
+				FOR UPDATE SKIP LOCKED
+		`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Update はドメインイベントを購読者に渡した結果を更新する
+func (r *EventRepository) Update(ctx context.Context, id domain.EventID, input *domain.UpdateEventInput) (*domain.Event, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE events SET attempts = ?, error = ?, next_attempt_at = ?, dispatched_at = ?
+				WHERE id = ?
+		`,
+		input.Attempts, input.Error, input.NextAttemptAt, input.DispatchedAt, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.findByID(ctx, id)
+}
+
+// DeleteDispatchedBefore は指定した日時より前に処理し終わったか諦めたドメインイベントを古い順に削除する
+func (r *EventRepository) DeleteDispatchedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM events WHERE dispatched_at < ?
+				ORDER BY dispatched_at ASC LIMIT ?
+		`,
+		before, limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
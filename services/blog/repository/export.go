@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// BlogExportRepository は domain.BlogExportRepository に対するデータベースを使った実装
+type BlogExportRepository struct {
+	db DB
+}
+
+func newBlogExportRepository(db DB) *BlogExportRepository {
+	return &BlogExportRepository{db}
+}
+
+// Create は新規の書き出しを作成し, リポジトリに保存する
+func (r *BlogExportRepository) Create(ctx context.Context, input *domain.CreateBlogExportInput) (*domain.BlogExport, error) {
+	id, err := generateID(r.db)
+	if err != nil {
+		return nil, err
+	}
+	export := &domain.BlogExport{
+		ID:          domain.BlogExportID(id),
+		BlogID:      input.BlogID,
+		RequestedBy: input.RequestedBy,
+		Status:      domain.BlogExportStatusPending,
+		CreatedAt:   input.CreatedAt,
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO blog_exports (id, blog_id, requested_by, status, created_at)
+				VALUES (?, ?, ?, ?, ?)
+		`,
+		export.ID, export.BlogID, export.RequestedBy, export.Status, export.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// FindByID はリポジトリから ID で書き出しを検索する
+func (r *BlogExportRepository) FindByID(ctx context.Context, id domain.BlogExportID) (*domain.BlogExport, error) {
+	var export domain.BlogExport
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&export,
+		`
+			SELECT id, blog_id, requested_by, status, size, error, created_at, started_at, completed_at FROM blog_exports
+				WHERE id = ? LIMIT 1
+		`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ListByBlogID はリポジトリからブログの ID で書き出しを新しい順に検索する
+func (r *BlogExportRepository) ListByBlogID(ctx context.Context, blogID domain.BlogID) ([]*domain.BlogExport, error) {
+	exports := []*domain.BlogExport{}
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&exports,
+		`
+			SELECT id, blog_id, requested_by, status, size, error, created_at, started_at, completed_at FROM blog_exports
+				WHERE blog_id = ?
+				ORDER BY created_at DESC, id DESC
+		`,
+		blogID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// ListRunnableForUpdate はリポジトリから書き出しを始めるべきものを行ロックを取って検索する
+func (r *BlogExportRepository) ListRunnableForUpdate(ctx context.Context, staleBefore time.Time, limit int) ([]*domain.BlogExport, error) {
+	exports := make([]*domain.BlogExport, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&exports,
+		`
+			SELECT id, blog_id, requested_by, status, size, error, created_at, started_at, completed_at FROM blog_exports
+				WHERE status = ? OR (status = ? AND started_at < ?)
+				ORDER BY created_at ASC LIMIT ?
+				FOR UPDATE SKIP LOCKED
+		`,
+		domain.BlogExportStatusPending, domain.BlogExportStatusRunning, staleBefore, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// ListCreatedBefore はリポジトリから指定した日時より前に作成した書き出しを検索する
+func (r *BlogExportRepository) ListCreatedBefore(ctx context.Context, before time.Time, limit int) ([]*domain.BlogExport, error) {
+	exports := make([]*domain.BlogExport, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&exports,
+		`
+			SELECT id, blog_id, requested_by, status, size, error, created_at, started_at, completed_at FROM blog_exports
+				WHERE created_at < ?
+				ORDER BY created_at ASC LIMIT ?
+		`,
+		before, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// Update は書き出しの状態を更新する
+func (r *BlogExportRepository) Update(ctx context.Context, id domain.BlogExportID, input *domain.UpdateBlogExportInput) (*domain.BlogExport, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE blog_exports SET status = ?, size = ?, error = ?, started_at = ?, completed_at = ?
+				WHERE id = ?
+		`,
+		input.Status, input.Size, input.Error, input.StartedAt, input.CompletedAt, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// Delete は書き出しをリポジトリから削除する
+func (r *BlogExportRepository) Delete(ctx context.Context, id domain.BlogExportID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM blog_exports WHERE id = ?
+		`,
+		id,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべての書き出しを削除する
+func (r *BlogExportRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM blog_exports WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
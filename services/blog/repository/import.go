@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// EntryImportRepository は domain.EntryImportRepository に対するデータベースを使った実装
+type EntryImportRepository struct {
+	db DB
+}
+
+func newEntryImportRepository(db DB) *EntryImportRepository {
+	return &EntryImportRepository{db}
+}
+
+// Create は取り込み元との対応を作成し, リポジトリに保存する
+func (r *EntryImportRepository) Create(ctx context.Context, input *domain.CreateEntryImportInput) (*domain.EntryImport, error) {
+	entryImport := &domain.EntryImport{
+		BlogID:    input.BlogID,
+		SourceKey: input.SourceKey,
+		EntryID:   input.EntryID,
+		CreatedAt: input.CreatedAt,
+	}
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO entry_imports (blog_id, source_key, entry_id, created_at)
+				VALUES (?, ?, ?, ?)
+		`,
+		entryImport.BlogID, entryImport.SourceKey, entryImport.EntryID, entryImport.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entryImport, nil
+}
+
+// FindBySourceKey はリポジトリからブログの ID と取り込み元での識別子で対応を検索する
+func (r *EntryImportRepository) FindBySourceKey(ctx context.Context, blogID domain.BlogID, sourceKey string) (*domain.EntryImport, error) {
+	var entryImport domain.EntryImport
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&entryImport,
+		`
+			SELECT blog_id, source_key, entry_id, created_at FROM entry_imports
+				WHERE blog_id = ? AND source_key = ? LIMIT 1
+		`,
+		blogID, sourceKey,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &entryImport, nil
+}
+
+// DeleteByEntryID はエントリの取り込み元との対応を削除する
+func (r *EntryImportRepository) DeleteByEntryID(ctx context.Context, entryID domain.EntryID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_imports WHERE entry_id = ?
+		`,
+		entryID,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべての取り込み元との対応を削除する
+func (r *EntryImportRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_imports WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"slices"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// keysetDirection は降順に並ぶ一覧を cursor の位置から読むための比較演算子と並び順を返す
+// 前のページを読む場合は cursor に近い方から昇順で読むので, 読んだ後に reverseKeyset で降順に戻す
+func keysetDirection(cursor *domain.Cursor) (op string, order string) {
+	if cursor != nil && cursor.Backward {
+		return ">", "ASC"
+	}
+	return "<", "DESC"
+}
+
+// ascendingKeysetDirection は昇順に並ぶ一覧を cursor の位置から読むための比較演算子と並び順を返す
+// 前のページを読む場合は cursor に近い方から降順で読むので, 読んだ後に reverseKeyset で昇順に戻す
+func ascendingKeysetDirection(cursor *domain.Cursor) (op string, order string) {
+	if cursor != nil && cursor.Backward {
+		return "<", "DESC"
+	}
+	return ">", "ASC"
+}
+
+// reverseKeyset は前のページを読んだ場合に一覧を元の並び順に戻す
+func reverseKeyset[T any](cursor *domain.Cursor, items []T) {
+	if cursor != nil && cursor.Backward {
+		slices.Reverse(items)
+	}
+}
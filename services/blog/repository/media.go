@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// MediaRepository は domain.MediaRepository に対するデータベースを使った実装
+type MediaRepository struct {
+	db DB
+}
+
+func newMediaRepository(db DB) *MediaRepository {
+	return &MediaRepository{db}
+}
+
+// Create は新規メディアを作成し, リポジトリに保存する
+func (r *MediaRepository) Create(ctx context.Context, input *domain.CreateMediaInput) (*domain.Media, error) {
+	id, err := generateID(r.db)
+	if err != nil {
+		return nil, err
+	}
+	media := &domain.Media{
+		ID:          domain.MediaID(id),
+		BlogID:      input.BlogID,
+		UploadedBy:  input.UploadedBy,
+		Filename:    input.Filename,
+		ContentType: input.ContentType,
+		Size:        input.Size,
+		Width:       input.Width,
+		Height:      input.Height,
+		CreatedAt:   input.CreatedAt,
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO media (id, blog_id, uploaded_by, filename, content_type, size, width, height, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+		media.ID, media.BlogID, media.UploadedBy, media.Filename, media.ContentType, media.Size, media.Width, media.Height, media.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// FindByID はリポジトリから ID でメディアを検索する
+func (r *MediaRepository) FindByID(ctx context.Context, id domain.MediaID) (*domain.Media, error) {
+	var media domain.Media
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&media,
+		`
+			SELECT id, blog_id, uploaded_by, filename, content_type, size, width, height, created_at FROM media
+				WHERE id = ? LIMIT 1
+		`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &media, nil
+}
+
+// ListByIDs はリポジトリから複数の ID でメディアを検索する
+func (r *MediaRepository) ListByIDs(ctx context.Context, ids []domain.MediaID) ([]*domain.Media, error) {
+	media := make([]*domain.Media, 0, len(ids))
+	if len(ids) == 0 {
+		return media, nil
+	}
+	query, args, err := sqlx.In(
+		`
+			SELECT id, blog_id, uploaded_by, filename, content_type, size, width, height, created_at FROM media
+				WHERE id IN (?)
+		`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.SelectContext(ctx, r.db, &media, query, args...); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// ListByBlogID はリポジトリからブログの ID でメディアをアップロードされた新しい順に検索する
+func (r *MediaRepository) ListByBlogID(ctx context.Context, blogID domain.BlogID, cursor *domain.Cursor, limit int) ([]*domain.Media, error) {
+	op, order := keysetDirection(cursor)
+	cond := "TRUE"
+	args := []interface{}{blogID}
+	if cursor != nil {
+		createdAt, err := cursor.Time()
+		if err != nil {
+			return nil, err
+		}
+		cond = "(created_at " + op + " ? OR (created_at = ? AND id " + op + " ?))"
+		args = append(args, createdAt, createdAt, cursor.ID)
+	}
+	args = append(args, limit)
+	media := make([]*domain.Media, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&media,
+		`
+			SELECT id, blog_id, uploaded_by, filename, content_type, size, width, height, created_at FROM media
+				WHERE blog_id = ? AND `+cond+`
+				ORDER BY created_at `+order+`, id `+order+` LIMIT ?
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reverseKeyset(cursor, media)
+	return media, nil
+}
+
+// Delete はメディアをリポジトリから削除する
+func (r *MediaRepository) Delete(ctx context.Context, id domain.MediaID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM media WHERE id = ?
+		`,
+		id,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべてのメディアを削除する
+func (r *MediaRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM media WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// MemberRepository は domain.MemberRepository に対するデータベースを使った実装
+type MemberRepository struct {
+	db DB
+}
+
+func newMemberRepository(db DB) *MemberRepository {
+	return &MemberRepository{db}
+}
+
+// Create は新規メンバーを招待を承諾していない状態で作成し, リポジトリに保存する
+func (r *MemberRepository) Create(ctx context.Context, input *domain.CreateMemberInput) (*domain.Member, error) {
+	member := &domain.Member{
+		BlogID:    input.BlogID,
+		UserID:    input.UserID,
+		Role:      input.Role,
+		InvitedBy: input.InvitedBy,
+		InvitedAt: input.InvitedAt,
+	}
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO blog_members (blog_id, user_id, role, invited_by, invited_at)
+				VALUES (?, ?, ?, ?, ?)
+		`,
+		member.BlogID, member.UserID, member.Role, member.InvitedBy, member.InvitedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// Find はリポジトリからブログの ID とユーザーの ID でメンバーを検索する
+func (r *MemberRepository) Find(ctx context.Context, blogID domain.BlogID, userID domain.UserID) (*domain.Member, error) {
+	var member domain.Member
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&member,
+		`
+			SELECT blog_id, user_id, role, invited_by, invited_at, accepted_at FROM blog_members
+				WHERE blog_id = ? AND user_id = ? LIMIT 1
+		`,
+		blogID, userID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListByBlogID はリポジトリからブログのメンバーを検索する
+func (r *MemberRepository) ListByBlogID(ctx context.Context, blogID domain.BlogID) ([]*domain.Member, error) {
+	members := []*domain.Member{}
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&members,
+		`
+			SELECT blog_id, user_id, role, invited_by, invited_at, accepted_at FROM blog_members
+				WHERE blog_id = ?
+				ORDER BY invited_at ASC, user_id ASC
+		`,
+		blogID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ListInvitationsByUserID はリポジトリからユーザーがまだ承諾していない招待を検索する
+func (r *MemberRepository) ListInvitationsByUserID(ctx context.Context, userID domain.UserID) ([]*domain.Member, error) {
+	members := []*domain.Member{}
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&members,
+		`
+			SELECT blog_id, user_id, role, invited_by, invited_at, accepted_at FROM blog_members
+				WHERE user_id = ? AND accepted_at IS NULL
+				ORDER BY invited_at DESC, blog_id DESC
+		`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// UpdateRole はメンバーの役割を更新する
+func (r *MemberRepository) UpdateRole(ctx context.Context, blogID domain.BlogID, userID domain.UserID, role domain.MemberRole) (*domain.Member, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE blog_members SET role = ?
+				WHERE blog_id = ? AND user_id = ?
+		`,
+		role, blogID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.Find(ctx, blogID, userID)
+}
+
+// UpdateAcceptedAt はメンバーが招待を承諾した日時を更新する
+func (r *MemberRepository) UpdateAcceptedAt(ctx context.Context, blogID domain.BlogID, userID domain.UserID, acceptedAt time.Time) (*domain.Member, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE blog_members SET accepted_at = ?
+				WHERE blog_id = ? AND user_id = ?
+		`,
+		acceptedAt, blogID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.Find(ctx, blogID, userID)
+}
+
+// Delete はメンバーをリポジトリから削除する
+func (r *MemberRepository) Delete(ctx context.Context, blogID domain.BlogID, userID domain.UserID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM blog_members WHERE blog_id = ? AND user_id = ?
+		`,
+		blogID, userID,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべてのメンバーを削除する
+func (r *MemberRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM blog_members WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
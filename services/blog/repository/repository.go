@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
 )
@@ -15,19 +18,53 @@ type DB interface {
 
 // Repository は domain.Repository に対するデータベースを使った実装
 type Repository struct {
-	user    *UserRepository
-	session *SessionRepository
-	blog    *BlogRepository
-	entry   *EntryRepository
+	user            *UserRepository
+	session         *SessionRepository
+	blog            *BlogRepository
+	entry           *EntryRepository
+	entryRevision   *EntryRevisionRepository
+	tag             *TagRepository
+	entryView       *EntryViewRepository
+	star            *StarRepository
+	comment         *CommentRepository
+	member          *MemberRepository
+	shareLink       *ShareLinkRepository
+	blogPath        *BlogPathRedirectRepository
+	entrySlug       *EntrySlugRedirectRepository
+	media           *MediaRepository
+	entryImport     *EntryImportRepository
+	blogExport      *BlogExportRepository
+	apiKey          *APIKeyRepository
+	accessToken     *AccessTokenRepository
+	webhook         *WebhookRepository
+	webhookDelivery *WebhookDeliveryRepository
+	event           *EventRepository
 }
 
 // NewRepository は Repository を作成する
 func NewRepository(db DB) *Repository {
 	return &Repository{
-		user:    newUserRepository(db),
-		session: newSessionRepository(db),
-		blog:    newBlogRepository(db),
-		entry:   newEntryRepository(db),
+		user:            newUserRepository(db),
+		session:         newSessionRepository(db),
+		blog:            newBlogRepository(db),
+		entry:           newEntryRepository(db),
+		entryRevision:   newEntryRevisionRepository(db),
+		tag:             newTagRepository(db),
+		entryView:       newEntryViewRepository(db),
+		star:            newStarRepository(db),
+		comment:         newCommentRepository(db),
+		member:          newMemberRepository(db),
+		shareLink:       newShareLinkRepository(db),
+		blogPath:        newBlogPathRedirectRepository(db),
+		entrySlug:       newEntrySlugRedirectRepository(db),
+		media:           newMediaRepository(db),
+		entryImport:     newEntryImportRepository(db),
+		blogExport:      newBlogExportRepository(db),
+		apiKey:          newAPIKeyRepository(db),
+		accessToken:     newAccessTokenRepository(db),
+		webhook:         newWebhookRepository(db),
+		webhookDelivery: newWebhookDeliveryRepository(db),
+		event:           newEventRepository(db),
 	}
 }
 
@@ -51,8 +88,102 @@ func (r *Repository) Entry() domain.EntryRepository {
 	return r.entry
 }
 
+// EntryRevision はエントリのリビジョンに対するリポジトリを返す
+func (r *Repository) EntryRevision() domain.EntryRevisionRepository {
+	return r.entryRevision
+}
+
+// Tag はタグに対するリポジトリを返す
+func (r *Repository) Tag() domain.TagRepository {
+	return r.tag
+}
+
+// EntryView はエントリの閲覧数に対するリポジトリを返す
+func (r *Repository) EntryView() domain.EntryViewRepository {
+	return r.entryView
+}
+
+// Star はエントリのスターに対するリポジトリを返す
+func (r *Repository) Star() domain.StarRepository {
+	return r.star
+}
+
+// Comment はコメントに対するリポジトリを返す
+func (r *Repository) Comment() domain.CommentRepository {
+	return r.comment
+}
+
+// Member はブログのメンバーに対するリポジトリを返す
+func (r *Repository) Member() domain.MemberRepository {
+	return r.member
+}
+
+// mysqlErrDupEntry は UNIQUE KEY や PRIMARY KEY と重複する行を書き込もうとしたときの MySQL のエラー番号
+const mysqlErrDupEntry = 1062
+
+// isDuplicateEntry は err が重複する行を書き込もうとしたことによるエラーかを判定する
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDupEntry
+}
+
 func generateID(db DB) (uint64, error) {
 	var id uint64
 	err := sqlx.Get(db, &id, "SELECT UUID_SHORT()")
 	return id, err
 }
+
+// ShareLink はエントリの共有リンクに対するリポジトリを返す
+func (r *Repository) ShareLink() domain.ShareLinkRepository {
+	return r.shareLink
+}
+
+// BlogPathRedirect はブログの古いパスに対するリポジトリを返す
+func (r *Repository) BlogPathRedirect() domain.BlogPathRedirectRepository {
+	return r.blogPath
+}
+
+// EntrySlugRedirect はエントリの古いスラッグに対するリポジトリを返す
+func (r *Repository) EntrySlugRedirect() domain.EntrySlugRedirectRepository {
+	return r.entrySlug
+}
+
+// Media はメディアに対するリポジトリを返す
+func (r *Repository) Media() domain.MediaRepository {
+	return r.media
+}
+
+// EntryImport はエントリの取り込み元との対応に対するリポジトリを返す
+func (r *Repository) EntryImport() domain.EntryImportRepository {
+	return r.entryImport
+}
+
+// BlogExport はブログの書き出しに対するリポジトリを返す
+func (r *Repository) BlogExport() domain.BlogExportRepository {
+	return r.blogExport
+}
+
+// APIKey はユーザーの API キーに対するリポジトリを返す
+func (r *Repository) APIKey() domain.APIKeyRepository {
+	return r.apiKey
+}
+
+// AccessToken はパーソナルアクセストークンに対するリポジトリを返す
+func (r *Repository) AccessToken() domain.AccessTokenRepository {
+	return r.accessToken
+}
+
+// Webhook はブログの Webhook に対するリポジトリを返す
+func (r *Repository) Webhook() domain.WebhookRepository {
+	return r.webhook
+}
+
+// WebhookDelivery は Webhook の配信に対するリポジトリを返す
+func (r *Repository) WebhookDelivery() domain.WebhookDeliveryRepository {
+	return r.webhookDelivery
+}
+
+// Event はドメインイベントのアウトボックスに対するリポジトリを返す
+func (r *Repository) Event() domain.EventRepository {
+	return r.event
+}
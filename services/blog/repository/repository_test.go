@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsDuplicateEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"duplicate entry", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-a' for key 'entries.blog_id'"}, true},
+		{"wrapped", fmt.Errorf("update slug: %w", &mysql.MySQLError{Number: 1062}), true},
+		{"deadlock", &mysql.MySQLError{Number: 1213}, false},
+		{"no rows", sql.ErrNoRows, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		if got := isDuplicateEntry(tt.err); got != tt.want {
+			t.Errorf("%s: isDuplicateEntry(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
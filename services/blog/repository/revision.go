@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// EntryRevisionRepository は domain.EntryRevisionRepository に対するデータベースを使った実装
+type EntryRevisionRepository struct {
+	db DB
+}
+
+func newEntryRevisionRepository(db DB) *EntryRevisionRepository {
+	return &EntryRevisionRepository{db}
+}
+
+// Create は新規リビジョンを作成し, リポジトリに保存する
+func (r *EntryRevisionRepository) Create(ctx context.Context, input *domain.CreateEntryRevisionInput) (*domain.EntryRevision, error) {
+	id, err := generateID(r.db)
+	if err != nil {
+		return nil, err
+	}
+	revision := &domain.EntryRevision{
+		ID:       domain.EntryRevisionID(id),
+		EntryID:  input.EntryID,
+		Title:    input.Title,
+		Body:     input.Body,
+		EditedAt: input.EditedAt,
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO entry_revisions (id, entry_id, title, body, edited_at)
+				VALUES (?, ?, ?, ?, ?)
+		`,
+		revision.ID, revision.EntryID, revision.Title, revision.Body, revision.EditedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// FindByID はリポジトリから ID でリビジョンを検索する
+func (r *EntryRevisionRepository) FindByID(ctx context.Context, id domain.EntryRevisionID) (*domain.EntryRevision, error) {
+	var revision domain.EntryRevision
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&revision,
+		`
+			SELECT id, entry_id, title, body, edited_at FROM entry_revisions
+				WHERE id = ? LIMIT 1
+		`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// ListByEntryID はリポジトリからエントリの ID でリビジョンを新しい順に検索する
+func (r *EntryRevisionRepository) ListByEntryID(ctx context.Context, entryID domain.EntryID, limit, offset int) ([]*domain.EntryRevision, error) {
+	revisions := make([]*domain.EntryRevision, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&revisions,
+		`
+			SELECT id, entry_id, title, body, edited_at FROM entry_revisions
+				WHERE entry_id = ?
+				ORDER BY edited_at DESC, id DESC LIMIT ? OFFSET ?
+		`,
+		entryID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// DeleteByEntryID はエントリのすべてのリビジョンを削除する
+func (r *EntryRevisionRepository) DeleteByEntryID(ctx context.Context, entryID domain.EntryID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_revisions WHERE entry_id = ?
+		`,
+		entryID,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべてのエントリのリビジョンを削除する
+func (r *EntryRevisionRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE entry_revisions FROM entry_revisions
+				INNER JOIN entries ON entries.id = entry_revisions.entry_id
+				WHERE entries.blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
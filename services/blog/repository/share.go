@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// ShareLinkRepository は domain.ShareLinkRepository に対するデータベースを使った実装
+type ShareLinkRepository struct {
+	db DB
+}
+
+func newShareLinkRepository(db DB) *ShareLinkRepository {
+	return &ShareLinkRepository{db}
+}
+
+// Create は新規共有リンクを作成し, リポジトリに保存する
+func (r *ShareLinkRepository) Create(ctx context.Context, input *domain.CreateShareLinkInput) (*domain.ShareLink, error) {
+	id, err := generateID(r.db)
+	if err != nil {
+		return nil, err
+	}
+	link := &domain.ShareLink{
+		ID:        domain.ShareLinkID(id),
+		EntryID:   input.EntryID,
+		CreatedBy: input.CreatedBy,
+		ExpiresAt: input.ExpiresAt,
+		CreatedAt: input.CreatedAt,
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO share_links (id, entry_id, created_by, expires_at, created_at)
+				VALUES (?, ?, ?, ?, ?)
+		`,
+		link.ID, link.EntryID, link.CreatedBy, link.ExpiresAt, link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// FindByID はリポジトリから ID で共有リンクを検索する
+func (r *ShareLinkRepository) FindByID(ctx context.Context, id domain.ShareLinkID) (*domain.ShareLink, error) {
+	var link domain.ShareLink
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&link,
+		`
+			SELECT id, entry_id, created_by, expires_at, created_at FROM share_links
+				WHERE id = ? LIMIT 1
+		`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ListByEntryID はリポジトリからエントリの ID で共有リンクを検索する
+func (r *ShareLinkRepository) ListByEntryID(ctx context.Context, entryID domain.EntryID) ([]*domain.ShareLink, error) {
+	links := []*domain.ShareLink{}
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&links,
+		`
+			SELECT id, entry_id, created_by, expires_at, created_at FROM share_links
+				WHERE entry_id = ?
+				ORDER BY created_at DESC, id DESC
+		`,
+		entryID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// Delete は共有リンクをリポジトリから削除する
+func (r *ShareLinkRepository) Delete(ctx context.Context, id domain.ShareLinkID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM share_links WHERE id = ?
+		`,
+		id,
+	)
+	return err
+}
+
+// DeleteByEntryID はエントリのすべての共有リンクを削除する
+func (r *ShareLinkRepository) DeleteByEntryID(ctx context.Context, entryID domain.EntryID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM share_links WHERE entry_id = ?
+		`,
+		entryID,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべてのエントリの共有リンクを削除する
+func (r *ShareLinkRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE share_links FROM share_links
+				INNER JOIN entries ON entries.id = share_links.entry_id
+				WHERE entries.blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
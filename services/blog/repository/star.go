@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// StarRepository は domain.StarRepository に対するデータベースを使った実装
+type StarRepository struct {
+	db DB
+}
+
+func newStarRepository(db DB) *StarRepository {
+	return &StarRepository{db}
+}
+
+// Create は新規スターを作成する
+// 同時に付けられても重複しないよう, 主キーが重複する場合は ErrAlreadyExists を返す
+func (r *StarRepository) Create(ctx context.Context, input *domain.CreateStarInput) (*domain.Star, error) {
+	star := &domain.Star{
+		UserID:    input.UserID,
+		EntryID:   input.EntryID,
+		BlogID:    input.BlogID,
+		CreatedAt: input.CreatedAt,
+	}
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO stars (user_id, entry_id, blog_id, created_at)
+				VALUES (?, ?, ?, ?)
+		`,
+		star.UserID, star.EntryID, star.BlogID, star.CreatedAt,
+	)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return nil, domain.ErrAlreadyExists
+		}
+		return nil, err
+	}
+	return star, nil
+}
+
+// Delete はスターを削除する
+func (r *StarRepository) Delete(ctx context.Context, userID domain.UserID, entryID domain.EntryID) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM stars WHERE user_id = ? AND entry_id = ?
+		`,
+		userID, entryID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ListByUserID はリポジトリからユーザーが付けたスターを検索する
+// (user_id, created_at) のインデックスを使って, cursor の位置から読む
+func (r *StarRepository) ListByUserID(ctx context.Context, userID domain.UserID, cursor *domain.Cursor, limit int) ([]*domain.Star, error) {
+	op, order := keysetDirection(cursor)
+	cond := "TRUE"
+	args := []interface{}{userID, domain.EntryStatusPublished}
+	if cursor != nil {
+		createdAt, err := cursor.Time()
+		if err != nil {
+			return nil, err
+		}
+		cond = "(s.created_at " + op + " ? OR (s.created_at = ? AND s.entry_id " + op + " ?))"
+		args = append(args, createdAt, createdAt, cursor.ID)
+	}
+	args = append(args, limit)
+	stars := make([]*domain.Star, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&stars,
+		`
+			SELECT s.user_id, s.entry_id, s.blog_id, s.created_at FROM stars AS s
+				INNER JOIN entries AS e ON e.id = s.entry_id
+				INNER JOIN blogs AS b ON b.id = s.blog_id
+				WHERE s.user_id = ? AND e.status = ? AND e.published_at <= CURRENT_TIMESTAMP(6) AND e.deleted_at IS NULL AND b.deleted_at IS NULL
+					AND `+cond+`
+				ORDER BY s.created_at `+order+`, s.entry_id `+order+` LIMIT ?
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reverseKeyset(cursor, stars)
+	return stars, nil
+}
+
+// ListStarredEntryIDs はリポジトリから複数のエントリのうちユーザーがスターを付けているものを検索する
+func (r *StarRepository) ListStarredEntryIDs(ctx context.Context, userID domain.UserID, entryIDs []domain.EntryID) (map[domain.EntryID]bool, error) {
+	starred := make(map[domain.EntryID]bool, len(entryIDs))
+	if len(entryIDs) == 0 {
+		return starred, nil
+	}
+	query, args, err := sqlx.In(
+		`
+			SELECT entry_id FROM stars
+				WHERE user_id = ? AND entry_id IN (?)
+		`,
+		userID, entryIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]domain.EntryID, 0, len(entryIDs))
+	if err := sqlx.SelectContext(ctx, r.db, &ids, query, args...); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		starred[id] = true
+	}
+	return starred, nil
+}
+
+// CountByEntryIDs はリポジトリから複数のエントリのスターの数をまとめて検索する
+// スターを数えずに, 付け外しするたびに増減させておいた数を読む
+func (r *StarRepository) CountByEntryIDs(ctx context.Context, entryIDs []domain.EntryID) (map[domain.EntryID]int, error) {
+	countsByEntryID := make(map[domain.EntryID]int, len(entryIDs))
+	if len(entryIDs) == 0 {
+		return countsByEntryID, nil
+	}
+	query, args, err := sqlx.In(
+		`
+			SELECT entry_id, count FROM entry_star_counts
+				WHERE entry_id IN (?) AND count > 0
+		`,
+		entryIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rows := []struct {
+		EntryID domain.EntryID `db:"entry_id"`
+		Count   int            `db:"count"`
+	}{}
+	if err := sqlx.SelectContext(ctx, r.db, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		countsByEntryID[row.EntryID] = row.Count
+	}
+	return countsByEntryID, nil
+}
+
+// AddCount はエントリのスターの数を増減させる. まだ数がない場合は作成する
+func (r *StarRepository) AddCount(ctx context.Context, entryID domain.EntryID, blogID domain.BlogID, delta int) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO entry_star_counts (entry_id, blog_id, count)
+				VALUES (?, ?, GREATEST(?, 0))
+				ON DUPLICATE KEY UPDATE count = GREATEST(CAST(entry_star_counts.count AS SIGNED) + ?, 0)
+		`,
+		entryID, blogID, delta, delta,
+	)
+	return err
+}
+
+// DeleteByEntryID はエントリに付けられたすべてのスターとスターの数を削除する
+func (r *StarRepository) DeleteByEntryID(ctx context.Context, entryID domain.EntryID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM stars WHERE entry_id = ?
+		`,
+		entryID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_star_counts WHERE entry_id = ?
+		`,
+		entryID,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのエントリに付けられたすべてのスターとスターの数を削除する
+func (r *StarRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM stars WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_star_counts WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// TagRepository は domain.TagRepository に対するデータベースを使った実装
+type TagRepository struct {
+	db DB
+}
+
+func newTagRepository(db DB) *TagRepository {
+	return &TagRepository{db}
+}
+
+// FindOrCreateByNames はリポジトリから名前でタグを検索し, 存在しないものは作成して保存する
+func (r *TagRepository) FindOrCreateByNames(ctx context.Context, names []string) ([]*domain.Tag, error) {
+	for _, name := range names {
+		id, err := generateID(r.db)
+		if err != nil {
+			return nil, err
+		}
+		_, err = r.db.ExecContext(
+			ctx,
+			`
+				INSERT INTO tags (id, name)
+					VALUES (?, ?)
+					ON DUPLICATE KEY UPDATE id = id
+			`,
+			id, name,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	query, args, err := sqlx.In(
+		`
+			SELECT id, name FROM tags
+				WHERE name IN (?)
+		`,
+		names,
+	)
+	if err != nil {
+		return nil, err
+	}
+	found := make([]*domain.Tag, 0, len(names))
+	if err := sqlx.SelectContext(ctx, r.db, &found, query, args...); err != nil {
+		return nil, err
+	}
+	// 指定された順序に並べ直す
+	byName := make(map[string]*domain.Tag, len(found))
+	for _, t := range found {
+		byName[t.Name] = t
+	}
+	tags := make([]*domain.Tag, 0, len(names))
+	for _, name := range names {
+		if t, ok := byName[name]; ok {
+			tags = append(tags, t)
+		}
+	}
+	return tags, nil
+}
+
+// ListByEntryID はリポジトリからエントリに付けられたタグを検索する
+func (r *TagRepository) ListByEntryID(ctx context.Context, entryID domain.EntryID) ([]*domain.Tag, error) {
+	tags := []*domain.Tag{}
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&tags,
+		`
+			SELECT t.id, t.name FROM tags AS t
+				INNER JOIN entry_tags AS et ON et.tag_id = t.id
+				WHERE et.entry_id = ?
+				ORDER BY t.name ASC
+		`,
+		entryID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ListByEntryIDs はリポジトリから複数のエントリに付けられたタグをまとめて検索する
+func (r *TagRepository) ListByEntryIDs(ctx context.Context, entryIDs []domain.EntryID) (map[domain.EntryID][]*domain.Tag, error) {
+	tagsByEntryID := make(map[domain.EntryID][]*domain.Tag, len(entryIDs))
+	if len(entryIDs) == 0 {
+		return tagsByEntryID, nil
+	}
+	query, args, err := sqlx.In(
+		`
+			SELECT et.entry_id, t.id, t.name FROM tags AS t
+				INNER JOIN entry_tags AS et ON et.tag_id = t.id
+				WHERE et.entry_id IN (?)
+				ORDER BY t.name ASC
+		`,
+		entryIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rows := []struct {
+		EntryID domain.EntryID `db:"entry_id"`
+		domain.Tag
+	}{}
+	if err := sqlx.SelectContext(ctx, r.db, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tag := row.Tag
+		tagsByEntryID[row.EntryID] = append(tagsByEntryID[row.EntryID], &tag)
+	}
+	return tagsByEntryID, nil
+}
+
+// ListCountsByBlogID はリポジトリからブログの公開されているエントリに付けられたタグとその数を検索する
+func (r *TagRepository) ListCountsByBlogID(ctx context.Context, blogID domain.BlogID, listedOnly bool) ([]*domain.TagCount, error) {
+	cond := "TRUE"
+	args := []interface{}{blogID, domain.EntryStatusPublished}
+	if listedOnly {
+		cond = "e.visibility = ?"
+		args = append(args, domain.VisibilityPublic)
+	}
+	counts := []*domain.TagCount{}
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&counts,
+		`
+			SELECT t.id, t.name, COUNT(*) AS count FROM tags AS t
+				INNER JOIN entry_tags AS et ON et.tag_id = t.id
+				INNER JOIN entries AS e ON e.id = et.entry_id
+				WHERE e.blog_id = ? AND e.status = ? AND e.published_at <= CURRENT_TIMESTAMP(6) AND e.deleted_at IS NULL AND `+cond+`
+				GROUP BY t.id, t.name
+				ORDER BY count DESC, t.name ASC
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// SetEntryTags はエントリに付けられたタグを置き換える
+func (r *TagRepository) SetEntryTags(ctx context.Context, entryID domain.EntryID, tagIDs []domain.TagID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_tags WHERE entry_id = ?
+		`,
+		entryID,
+	)
+	if err != nil {
+		return err
+	}
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(tagIDs))
+	args := make([]interface{}, 0, len(tagIDs)*2)
+	for _, tagID := range tagIDs {
+		placeholders = append(placeholders, "(?, ?)")
+		args = append(args, entryID, tagID)
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO entry_tags (entry_id, tag_id)
+				VALUES `+strings.Join(placeholders, ", "),
+		args...,
+	)
+	return err
+}
+
+// DeleteEntryTagsByBlogID はブログのすべてのエントリからタグを外す
+func (r *TagRepository) DeleteEntryTagsByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE entry_tags FROM entry_tags
+				INNER JOIN entries ON entries.id = entry_tags.entry_id
+				WHERE entries.blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
@@ -65,6 +65,28 @@ func (r *UserRepository) FindByID(ctx context.Context, id domain.UserID) (*domai
 	return &user, nil
 }
 
+// ListByIDs はリポジトリから複数の ID でユーザーを検索する
+func (r *UserRepository) ListByIDs(ctx context.Context, ids []domain.UserID) ([]*domain.User, error) {
+	users := make([]*domain.User, 0, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+	query, args, err := sqlx.In(
+		`
+			SELECT id, account_id, name FROM users
+				WHERE id IN (?)
+		`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.SelectContext(ctx, r.db, &users, query, args...); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // FindByAccountID はリポジトリからアカウントサービスの ID でユーザーを検索する
 func (r *UserRepository) FindByAccountID(ctx context.Context, id domain.AccountID) (*domain.User, error) {
 	var user domain.User
@@ -86,3 +108,25 @@ func (r *UserRepository) FindByAccountID(ctx context.Context, id domain.AccountI
 	}
 	return &user, nil
 }
+
+// FindByName はリポジトリから名前でユーザーを検索する
+func (r *UserRepository) FindByName(ctx context.Context, name string) (*domain.User, error) {
+	var user domain.User
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&user,
+		`
+			SELECT id, account_id, name FROM users
+				WHERE name = ? LIMIT 1
+		`,
+		name,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// WebhookRepository は domain.WebhookRepository に対するデータベースを使った実装
+type WebhookRepository struct {
+	db DB
+}
+
+func newWebhookRepository(db DB) *WebhookRepository {
+	return &WebhookRepository{db}
+}
+
+// Create は新規 Webhook を作成し, リポジトリに保存する
+func (r *WebhookRepository) Create(ctx context.Context, input *domain.CreateWebhookInput) (*domain.Webhook, error) {
+	id, err := generateID(r.db)
+	if err != nil {
+		return nil, err
+	}
+	webhook := &domain.Webhook{
+		ID:        domain.WebhookID(id),
+		BlogID:    input.BlogID,
+		URL:       input.URL,
+		Secret:    input.Secret,
+		CreatedAt: input.CreatedAt,
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO webhooks (id, blog_id, url, secret, created_at)
+				VALUES (?, ?, ?, ?, ?)
+		`,
+		webhook.ID, webhook.BlogID, webhook.URL, webhook.Secret, webhook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// FindByID はリポジトリから ID で Webhook を検索する
+func (r *WebhookRepository) FindByID(ctx context.Context, id domain.WebhookID) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&webhook,
+		`
+			SELECT id, blog_id, url, secret, created_at FROM webhooks
+				WHERE id = ? LIMIT 1
+		`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListByBlogID はリポジトリからブログの ID で Webhook を作成した順に検索する
+func (r *WebhookRepository) ListByBlogID(ctx context.Context, blogID domain.BlogID) ([]*domain.Webhook, error) {
+	webhooks := []*domain.Webhook{}
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&webhooks,
+		`
+			SELECT id, blog_id, url, secret, created_at FROM webhooks
+				WHERE blog_id = ?
+				ORDER BY created_at ASC, id ASC
+		`,
+		blogID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// Delete は Webhook をリポジトリから削除する
+func (r *WebhookRepository) Delete(ctx context.Context, id domain.WebhookID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM webhooks WHERE id = ?
+		`,
+		id,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべての Webhook を削除する
+func (r *WebhookRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM webhooks WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
+
+// WebhookDeliveryRepository は domain.WebhookDeliveryRepository に対するデータベースを使った実装
+type WebhookDeliveryRepository struct {
+	db DB
+}
+
+func newWebhookDeliveryRepository(db DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db}
+}
+
+// Create は新規の Webhook の配信を作成し, リポジトリに保存する. 作成した日時にすぐ送るようにする
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, input *domain.CreateWebhookDeliveryInput) (*domain.WebhookDelivery, error) {
+	id, err := generateID(r.db)
+	if err != nil {
+		return nil, err
+	}
+	nextAttemptAt := input.CreatedAt
+	delivery := &domain.WebhookDelivery{
+		ID:            domain.WebhookDeliveryID(id),
+		WebhookID:     input.WebhookID,
+		BlogID:        input.BlogID,
+		Event:         input.Event,
+		Payload:       input.Payload,
+		Status:        domain.WebhookDeliveryStatusPending,
+		TraceParent:   input.TraceParent,
+		NextAttemptAt: &nextAttemptAt,
+		CreatedAt:     input.CreatedAt,
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO webhook_deliveries (id, webhook_id, blog_id, event, payload, status, trace_parent, next_attempt_at, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+		delivery.ID, delivery.WebhookID, delivery.BlogID, delivery.Event, delivery.Payload, delivery.Status, delivery.TraceParent, delivery.NextAttemptAt, delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// FindByID はリポジトリから ID で Webhook の配信を検索する
+func (r *WebhookDeliveryRepository) FindByID(ctx context.Context, id domain.WebhookDeliveryID) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := sqlx.GetContext(
+		ctx,
+		r.db,
+		&delivery,
+		`
+			SELECT id, webhook_id, blog_id, event, payload, status, attempts, status_code, error, trace_parent, next_attempt_at, last_attempted_at, created_at FROM webhook_deliveries
+				WHERE id = ? LIMIT 1
+		`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListByWebhookID はリポジトリから Webhook の ID で配信を新しい順に検索する
+func (r *WebhookDeliveryRepository) ListByWebhookID(ctx context.Context, webhookID domain.WebhookID, limit int) ([]*domain.WebhookDelivery, error) {
+	deliveries := make([]*domain.WebhookDelivery, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&deliveries,
+		`
+			SELECT id, webhook_id, blog_id, event, payload, status, attempts, status_code, error, trace_parent, next_attempt_at, last_attempted_at, created_at FROM webhook_deliveries
+				WHERE webhook_id = ?
+				ORDER BY created_at DESC, id DESC LIMIT ?
+		`,
+		webhookID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ListDueForUpdate はリポジトリから次に送る日時を過ぎた配信を行ロックを取って検索する
+func (r *WebhookDeliveryRepository) ListDueForUpdate(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	deliveries := make([]*domain.WebhookDelivery, 0, limit)
+	err := sqlx.SelectContext(
+		ctx,
+		r.db,
+		&deliveries,
+		`
+			SELECT id, webhook_id, blog_id, event, payload, status, attempts, status_code, error, trace_parent, next_attempt_at, last_attempted_at, created_at FROM webhook_deliveries
+				WHERE status = ? AND next_attempt_at <= ?
+				ORDER BY next_attempt_at ASC LIMIT ?
+				FOR UPDATE SKIP LOCKED
+		`,
+		domain.WebhookDeliveryStatusPending, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Update は Webhook の配信の状態を更新する
+func (r *WebhookDeliveryRepository) Update(ctx context.Context, id domain.WebhookDeliveryID, input *domain.UpdateWebhookDeliveryInput) (*domain.WebhookDelivery, error) {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE webhook_deliveries SET status = ?, attempts = ?, status_code = ?, error = ?, next_attempt_at = ?, last_attempted_at = ?
+				WHERE id = ?
+		`,
+		input.Status, input.Attempts, input.StatusCode, input.Error, input.NextAttemptAt, input.LastAttemptedAt, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// DeleteCreatedBefore は指定した日時より前に作成した配信を古い順に削除する
+func (r *WebhookDeliveryRepository) DeleteCreatedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM webhook_deliveries WHERE created_at < ?
+				ORDER BY created_at ASC LIMIT ?
+		`,
+		before, limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// DeleteByWebhookID は Webhook のすべての配信を削除する
+func (r *WebhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID domain.WebhookID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM webhook_deliveries WHERE webhook_id = ?
+		`,
+		webhookID,
+	)
+	return err
+}
+
+// DeleteByBlogID はブログのすべての Webhook の配信を削除する
+func (r *WebhookDeliveryRepository) DeleteByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM webhook_deliveries WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
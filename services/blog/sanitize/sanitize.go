@@ -0,0 +1,181 @@
+package sanitize
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedAttrs は残してよい要素と, その要素で残してよい属性
+// ここにない要素はタグだけを取り除いて中身を残す
+var allowedAttrs = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Abbr:       {"title"},
+	atom.B:          nil,
+	atom.Blockquote: {"cite"},
+	atom.Br:         nil,
+	atom.Caption:    nil,
+	atom.Cite:       nil,
+	atom.Code:       nil,
+	atom.Dd:         nil,
+	atom.Del:        nil,
+	atom.Details:    nil,
+	atom.Div:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Ins:        nil,
+	atom.Kbd:        nil,
+	atom.Li:         nil,
+	atom.Mark:       nil,
+	atom.Ol:         {"start"},
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Q:          {"cite"},
+	atom.Rp:         nil,
+	atom.Rt:         nil,
+	atom.Ruby:       nil,
+	atom.S:          nil,
+	atom.Small:      nil,
+	atom.Span:       nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Summary:    nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Thead:      nil,
+	atom.Time:       {"datetime"},
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+	atom.Var:        nil,
+}
+
+// droppedElements は中身ごと取り除く要素
+var droppedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Textarea: true,
+	atom.Select:   true,
+	atom.Title:    true,
+	atom.Svg:      true,
+	atom.Math:     true,
+}
+
+// urlAttrs は URL を値に取る属性. 相対 URL か, allowedSchemes のスキームの URL だけを残す
+var urlAttrs = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// HTML は信頼できない HTML から allowedAttrs にある要素と属性だけを残した HTML を返す
+// スクリプトやスタイルは中身ごと取り除き, それ以外の許可していない要素はタグだけを取り除く
+func HTML(src string) string {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(src), body)
+	if err != nil {
+		// strings.Reader からの読み込みは失敗しないが, 念のため何も残さない
+		return ""
+	}
+	var sb strings.Builder
+	for _, n := range nodes {
+		writeNode(&sb, n)
+	}
+	return sb.String()
+}
+
+func writeNode(sb *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		// コメントや DOCTYPE は取り除く
+		return
+	}
+	if droppedElements[n.DataAtom] {
+		return
+	}
+	attrs, ok := allowedAttrs[n.DataAtom]
+	if !ok || n.DataAtom == 0 {
+		writeChildren(sb, n)
+		return
+	}
+	sb.WriteString("<")
+	sb.WriteString(n.Data)
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !slices.Contains(attrs, a.Key) {
+			continue
+		}
+		if urlAttrs[a.Key] && !isAllowedURL(a.Val) {
+			continue
+		}
+		sb.WriteString(" ")
+		sb.WriteString(a.Key)
+		sb.WriteString(`="`)
+		sb.WriteString(html.EscapeString(a.Val))
+		sb.WriteString(`"`)
+	}
+	sb.WriteString(">")
+	if isVoid(n.DataAtom) {
+		return
+	}
+	// pre の直後の改行はパース時に読み捨てられるので, 本文が改行で始まる場合は改行を補う
+	if n.DataAtom == atom.Pre && n.FirstChild != nil && n.FirstChild.Type == html.TextNode && strings.HasPrefix(n.FirstChild.Data, "\n") {
+		sb.WriteString("\n")
+	}
+	writeChildren(sb, n)
+	sb.WriteString("</")
+	sb.WriteString(n.Data)
+	sb.WriteString(">")
+}
+
+func writeChildren(sb *strings.Builder, n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeNode(sb, c)
+	}
+}
+
+func isVoid(a atom.Atom) bool {
+	return a == atom.Br || a == atom.Hr || a == atom.Img
+}
+
+// isAllowedURL は URL が相対 URL か, 許可したスキームの URL であるかを判定する
+func isAllowedURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || allowedSchemes[strings.ToLower(u.Scheme)]
+}
@@ -0,0 +1,165 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "allowed elements are kept",
+			src:  `<p>Hello <strong>world</strong> <a href="https://example.com/" title="t">link</a></p>`,
+			want: `<p>Hello <strong>world</strong> <a href="https://example.com/" title="t">link</a></p>`,
+		},
+		{
+			name: "script is dropped with its content",
+			src:  `<p>a</p><script>alert(1)</script><p>b</p>`,
+			want: `<p>a</p><p>b</p>`,
+		},
+		{
+			name: "style is dropped with its content",
+			src:  `<style>body { display: none }</style><p>a</p>`,
+			want: `<p>a</p>`,
+		},
+		{
+			name: "style attribute is dropped",
+			src:  `<p style="background:url(javascript:alert(1))">a</p>`,
+			want: `<p>a</p>`,
+		},
+		{
+			name: "event handler attributes are dropped",
+			src:  `<img src="/a.png" onerror="alert(1)" alt="a"><p onclick="alert(1)" onmouseover=alert(1)>b</p>`,
+			want: `<img src="/a.png" alt="a"><p>b</p>`,
+		},
+		{
+			name: "javascript URL",
+			src:  `<a href="javascript:alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "javascript URL in mixed case with spaces",
+			src:  `<a href="  JaVaScRiPt:alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "data URL",
+			src:  `<img src="data:image/svg+xml;base64,PHN2Zz4="><a href="data:text/html,<script>alert(1)</script>">x</a>`,
+			want: `<img><a>x</a>`,
+		},
+		{
+			name: "vbscript URL",
+			src:  `<a href="vbscript:msgbox(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "entity-encoded scheme",
+			src:  `<a href="&#106;&#97;&#118;&#97;&#115;&#99;&#114;&#105;&#112;&#116;&#58;alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "hex entity-encoded scheme",
+			src:  `<a href="&#x6A;avascript&#x3A;alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "scheme split by an encoded tab",
+			src:  `<a href="java&#x09;script:alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "scheme split by an encoded newline",
+			src:  `<a href="java&#x0A;script:alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "scheme split by a null character",
+			src:  "<a href=\"java\x00script:alert(1)\">x</a>",
+			want: `<a>x</a>`,
+		},
+		{
+			name: "relative and mailto URLs are kept",
+			src:  `<a href="/blogs/a">x</a><a href="mailto:a@example.com">y</a><a href="#top">z</a>`,
+			want: `<a href="/blogs/a">x</a><a href="mailto:a@example.com">y</a><a href="#top">z</a>`,
+		},
+		{
+			name: "unknown elements are unwrapped",
+			src:  `<font color="red"><center>a</center></font>`,
+			want: `a`,
+		},
+		{
+			name: "nested dropped elements",
+			src:  `<div><iframe src="https://example.com/"><script>alert(1)</script></iframe>a</div>`,
+			want: `<div>a</div>`,
+		},
+		{
+			name: "unclosed tags are closed",
+			src:  `<div><p>a<b>b`,
+			want: `<div><p>a<b>b</b></p></div>`,
+		},
+		{
+			name: "unclosed script drops the rest",
+			src:  `<p>a</p><script>alert(1)`,
+			want: `<p>a</p>`,
+		},
+		{
+			name: "broken tag names",
+			src:  `<scr<script>ipt>alert(1)</script>`,
+			want: `ipt&gt;alert(1)`,
+		},
+		{
+			name: "attribute values are escaped",
+			src:  `<a title="&quot;><script>alert(1)</script>">x</a>`,
+			want: `<a title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;">x</a>`,
+		},
+		{
+			name: "text is escaped",
+			src:  `&lt;script&gt;alert(1)&lt;/script&gt;`,
+			want: `&lt;script&gt;alert(1)&lt;/script&gt;`,
+		},
+		{
+			name: "svg is dropped with its content",
+			src:  `<svg onload="alert(1)"><a xlink:href="javascript:alert(1)"><text>x</text></a><script>alert(1)</script></svg><p>a</p>`,
+			want: `<p>a</p>`,
+		},
+		{
+			name: "math is dropped with its content",
+			src:  `<math><mtext><table><mglyph><style><img src=x onerror=alert(1)></style></mglyph></table></mtext></math>`,
+			want: ``,
+		},
+		{
+			name: "noscript mutation",
+			src:  `<noscript><p title="</noscript><img src=x onerror=alert(1)>">`,
+			want: `<img src="x">&#34;&gt;`,
+		},
+		{
+			name: "comments are dropped",
+			src:  `<!--<script>alert(1)</script>--><p>a</p><!-- x -->`,
+			want: `<p>a</p>`,
+		},
+		{
+			name: "form controls are dropped",
+			src:  `<form action="https://evil.example/"><input name="a"><textarea>x</textarea><button>b</button></form>`,
+			want: `b`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HTML(tt.src)
+			if got != tt.want {
+				t.Errorf("HTML(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+			// 何を入力しても, スクリプトを実行できる要素や属性は残らない
+			lower := strings.ToLower(got)
+			for _, bad := range []string{"<script", "<style", "<svg", "<iframe", " on", "javascript:", "data:"} {
+				if strings.Contains(lower, bad) {
+					t.Errorf("HTML(%q) = %q, contains %q", tt.src, got, bad)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// batchSize は一度の実行で公開するエントリや, 完全に削除するブログとエントリ, 書き出し, 購読者に渡すドメインイベント, 送る Webhook の配信の最大数
+const batchSize = 100
+
+var tracer = otel.Tracer("scheduler")
+
+// Scheduler は予約投稿されたエントリの公開と, メモリに貯めたエントリの閲覧の書き込み, ゴミ箱に残しておく期間を過ぎたブログとエントリの削除,
+// ブログの書き出しと残しておく期間を過ぎた書き出しの削除, ドメインイベントの購読者への受け渡しと処理済みのイベントの削除,
+// Webhook の配信と古い配信の記録の削除を定期的に行う
+// 時間のかかる処理に予約投稿の公開や閲覧の書き込みが待たされないよう, 処理の種類ごとに別の goroutine で実行する
+type Scheduler struct {
+	app      *app.App
+	interval time.Duration
+	logger   *zap.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler は Scheduler を作成する
+func NewScheduler(app *app.App, interval time.Duration, logger *zap.Logger) *Scheduler {
+	return &Scheduler{app: app, interval: interval, logger: logger}
+}
+
+// Start はスケジューラーをバックグラウンドで開始する
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	// すぐに終わる処理. 他の処理が長引いても interval ごとに実行する
+	s.startLoop(ctx, s.interval, s.flushEntryViews, s.publishScheduledEntries)
+	// 配信先の応答を待つことがあるので, イベントの受け渡しと Webhook の配信は別に実行する
+	// 予約投稿を公開したことのイベントは, 公開した後にこちらで購読者に渡す
+	s.startLoop(ctx, s.interval, s.relayEvents, s.deliverWebhooks)
+	// 書き出しは大きなブログだと数分かかるので, 他の処理とは別に実行する
+	s.startLoop(ctx, s.interval, s.runBlogExports)
+	// 古いものの削除は消すものが溜まっていると時間がかかるので, これも別に実行する
+	s.startLoop(ctx, s.interval,
+		s.purgeTrash,
+		s.purgeExpiredBlogExports,
+		s.purgeDispatchedEvents,
+		s.purgeExpiredWebhookDeliveries,
+	)
+}
+
+// startLoop は jobs をすぐに順に実行し, その後は interval ごとに順に実行する goroutine を開始する
+// 実行に interval より長くかかった場合は, 終わってからすぐに次を実行する
+func (s *Scheduler) startLoop(ctx context.Context, interval time.Duration, jobs ...func(ctx context.Context)) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for _, job := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				job(ctx)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown はスケジューラーを停止し, 実行中の処理が終わるのを待つ
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) publishScheduledEntries(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "scheduler.PublishScheduledEntries")
+	defer span.End()
+
+	// 公開すべきエントリが残っている間は続けて処理する
+	total := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("scheduler.published_entries", total))
+	}()
+	for {
+		entries, err := s.app.PublishScheduledEntries(ctx, time.Now(), batchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				span.SetStatus(codes.Error, "failed to publish scheduled entries")
+				span.RecordError(err)
+				s.logger.Warn(fmt.Sprintf("failed to publish scheduled entries: %+v", err))
+			}
+			return
+		}
+		total += len(entries)
+		for _, e := range entries {
+			s.logger.Info(fmt.Sprintf("published scheduled entry (id = %v)", e.ID))
+		}
+		if len(entries) < batchSize {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) flushEntryViews(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "scheduler.FlushEntryViews")
+	defer span.End()
+
+	n, err := s.app.FlushEntryViews(ctx)
+	span.SetAttributes(attribute.Int64("scheduler.entry_views", n))
+	if err != nil && ctx.Err() == nil {
+		span.SetStatus(codes.Error, "failed to flush entry views")
+		span.RecordError(err)
+		s.logger.Warn(fmt.Sprintf("failed to flush entry views: %+v", err))
+	}
+}
+
+func (s *Scheduler) purgeTrash(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "scheduler.PurgeTrash")
+	defer span.End()
+
+	purgedBlogs, purgedEntries := 0, 0
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("scheduler.purged_blogs", purgedBlogs),
+			attribute.Int("scheduler.purged_entries", purgedEntries),
+		)
+	}()
+	for {
+		blogs, entries, err := s.app.PurgeTrash(ctx, time.Now(), batchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				span.SetStatus(codes.Error, "failed to purge trash")
+				span.RecordError(err)
+				s.logger.Warn(fmt.Sprintf("failed to purge trash: %+v", err))
+			}
+			return
+		}
+		purgedBlogs += len(blogs)
+		purgedEntries += len(entries)
+		for _, b := range blogs {
+			s.logger.Info(fmt.Sprintf("purged trashed blog (id = %v)", b.ID))
+		}
+		for _, e := range entries {
+			s.logger.Info(fmt.Sprintf("purged trashed entry (id = %v)", e.ID))
+		}
+		if len(blogs) < batchSize && len(entries) < batchSize {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) runBlogExports(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "scheduler.RunBlogExports")
+	defer span.End()
+
+	// 書き出しには時間がかかるので, 始めたまま長く待たせないよう 1 件ずつ始める
+	total := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("scheduler.blog_exports", total))
+	}()
+	for {
+		exports, err := s.app.RunBlogExports(ctx, time.Now(), 1)
+		if err != nil {
+			if ctx.Err() == nil {
+				span.SetStatus(codes.Error, "failed to run blog exports")
+				span.RecordError(err)
+				s.logger.Warn(fmt.Sprintf("failed to run blog exports: %+v", err))
+			}
+			return
+		}
+		total += len(exports)
+		for _, e := range exports {
+			if e.Status == domain.BlogExportStatusFailed {
+				s.logger.Warn(fmt.Sprintf("failed to export blog (id = %v, export id = %v): %s", e.BlogID, e.ID, e.Error))
+				continue
+			}
+			s.logger.Info(fmt.Sprintf("exported blog (id = %v, export id = %v, size = %d)", e.BlogID, e.ID, e.Size))
+		}
+		if len(exports) == 0 {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) purgeExpiredBlogExports(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "scheduler.PurgeExpiredBlogExports")
+	defer span.End()
+
+	total := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("scheduler.purged_blog_exports", total))
+	}()
+	for {
+		exports, err := s.app.PurgeExpiredBlogExports(ctx, time.Now(), batchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				span.SetStatus(codes.Error, "failed to purge expired blog exports")
+				span.RecordError(err)
+				s.logger.Warn(fmt.Sprintf("failed to purge expired blog exports: %+v", err))
+			}
+			return
+		}
+		total += len(exports)
+		for _, e := range exports {
+			s.logger.Info(fmt.Sprintf("purged expired blog export (id = %v)", e.ID))
+		}
+		if len(exports) < batchSize {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) relayEvents(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "scheduler.RelayEvents")
+	defer span.End()
+
+	// 渡すべきイベントが残っている間は続けて処理する. 失敗したものは次に渡す日時を先にするので, 同じ実行では渡し直さない
+	total := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("scheduler.relayed_events", total))
+	}()
+	for {
+		events, err := s.app.RelayEvents(ctx, time.Now(), batchSize)
+		total += len(events)
+		for _, e := range events {
+			if e.DispatchedAt == nil || e.IsAbandoned() {
+				s.logger.Warn(fmt.Sprintf("failed to relay event (id = %v, type = %s, attempts = %d): %s", e.ID, e.Type, e.Attempts, e.Error))
+				continue
+			}
+			s.logger.Info(fmt.Sprintf("relayed event (id = %v, type = %s)", e.ID, e.Type))
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				span.SetStatus(codes.Error, "failed to relay events")
+				span.RecordError(err)
+				s.logger.Warn(fmt.Sprintf("failed to relay events: %+v", err))
+			}
+			return
+		}
+		if len(events) < batchSize {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) purgeDispatchedEvents(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "scheduler.PurgeDispatchedEvents")
+	defer span.End()
+
+	total := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("scheduler.purged_events", total))
+	}()
+	for {
+		n, err := s.app.PurgeDispatchedEvents(ctx, time.Now(), batchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				span.SetStatus(codes.Error, "failed to purge dispatched events")
+				span.RecordError(err)
+				s.logger.Warn(fmt.Sprintf("failed to purge dispatched events: %+v", err))
+			}
+			return
+		}
+		total += n
+		if n < batchSize {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) deliverWebhooks(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "scheduler.DeliverWebhooks")
+	defer span.End()
+
+	// 送るべき配信が残っている間は続けて処理する. 失敗したものは次に送る日時を先にするので, 同じ実行では送り直さない
+	total := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("scheduler.webhook_deliveries", total))
+	}()
+	for {
+		deliveries, err := s.app.DeliverWebhooks(ctx, time.Now(), batchSize)
+		total += len(deliveries)
+		for _, d := range deliveries {
+			if d.Status != domain.WebhookDeliveryStatusSucceeded {
+				s.logger.Warn(fmt.Sprintf("failed to deliver webhook (id = %v, delivery id = %v, attempts = %d): %s", d.WebhookID, d.ID, d.Attempts, d.Error))
+				continue
+			}
+			s.logger.Info(fmt.Sprintf("delivered webhook (id = %v, delivery id = %v, event = %s)", d.WebhookID, d.ID, d.Event))
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				span.SetStatus(codes.Error, "failed to deliver webhooks")
+				span.RecordError(err)
+				s.logger.Warn(fmt.Sprintf("failed to deliver webhooks: %+v", err))
+			}
+			return
+		}
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) purgeExpiredWebhookDeliveries(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "scheduler.PurgeExpiredWebhookDeliveries")
+	defer span.End()
+
+	total := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("scheduler.purged_webhook_deliveries", total))
+	}()
+	for {
+		n, err := s.app.PurgeExpiredWebhookDeliveries(ctx, time.Now(), batchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				span.SetStatus(codes.Error, "failed to purge expired webhook deliveries")
+				span.RecordError(err)
+				s.logger.Warn(fmt.Sprintf("failed to purge expired webhook deliveries: %+v", err))
+			}
+			return
+		}
+		total += n
+		if n < batchSize {
+			return
+		}
+	}
+}
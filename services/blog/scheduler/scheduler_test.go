@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartLoop(t *testing.T) {
+	s := &Scheduler{}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	// 長くかかる処理が実行中でも, 別のループの処理は間隔ごとに実行される
+	release := make(chan struct{})
+	var slow, fast atomic.Int64
+	s.startLoop(ctx, time.Millisecond, func(ctx context.Context) {
+		slow.Add(1)
+		<-release
+	})
+	s.startLoop(ctx, time.Millisecond, func(ctx context.Context) {
+		fast.Add(1)
+	})
+	deadline := time.Now().Add(time.Second)
+	for fast.Load() < 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("fast job ran %d times while the slow job was running", fast.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 実行中の処理が終わるまで停止を待つ
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer shutdownCancel()
+	if err := s.Shutdown(shutdownCtx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := slow.Load(); n != 1 {
+		t.Errorf("slow job ran %d times, want 1", n)
+	}
+}
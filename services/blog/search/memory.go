@@ -0,0 +1,226 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// MemoryIndex は domain.SearchIndex に対するプロセス内のメモリを使った実装
+// データベースを用意せずにテストするためのもので, MySQLIndex と同じく n-gram で照合する
+// エントリの公開状態はインデックスに追加したときのものを使い, ブログの公開範囲とゴミ箱は IndexBlog で知らせる
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[domain.EntryID]*memoryDoc
+	postings map[string]map[domain.EntryID]struct{}
+	blogs    map[domain.BlogID]memoryBlog
+}
+
+type memoryDoc struct {
+	blogID      domain.BlogID
+	title       string
+	body        string
+	status      domain.EntryStatus
+	visibility  domain.Visibility
+	trashed     bool
+	publishedAt time.Time
+	grams       []string
+}
+
+type memoryBlog struct {
+	visibility domain.Visibility
+	trashed    bool
+}
+
+// NewMemoryIndex は MemoryIndex を作成する
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[domain.EntryID]*memoryDoc),
+		postings: make(map[string]map[domain.EntryID]struct{}),
+		blogs:    make(map[domain.BlogID]memoryBlog),
+	}
+}
+
+// IndexBlog はブログの公開範囲とゴミ箱にあるかを記録する. 記録していないブログは公開範囲が public でゴミ箱にないものとする
+func (i *MemoryIndex) IndexBlog(blog *domain.Blog) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.blogs[blog.ID] = memoryBlog{blog.Visibility, blog.IsTrashed()}
+}
+
+// Index はエントリをインデックスに追加する
+func (i *MemoryIndex) Index(ctx context.Context, entry *domain.Entry) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(entry.ID)
+	doc := &memoryDoc{
+		blogID:      entry.BlogID,
+		title:       strings.ToLower(entry.Title),
+		body:        strings.ToLower(entry.Body),
+		status:      entry.Status,
+		visibility:  entry.Visibility,
+		trashed:     entry.IsTrashed(),
+		publishedAt: entry.PublishedAt,
+		grams:       append(ngrams(entry.Title), ngrams(entry.Body)...),
+	}
+	i.docs[entry.ID] = doc
+	for _, g := range doc.grams {
+		if i.postings[g] == nil {
+			i.postings[g] = make(map[domain.EntryID]struct{})
+		}
+		i.postings[g][entry.ID] = struct{}{}
+	}
+	return nil
+}
+
+// Remove はエントリをインデックスから取り除く
+func (i *MemoryIndex) Remove(ctx context.Context, entryID domain.EntryID) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(entryID)
+	return nil
+}
+
+// RemoveByBlogID はブログのすべてのエントリをインデックスから取り除く
+func (i *MemoryIndex) RemoveByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for id, doc := range i.docs {
+		if doc.blogID == blogID {
+			i.remove(id)
+		}
+	}
+	return nil
+}
+
+func (i *MemoryIndex) remove(entryID domain.EntryID) {
+	doc, ok := i.docs[entryID]
+	if !ok {
+		return
+	}
+	for _, g := range doc.grams {
+		delete(i.postings[g], entryID)
+		if len(i.postings[g]) == 0 {
+			delete(i.postings, g)
+		}
+	}
+	delete(i.docs, entryID)
+}
+
+// Search はクエリに一致するエントリを検索する
+// タイトルに一致したエントリは本文にのみ一致したエントリより上位になる
+func (i *MemoryIndex) Search(ctx context.Context, query string, opts domain.SearchOptions, limit, offset int) ([]*domain.SearchHit, error) {
+	terms := parseTerms(query)
+	hits := make([]*domain.SearchHit, 0, limit)
+	if len(terms) == 0 {
+		return hits, nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	// n-gram の転置インデックスで候補を絞り込んでから, 検索語が実際に含まれているかを確かめる
+	// n 文字に満たない検索語は n-gram の途中にも現れるので, 絞り込みには使わない
+	var candidates map[domain.EntryID]struct{}
+	for _, t := range terms {
+		if utf8.RuneCountInString(t) < ngramSize {
+			continue
+		}
+		for _, g := range ngrams(t) {
+			candidates = intersect(candidates, i.postings[g])
+		}
+	}
+	if candidates == nil {
+		candidates = make(map[domain.EntryID]struct{}, len(i.docs))
+		for id := range i.docs {
+			candidates[id] = struct{}{}
+		}
+	}
+	type scored struct {
+		hit         *domain.SearchHit
+		publishedAt time.Time
+	}
+	results := make([]scored, 0, len(candidates))
+	for id := range candidates {
+		doc := i.docs[id]
+		if !i.matches(doc, opts) {
+			continue
+		}
+		score := 0.0
+		for _, t := range terms {
+			inTitle := strings.Count(doc.title, t)
+			inBody := strings.Count(doc.body, t)
+			if inTitle+inBody == 0 {
+				score = 0
+				break
+			}
+			score += float64(inTitle*2 + inBody)
+		}
+		if score == 0 {
+			continue
+		}
+		results = append(results, scored{
+			hit:         &domain.SearchHit{EntryID: id, BlogID: doc.blogID, Score: score},
+			publishedAt: doc.publishedAt,
+		})
+	}
+	sort.Slice(results, func(a, b int) bool {
+		if results[a].hit.Score != results[b].hit.Score {
+			return results[a].hit.Score > results[b].hit.Score
+		}
+		if !results[a].publishedAt.Equal(results[b].publishedAt) {
+			return results[a].publishedAt.After(results[b].publishedAt)
+		}
+		return results[a].hit.EntryID > results[b].hit.EntryID
+	})
+
+	for n, r := range results {
+		if n < offset {
+			continue
+		}
+		if len(hits) >= limit {
+			break
+		}
+		hits = append(hits, r.hit)
+	}
+	return hits, nil
+}
+
+// matches はエントリが opts の範囲にあり, 読者に公開されているかを判定する
+func (i *MemoryIndex) matches(doc *memoryDoc, opts domain.SearchOptions) bool {
+	if opts.BlogID != 0 && doc.blogID != opts.BlogID {
+		return false
+	}
+	blog, ok := i.blogs[doc.blogID]
+	if !ok {
+		blog = memoryBlog{visibility: domain.VisibilityPublic}
+	}
+	if doc.status != domain.EntryStatusPublished || doc.trashed || blog.trashed {
+		return false
+	}
+	if opts.ListedOnly && (doc.visibility != domain.VisibilityPublic || blog.visibility != domain.VisibilityPublic) {
+		return false
+	}
+	return true
+}
+
+func intersect(a, b map[domain.EntryID]struct{}) map[domain.EntryID]struct{} {
+	result := make(map[domain.EntryID]struct{})
+	if a == nil {
+		for id := range b {
+			result[id] = struct{}{}
+		}
+		return result
+	}
+	for id := range a {
+		if _, ok := b[id]; ok {
+			result[id] = struct{}{}
+		}
+	}
+	return result
+}
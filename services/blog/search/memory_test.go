@@ -0,0 +1,100 @@
+package search
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func TestMemoryIndexSearch(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	trashedAt := now
+	i := NewMemoryIndex()
+	i.IndexBlog(&domain.Blog{ID: 2, Visibility: domain.VisibilityPrivate})
+	i.IndexBlog(&domain.Blog{ID: 3, Visibility: domain.VisibilityPublic, DeletedAt: &trashedAt})
+	entries := []*domain.Entry{
+		{ID: 1, BlogID: 1, Title: "Go の並行処理", Body: "goroutine と channel", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic, PublishedAt: now},
+		{ID: 2, BlogID: 1, Title: "日記", Body: "今日は Go を書いた", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic, PublishedAt: now.Add(time.Hour)},
+		{ID: 3, BlogID: 1, Title: "Go の下書き", Status: domain.EntryStatusDraft, Visibility: domain.VisibilityPublic, PublishedAt: now},
+		{ID: 4, BlogID: 1, Title: "限定公開の Go", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityUnlisted, PublishedAt: now},
+		{ID: 5, BlogID: 1, Title: "ゴミ箱の Go", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic, PublishedAt: now, DeletedAt: &trashedAt},
+		{ID: 6, BlogID: 2, Title: "非公開ブログの Go", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic, PublishedAt: now},
+		{ID: 7, BlogID: 3, Title: "ゴミ箱のブログの Go", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic, PublishedAt: now},
+	}
+	for _, e := range entries {
+		if err := i.Index(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		opts   domain.SearchOptions
+		offset int
+		want   []domain.EntryID
+	}{
+		// タイトルに一致したエントリが上位になる
+		{"title before body", "go", domain.SearchOptions{ListedOnly: true}, 0, []domain.EntryID{1, 2}},
+		{"including unlisted entries and blogs", "go", domain.SearchOptions{}, 0, []domain.EntryID{1, 6, 4, 2}},
+		{"in a blog", "go", domain.SearchOptions{BlogID: 2}, 0, []domain.EntryID{6}},
+		{"japanese", "並行", domain.SearchOptions{ListedOnly: true}, 0, []domain.EntryID{1}},
+		{"all terms", "go 日記", domain.SearchOptions{ListedOnly: true}, 0, []domain.EntryID{2}},
+		{"shorter than a n-gram", "と", domain.SearchOptions{ListedOnly: true}, 0, []domain.EntryID{1}},
+		{"offset", "go", domain.SearchOptions{ListedOnly: true}, 1, []domain.EntryID{2}},
+		{"no match", "rust", domain.SearchOptions{}, 0, []domain.EntryID{}},
+		{"empty query", " ", domain.SearchOptions{}, 0, []domain.EntryID{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := i.Search(ctx, tt.query, tt.opts, 10, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]domain.EntryID, 0, len(hits))
+			for _, h := range hits {
+				got = append(got, h.EntryID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexRemove(t *testing.T) {
+	ctx := context.Background()
+	i := NewMemoryIndex()
+	for _, e := range []*domain.Entry{
+		{ID: 1, BlogID: 1, Title: "Go", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic},
+		{ID: 2, BlogID: 2, Title: "Go", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic},
+		{ID: 3, BlogID: 2, Title: "Go", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic},
+	} {
+		if err := i.Index(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 内容を更新すると古い内容では見つからない
+	if err := i.Index(ctx, &domain.Entry{ID: 1, BlogID: 1, Title: "Rust", Status: domain.EntryStatusPublished, Visibility: domain.VisibilityPublic}); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Remove(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := i.Search(ctx, "go", domain.SearchOptions{}, 10, 0); len(hits) != 1 || hits[0].EntryID != 3 {
+		t.Errorf("hits = %v, want only entry 3", hits)
+	}
+	if err := i.RemoveByBlogID(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := i.Search(ctx, "go", domain.SearchOptions{}, 10, 0); len(hits) != 0 {
+		t.Errorf("hits = %v, want none", hits)
+	}
+	if len(i.postings) != 3 {
+		t.Errorf("postings = %v, want only the n-grams of entry 1", i.postings)
+	}
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/repository"
+)
+
+// MySQLIndex は domain.SearchIndex に対する MySQL の FULLTEXT インデックス (ngram パーサー) を使った実装
+type MySQLIndex struct {
+	db repository.DB
+}
+
+// NewMySQLIndex は MySQLIndex を作成する
+func NewMySQLIndex(db repository.DB) *MySQLIndex {
+	return &MySQLIndex{db}
+}
+
+// Index はエントリをインデックスに追加する
+func (i *MySQLIndex) Index(ctx context.Context, entry *domain.Entry) error {
+	_, err := i.db.ExecContext(
+		ctx,
+		`
+			INSERT INTO entry_search_index (entry_id, blog_id, title, body, published_at)
+				VALUES (?, ?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE blog_id = VALUES(blog_id), title = VALUES(title), body = VALUES(body), published_at = VALUES(published_at)
+		`,
+		entry.ID, entry.BlogID, entry.Title, entry.Body, entry.PublishedAt,
+	)
+	return err
+}
+
+// Remove はエントリをインデックスから取り除く
+func (i *MySQLIndex) Remove(ctx context.Context, entryID domain.EntryID) error {
+	_, err := i.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_search_index WHERE entry_id = ?
+		`,
+		entryID,
+	)
+	return err
+}
+
+// RemoveByBlogID はブログのすべてのエントリをインデックスから取り除く
+func (i *MySQLIndex) RemoveByBlogID(ctx context.Context, blogID domain.BlogID) error {
+	_, err := i.db.ExecContext(
+		ctx,
+		`
+			DELETE FROM entry_search_index WHERE blog_id = ?
+		`,
+		blogID,
+	)
+	return err
+}
+
+// Search はクエリに一致するエントリを検索する
+// タイトルに一致したエントリは本文にのみ一致したエントリより上位になる
+// エントリとブログの公開状態はインデックスではなく entries と blogs から読むので, 返すエントリの数は limit より少なくならない
+func (i *MySQLIndex) Search(ctx context.Context, query string, opts domain.SearchOptions, limit, offset int) ([]*domain.SearchHit, error) {
+	terms := parseTerms(query)
+	hits := make([]*domain.SearchHit, 0, limit)
+	if len(terms) == 0 {
+		return hits, nil
+	}
+	phrases := make([]string, 0, len(terms))
+	for _, t := range terms {
+		phrases = append(phrases, `+"`+t+`"`)
+	}
+	against := strings.Join(phrases, " ")
+
+	cond := ""
+	args := []interface{}{against, against, against, domain.EntryStatusPublished}
+	if opts.BlogID != 0 {
+		cond += " AND i.blog_id = ?"
+		args = append(args, opts.BlogID)
+	}
+	if opts.ListedOnly {
+		cond += " AND e.visibility = ? AND b.visibility = ?"
+		args = append(args, domain.VisibilityPublic, domain.VisibilityPublic)
+	}
+	args = append(args, limit, offset)
+	err := sqlx.SelectContext(
+		ctx,
+		i.db,
+		&hits,
+		`
+			SELECT i.entry_id, i.blog_id,
+					MATCH (i.title) AGAINST (? IN BOOLEAN MODE) * 2 + MATCH (i.title, i.body) AGAINST (? IN BOOLEAN MODE) AS score
+				FROM entry_search_index AS i
+					JOIN entries AS e ON e.id = i.entry_id
+					JOIN blogs AS b ON b.id = i.blog_id
+				WHERE MATCH (i.title, i.body) AGAINST (? IN BOOLEAN MODE)
+					AND e.status = ? AND e.deleted_at IS NULL AND b.deleted_at IS NULL`+cond+`
+				ORDER BY score DESC, i.published_at DESC LIMIT ? OFFSET ?
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
@@ -0,0 +1,48 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ngramSize は n-gram の n. MySQL の ngram_token_size のデフォルト値に合わせている
+const ngramSize = 2
+
+// parseTerms はクエリを空白で区切って検索語に分ける
+// 検索語はすべて含まれている必要があり, 検索語の中の空白以外の文字は MySQL の ngram パーサーや MemoryIndex の n-gram で照合する
+func parseTerms(query string) []string {
+	fields := strings.Fields(query)
+	terms := make([]string, 0, len(fields))
+	for _, f := range fields {
+		// MySQL の BOOLEAN MODE で特別な意味を持つ文字は取り除く
+		f = strings.Map(func(r rune) rune {
+			switch r {
+			case '"', '+', '-', '<', '>', '(', ')', '~', '*', '@':
+				return -1
+			}
+			return r
+		}, f)
+		if f != "" {
+			terms = append(terms, strings.ToLower(f))
+		}
+	}
+	return terms
+}
+
+// ngrams は文字列を n-gram に分割する
+// n 文字に満たない文字列はそれ自体を 1 つの n-gram とする
+func ngrams(s string) []string {
+	s = strings.ToLower(s)
+	var grams []string
+	for _, field := range strings.Fields(s) {
+		if utf8.RuneCountInString(field) < ngramSize {
+			grams = append(grams, field)
+			continue
+		}
+		runes := []rune(field)
+		for i := 0; i+ngramSize <= len(runes); i++ {
+			grams = append(grams, string(runes[i:i+ngramSize]))
+		}
+	}
+	return grams
+}
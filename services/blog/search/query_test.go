@@ -0,0 +1,29 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{}},
+		{"   ", []string{}},
+		{"Go", []string{"go"}},
+		{"日本語 検索", []string{"日本語", "検索"}},
+		{"全角　スペース", []string{"全角", "スペース"}},
+		// BOOLEAN MODE の演算子で検索の意味を変えられないよう取り除く
+		{`+go -rust "phrase" (a) ~b c* @d <e >f`, []string{"go", "rust", "phrase", "a", "b", "c", "d", "e", "f"}},
+		{`"`, []string{}},
+		{`" +`, []string{}},
+		{`a"b`, []string{"ab"}},
+	}
+	for _, tt := range tests {
+		if got := parseTerms(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("parseTerms(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
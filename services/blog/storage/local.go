@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// LocalStorage は domain.MediaStorage に対するローカルのファイルシステムを使った実装
+// キーの / で区切った部分をディレクトリとして, dir の下にファイルを保存する
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage は LocalStorage を作成する
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{dir}
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	p := filepath.FromSlash(key)
+	if !filepath.IsLocal(p) {
+		return "", fmt.Errorf("invalid key: %s", key)
+	}
+	return filepath.Join(s.dir, p), nil
+}
+
+// Put は body をファイルとして key に保存する
+// 書き込み途中のファイルを読まれないよう, 一時ファイルに書いてから名前を変更する
+func (s *LocalStorage) Put(ctx context.Context, key, contentType string, body io.ReadSeeker) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	if _, err := io.Copy(f, body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Get は key に保存されたファイルを返す
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// Delete は key に保存されたファイルを削除する
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
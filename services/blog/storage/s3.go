@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// S3Storage は domain.MediaStorage に対する S3 互換のオブジェクトストレージを使った実装
+// バケットはパス形式の URL で指定するので, endpoint を変えれば MinIO などのローカルのスタブにも向けられる
+type S3Storage struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+}
+
+// NewS3Storage は S3Storage を作成する. endpoint は https://s3.ap-northeast-1.amazonaws.com のようなバケットを含まない URL
+func NewS3Storage(endpoint, region, bucket, accessKeyID, secretAccessKey string) (*S3Storage, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint: %s", endpoint)
+	}
+	return &S3Storage{
+		endpoint:        u,
+		region:          region,
+		bucket:          bucket,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Put は body をファイルとして key に保存する
+func (s *S3Storage) Put(ctx context.Context, key, contentType string, body io.ReadSeeker) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return s3Error(res)
+	}
+	return nil
+}
+
+// Get は key に保存されたファイルを返す
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, domain.ErrNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, s3Error(res)
+	}
+	return res.Body, nil
+}
+
+// Delete は key に保存されたファイルを削除する
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return s3Error(res)
+	}
+	return nil
+}
+
+func s3Error(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("s3: unexpected status %d: %s", res.StatusCode, bytes.TrimSpace(body))
+}
+
+// newRequest はオブジェクトに対するリクエストを作成し, 署名バージョン 4 で署名する. body が nil の場合は本文を送らない
+// 署名には本文のハッシュが必要なので, body を一度読んでハッシュを計算してから先頭に戻して送る
+func (s *S3Storage) newRequest(ctx context.Context, method, key string, body io.ReadSeeker) (*http.Request, error) {
+	u := *s.endpoint
+	u.RawPath = strings.TrimSuffix(s.endpoint.EscapedPath(), "/") + "/" + uriEncode(s.bucket, false) + "/" + uriEncode(key, true)
+	u.Path = strings.TrimSuffix(s.endpoint.Path, "/") + "/" + s.bucket + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	if body != nil {
+		size, err := io.Copy(hash, body)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if size > 0 {
+			// 呼び出し側が閉じる body を, 送った後に http.Client に閉じられないようにする
+			req.Body = io.NopCloser(body)
+			req.ContentLength = size
+		}
+	}
+	s.sign(req, hash.Sum(nil), time.Now().UTC())
+	return req, nil
+}
+
+// s3SignedHeaders は署名に含めるヘッダ
+const s3SignedHeaders = "host;x-amz-content-sha256;x-amz-date"
+
+func (s *S3Storage) sign(req *http.Request, payloadHash []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash))
+
+	canonical := canonicalRequest(req.Method, req.URL.EscapedPath(), req.URL.Host, hex.EncodeToString(payloadHash), amzDate)
+	scope := now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+	sig := signature(s.secretAccessKey, s.region, amzDate, canonical)
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.accessKeyID+"/"+scope+", SignedHeaders="+s3SignedHeaders+", Signature="+sig)
+}
+
+// canonicalRequest は署名バージョン 4 の正規リクエストを作る. クエリ文字列は使わないので常に空にする
+func canonicalRequest(method, escapedPath, host, payloadHash, amzDate string) string {
+	return strings.Join([]string{
+		method,
+		escapedPath,
+		"",
+		"host:" + host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		s3SignedHeaders,
+		payloadHash,
+	}, "\n")
+}
+
+// signature は正規リクエストに対する署名バージョン 4 の署名を返す. amzDate は 20060102T150405Z 形式の UTC の日時
+func signature(secretAccessKey, region, amzDate, canonicalRequest string) string {
+	date := amzDate[:8]
+	scope := date + "/" + region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode は署名バージョン 4 の規則で URI をエンコードする. keepSlash が true の場合は / をエンコードしない
+func uriEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' || c == '/' && keepSlash {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
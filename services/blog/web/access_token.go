@@ -0,0 +1,82 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// accessTokenLifetimeDays はパーソナルアクセストークンの有効期間として選べる日数
+var accessTokenLifetimeDays = []int{7, 30, 90, 365}
+
+func (s *Server) renderAccessTokens(c echo.Context, user *domain.User, newToken string) error {
+	tokens, err := s.app.ListAccessTokens(c.Request().Context(), user)
+	if err != nil {
+		return err
+	}
+	return c.Render(http.StatusOK, "my-tokens.html", map[string]interface{}{
+		"User":         user,
+		"Tokens":       tokens,
+		"NewToken":     newToken,
+		"Scopes":       domain.AccessTokenScopes,
+		"LifetimeDays": accessTokenLifetimeDays,
+		"Now":          time.Now(),
+		"OpenAPIURL":   s.baseURL + "/api/v1/openapi.yaml",
+	})
+}
+
+func (s *Server) MyAccessTokensHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		return s.renderAccessTokens(c, user, "")
+	}
+}
+
+func (s *Server) CreateAccessTokenHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		params := new(struct {
+			Name         string   `form:"name"`
+			Scopes       []string `form:"scopes"`
+			LifetimeDays int      `form:"lifetime_days"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		scopes := make([]domain.AccessTokenScope, 0, len(params.Scopes))
+		for _, s := range params.Scopes {
+			scopes = append(scopes, domain.AccessTokenScope(s))
+		}
+		lifetime := time.Duration(params.LifetimeDays) * 24 * time.Hour
+		_, token, err := s.app.CreateAccessToken(c.Request().Context(), user, params.Name, scopes, lifetime)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid params")
+			}
+			return err
+		}
+		// トークンそのものは保存していないので, リダイレクトせずにこのレスポンスで一度だけ見せる
+		return s.renderAccessTokens(c, user, token)
+	}
+}
+
+func (s *Server) RevokeAccessTokenHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		tokenID, err := domain.ParseAccessTokenID(c.Param("token_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		if err := s.app.RevokeAccessToken(c.Request().Context(), user, tokenID); err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, "/my/tokens")
+	}
+}
@@ -0,0 +1,585 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/openapi"
+)
+
+const (
+	// apiDefaultLimit は一覧の 1 ページに含める要素の数の既定値
+	apiDefaultLimit = 20
+	// apiMaxLimit は一覧の 1 ページに含める要素の数の上限
+	apiMaxLimit = 100
+)
+
+// apiError は JSON API のエラーのレスポンスを表す
+// エラーの本文は常に {"error": {"code": ..., "message": ...}} の形にする
+type apiError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// errInvalidParam はリクエストのパラメーターが不正なことを表す apiError を返す
+func errInvalidParam(message string) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Code: "invalid_argument", Message: message}
+}
+
+// toAPIError はハンドラが返したエラーを apiError に変換する. 想定していないエラーは内部エラーとする
+func toAPIError(err error) *apiError {
+	var ae *apiError
+	if errors.As(err, &ae) {
+		return ae
+	}
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		code := strings.ToLower(strings.ReplaceAll(http.StatusText(he.Code), " ", "_"))
+		return &apiError{Status: he.Code, Code: code, Message: fmt.Sprint(he.Message)}
+	}
+	switch {
+	case errors.Is(err, app.ErrNotFound):
+		return &apiError{Status: http.StatusNotFound, Code: "not_found", Message: "not found"}
+	case errors.Is(err, app.ErrPermissionDenied):
+		return &apiError{Status: http.StatusForbidden, Code: "permission_denied", Message: "permission denied"}
+	case errors.Is(err, app.ErrInvalidArgument):
+		return &apiError{Status: http.StatusBadRequest, Code: "invalid_argument", Message: "invalid argument"}
+	case errors.Is(err, app.ErrAlreadyExists):
+		return &apiError{Status: http.StatusConflict, Code: "already_exists", Message: "already exists"}
+	case errors.Is(err, app.ErrAuthenticationFailed):
+		return &apiError{Status: http.StatusUnauthorized, Code: "unauthenticated", Message: "authentication required"}
+	default:
+		return &apiError{Status: http.StatusInternalServerError, Code: "internal", Message: "internal server error"}
+	}
+}
+
+// apiErrorMiddleware はハンドラが返したエラーを JSON のエラーのレスポンスにする
+// ハンドラでは app のエラーをそのまま返せば, ステータスコードとエラーの本文はここで揃える
+func apiErrorMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			if err == nil || c.Response().Committed {
+				return err
+			}
+			ae := toAPIError(err)
+			if ae.Status == http.StatusUnauthorized {
+				c.Response().Header().Add(echo.HeaderWWWAuthenticate, `Bearer realm="API"`)
+				c.Response().Header().Add(echo.HeaderWWWAuthenticate, `Basic realm="API"`)
+			}
+			if jsonErr := c.JSON(ae.Status, map[string]interface{}{"error": ae}); jsonErr != nil {
+				return jsonErr
+			}
+			// 内部エラーはログやトレースに残るよう, レスポンスを書いた後もエラーとして返す
+			if ae.Status == http.StatusInternalServerError {
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+// apiAuthMiddleware は Basic 認証でユーザー名と API キーを確かめ, 認証したユーザーをセッションのユーザーの代わりに使う
+// JSON API はクッキーのセッションを使わないので, CSRF のトークンがなくても他のサイトから操作されることはない
+// 認証情報を送らないリクエストは, サインインしていないユーザーとして扱う
+// パーソナルアクセストークンは, この後の accessTokenMiddleware で認証する
+func (s *Server) apiAuthMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cc := c.(*CustomContext)
+			cc.User = nil
+			if name, key, ok := c.Request().BasicAuth(); ok {
+				user, err := s.app.AuthenticateByAPIKey(c.Request().Context(), name, key)
+				if err != nil {
+					return err
+				}
+				cc.User = user
+			}
+			return next(cc)
+		}
+	}
+}
+
+// requireAPIUserMiddleware は認証していないリクエストを 401 で断る
+func requireAPIUserMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if getSessionUser(c) == nil {
+				return app.ErrAuthenticationFailed
+			}
+			return next(c)
+		}
+	}
+}
+
+// parseAPIPage は一覧の cursor と limit のクエリパラメーターをパースする
+func parseAPIPage(c echo.Context) (*domain.Cursor, int, error) {
+	var cursor *domain.Cursor
+	if str := c.QueryParam("cursor"); str != "" {
+		var err error
+		cursor, err = domain.ParseCursor(str)
+		if err != nil {
+			return nil, 0, errInvalidParam("invalid cursor")
+		}
+	}
+	limit := apiDefaultLimit
+	if str := c.QueryParam("limit"); str != "" {
+		var err error
+		limit, err = strconv.Atoi(str)
+		if err != nil || limit < 1 || limit > apiMaxLimit {
+			return nil, 0, errInvalidParam(fmt.Sprintf("limit must be between 1 and %d", apiMaxLimit))
+		}
+	}
+	return cursor, limit, nil
+}
+
+// apiList は一覧の 1 ページを表す. 前後のページがない場合はそれぞれのカーソルを省く
+type apiList[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+func newAPIList[T any](items []T, page *domain.Page) *apiList[T] {
+	l := &apiList[T]{Items: items}
+	if page.Next != nil {
+		l.NextCursor = page.Next.String()
+	}
+	if page.Prev != nil {
+		l.PrevCursor = page.Prev.String()
+	}
+	return l
+}
+
+type apiBlog struct {
+	ID              domain.BlogID     `json:"id,string"`
+	Path            string            `json:"path"`
+	Title           string            `json:"title"`
+	Description     string            `json:"description"`
+	Visibility      domain.Visibility `json:"visibility"`
+	CommentsEnabled bool              `json:"comments_enabled"`
+	CreatedAt       time.Time         `json:"created_at"`
+	URL             string            `json:"url"`
+}
+
+func (s *Server) apiBlog(blog *domain.Blog) *apiBlog {
+	return &apiBlog{
+		ID:              blog.ID,
+		Path:            blog.Path,
+		Title:           blog.Title,
+		Description:     blog.Description,
+		Visibility:      blog.Visibility,
+		CommentsEnabled: blog.CommentsEnabled,
+		CreatedAt:       blog.CreatedAt,
+		URL:             fmt.Sprintf("%s/blogs/%s", s.baseURL, blog.Path),
+	}
+}
+
+type apiEntry struct {
+	ID          domain.EntryID     `json:"id,string"`
+	BlogID      domain.BlogID      `json:"blog_id,string"`
+	Author      string             `json:"author"`
+	Title       string             `json:"title"`
+	Slug        string             `json:"slug"`
+	Body        string             `json:"body"`
+	BodyHTML    string             `json:"body_html"`
+	Format      domain.EntryFormat `json:"format"`
+	Status      domain.EntryStatus `json:"status"`
+	Visibility  domain.Visibility  `json:"visibility"`
+	Tags        []string           `json:"tags"`
+	PublishedAt time.Time          `json:"published_at"`
+	EditedAt    time.Time          `json:"edited_at"`
+	URL         string             `json:"url"`
+}
+
+func (s *Server) apiEntry(blog *domain.Blog, entry *domain.Entry, author string, tags []*domain.Tag) *apiEntry {
+	tagNames := make([]string, 0, len(tags))
+	for _, t := range tags {
+		tagNames = append(tagNames, t.Name)
+	}
+	return &apiEntry{
+		ID:          entry.ID,
+		BlogID:      entry.BlogID,
+		Author:      author,
+		Title:       entry.Title,
+		Slug:        entry.Slug,
+		Body:        entry.Body,
+		BodyHTML:    entry.BodyHTML,
+		Format:      entry.Format,
+		Status:      entry.Status,
+		Visibility:  entry.VisibilityIn(blog),
+		Tags:        tagNames,
+		PublishedAt: entry.PublishedAt,
+		EditedAt:    entry.EditedAt,
+		URL:         fmt.Sprintf("%s/blogs/%s/%s", s.baseURL, blog.Path, entry.Permalink()),
+	}
+}
+
+// apiEntries はエントリのタグと書いたメンバーをまとめて読み, JSON で返すエントリにする
+func (s *Server) apiEntries(c echo.Context, blog *domain.Blog, entries []*domain.Entry) ([]*apiEntry, error) {
+	tags, err := s.app.ListTagsByEntries(c.Request().Context(), entries)
+	if err != nil {
+		return nil, err
+	}
+	authors, err := s.app.ListEntryAuthors(c.Request().Context(), entries)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*apiEntry, 0, len(entries))
+	for _, e := range entries {
+		var author string
+		if u, ok := authors[e.AuthorID]; ok {
+			author = u.Name
+		}
+		items = append(items, s.apiEntry(blog, e, author, tags[e.ID]))
+	}
+	return items, nil
+}
+
+// findAPIBlog はパスのブログを検索する. ユーザーが読めないブログは見つからないものとして扱う
+func (s *Server) findAPIBlog(c echo.Context) (*domain.Blog, error) {
+	blog, err := s.findBlogByPath(c, c.Param("path"))
+	if err != nil {
+		return nil, err
+	}
+	canView, err := s.app.CanViewBlog(c.Request().Context(), getSessionUser(c), blog)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, app.ErrNotFound
+	}
+	return blog, nil
+}
+
+// findAPIEntry はパスのブログとエントリを検索する
+func (s *Server) findAPIEntry(c echo.Context) (*domain.Blog, *domain.Entry, error) {
+	blog, err := s.findAPIBlog(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	entryID, err := domain.ParseEntryID(c.Param("id"))
+	if err != nil {
+		return nil, nil, errInvalidParam("invalid id")
+	}
+	entry, err := s.app.FindEntryByID(c.Request().Context(), getSessionUser(c), blog, entryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return blog, entry, nil
+}
+
+func (s *Server) APIOpenAPIHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.Blob(http.StatusOK, "application/yaml", openapi.Document)
+	}
+}
+
+func (s *Server) APIListBlogsHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cursor, limit, err := parseAPIPage(c)
+		if err != nil {
+			return err
+		}
+		blogs, page, err := s.app.ListBlogs(c.Request().Context(), cursor, limit)
+		if err != nil {
+			return err
+		}
+		items := make([]*apiBlog, 0, len(blogs))
+		for _, b := range blogs {
+			items = append(items, s.apiBlog(b))
+		}
+		return c.JSON(http.StatusOK, newAPIList(items, page))
+	}
+}
+
+func (s *Server) APIListMyBlogsHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		cursor, limit, err := parseAPIPage(c)
+		if err != nil {
+			return err
+		}
+		blogs, page, err := s.app.ListBlogsByUser(c.Request().Context(), user, cursor, limit)
+		if err != nil {
+			return err
+		}
+		items := make([]*apiBlog, 0, len(blogs))
+		for _, b := range blogs {
+			items = append(items, s.apiBlog(b))
+		}
+		return c.JSON(http.StatusOK, newAPIList(items, page))
+	}
+}
+
+func (s *Server) APICreateBlogHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		params := new(struct {
+			Path        string `json:"path"`
+			Title       string `json:"title"`
+			Description string `json:"description"`
+		})
+		if err := c.Bind(params); err != nil {
+			return err
+		}
+		blog, err := s.app.CreateBlog(c.Request().Context(), user, params.Path, params.Title, params.Description)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("%s/api/v1/blogs/%s", s.baseURL, blog.Path))
+		return c.JSON(http.StatusCreated, s.apiBlog(blog))
+	}
+}
+
+func (s *Server) APIBlogHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		blog, err := s.findAPIBlog(c)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, s.apiBlog(blog))
+	}
+}
+
+func (s *Server) APIEditBlogHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		blog, err := s.findAPIBlog(c)
+		if err != nil {
+			return err
+		}
+		// 指定されなかった項目は今の値のままにする
+		params := new(struct {
+			Title       *string `json:"title"`
+			Description *string `json:"description"`
+		})
+		if err := c.Bind(params); err != nil {
+			return err
+		}
+		title, description := blog.Title, blog.Description
+		if params.Title != nil {
+			title = *params.Title
+		}
+		if params.Description != nil {
+			description = *params.Description
+		}
+		blog, err = s.app.EditBlog(c.Request().Context(), user, blog, title, description)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, s.apiBlog(blog))
+	}
+}
+
+func (s *Server) APIListEntriesHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		blog, err := s.findAPIBlog(c)
+		if err != nil {
+			return err
+		}
+		cursor, limit, err := parseAPIPage(c)
+		if err != nil {
+			return err
+		}
+		// status=all では下書きや非公開のエントリも含める. 下書きを読めるメンバーだけが使える
+		var entries []*domain.Entry
+		var page *domain.Page
+		switch c.QueryParam("status") {
+		case "", "published":
+			entries, page, err = s.app.ListEntriesByBlog(c.Request().Context(), user, blog, cursor, limit)
+		case "all":
+			entries, page, err = s.app.ListAllEntriesByBlog(c.Request().Context(), user, blog, cursor, limit)
+		default:
+			return errInvalidParam("status must be published or all")
+		}
+		if err != nil {
+			return err
+		}
+		items, err := s.apiEntries(c, blog, entries)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, newAPIList(items, page))
+	}
+}
+
+func (s *Server) APICreateEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		blog, err := s.findAPIBlog(c)
+		if err != nil {
+			return err
+		}
+		params := new(struct {
+			Title string   `json:"title"`
+			Slug  string   `json:"slug"`
+			Body  string   `json:"body"`
+			Tags  []string `json:"tags"`
+			Draft bool     `json:"draft"`
+			// PublishedAt が未来の日時であれば予約投稿にする
+			PublishedAt time.Time `json:"published_at"`
+		})
+		if err := c.Bind(params); err != nil {
+			return err
+		}
+		var entry *domain.Entry
+		if params.Draft {
+			entry, err = s.app.SaveDraftEntry(c.Request().Context(), user, blog, params.Title, params.Slug, params.Body, params.Tags)
+		} else {
+			entry, err = s.app.PublishEntry(c.Request().Context(), user, blog, params.Title, params.Slug, params.Body, params.Tags, params.PublishedAt)
+		}
+		if err != nil {
+			return err
+		}
+		items, err := s.apiEntries(c, blog, []*domain.Entry{entry})
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("%s/api/v1/blogs/%s/entries/%s", s.baseURL, blog.Path, entry.ID))
+		return c.JSON(http.StatusCreated, items[0])
+	}
+}
+
+func (s *Server) APIEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		blog, entry, err := s.findAPIEntry(c)
+		if err != nil {
+			return err
+		}
+		items, err := s.apiEntries(c, blog, []*domain.Entry{entry})
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, items[0])
+	}
+}
+
+func (s *Server) APIEditEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		blog, entry, err := s.findAPIEntry(c)
+		if err != nil {
+			return err
+		}
+		// 指定されなかった項目は今の値のままにする
+		params := new(struct {
+			Title       *string    `json:"title"`
+			Slug        *string    `json:"slug"`
+			Body        *string    `json:"body"`
+			Tags        *[]string  `json:"tags"`
+			Draft       *bool      `json:"draft"`
+			PublishedAt *time.Time `json:"published_at"`
+		})
+		if err := c.Bind(params); err != nil {
+			return err
+		}
+		if params.Title != nil || params.Slug != nil || params.Body != nil || params.Tags != nil {
+			title, body := entry.Title, entry.Body
+			if params.Title != nil {
+				title = *params.Title
+			}
+			if params.Body != nil {
+				body = *params.Body
+			}
+			var slug string
+			if params.Slug != nil {
+				slug = *params.Slug
+			} else if entry.HasCustomSlug() {
+				slug = entry.Slug
+			}
+			var tagNames []string
+			if params.Tags != nil {
+				tagNames = *params.Tags
+			} else {
+				tags, err := s.app.ListTagsByEntry(c.Request().Context(), entry)
+				if err != nil {
+					return err
+				}
+				for _, t := range tags {
+					tagNames = append(tagNames, t.Name)
+				}
+			}
+			entry, err = s.app.EditEntry(c.Request().Context(), user, blog, entry, title, slug, body, tagNames)
+			if err != nil {
+				return err
+			}
+		}
+		// draft を変えた場合は, フォームの公開と非公開の操作と同じように公開状態を変える
+		if params.Draft != nil {
+			switch {
+			case *params.Draft && (entry.IsPublished() || entry.IsScheduled()):
+				if err := s.app.UnpublishEntry(c.Request().Context(), user, blog, entry); err != nil {
+					return err
+				}
+				entry, err = s.app.FindEntryByID(c.Request().Context(), user, blog, entry.ID)
+				if err != nil {
+					return err
+				}
+			case !*params.Draft && !entry.IsPublished() && !entry.IsScheduled():
+				var publishAt time.Time
+				if params.PublishedAt != nil {
+					publishAt = *params.PublishedAt
+				}
+				entry, err = s.app.PublishDraftEntry(c.Request().Context(), user, blog, entry, publishAt)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		items, err := s.apiEntries(c, blog, []*domain.Entry{entry})
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, items[0])
+	}
+}
+
+func (s *Server) APIDeleteEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		blog, entry, err := s.findAPIEntry(c)
+		if err != nil {
+			return err
+		}
+		// フォームから削除したときと同じくゴミ箱に移動し, 保管期間の間は元に戻せるようにする
+		if err := s.app.TrashEntry(c.Request().Context(), user, blog, entry); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func (s *Server) APIPreviewEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		blog, err := s.findAPIBlog(c)
+		if err != nil {
+			return err
+		}
+		params := new(struct {
+			Body string `json:"body"`
+		})
+		if err := c.Bind(params); err != nil {
+			return err
+		}
+		html, err := s.app.PreviewEntry(c.Request().Context(), user, blog, params.Body)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, map[string]string{"body_html": html})
+	}
+}
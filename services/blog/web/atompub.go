@@ -0,0 +1,414 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/atompub"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// atomPubEntriesLimit はコレクションの一覧の 1 ページに含めるエントリの数
+const atomPubEntriesLimit = 10
+
+// atomPubAuthMiddleware は WSSE 認証か Basic 認証で API キーを確かめ, 認証したユーザーをセッションのユーザーの代わりに使う
+// AtomPub のクライアントはクッキーを送らないので, サインインしていてもクッキーのユーザーは使わない
+func (s *Server) atomPubAuthMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cc := c.(*CustomContext)
+			var user *domain.User
+			var err error
+			if header := c.Request().Header.Get("X-WSSE"); header != "" {
+				token, parseErr := atompub.ParseWSSE(header)
+				if parseErr != nil {
+					return atomPubUnauthorized(c)
+				}
+				now := time.Now()
+				user, err = s.app.AuthenticateByAPIKeyFunc(c.Request().Context(), token.Username, func(key string) bool {
+					return token.Verify(key, now)
+				})
+				// 一度使われたヘッダは Created が古くなるまでの間も受け付けない
+				if err == nil && !s.wsseNonces.Use(token, now) {
+					return atomPubUnauthorized(c)
+				}
+			} else if name, key, ok := c.Request().BasicAuth(); ok {
+				user, err = s.app.AuthenticateByAPIKey(c.Request().Context(), name, key)
+			} else {
+				return atomPubUnauthorized(c)
+			}
+			if err != nil {
+				if errors.Is(err, app.ErrAuthenticationFailed) {
+					return atomPubUnauthorized(c)
+				}
+				return err
+			}
+			cc.User = user
+			return next(cc)
+		}
+	}
+}
+
+func atomPubUnauthorized(c echo.Context) error {
+	c.Response().Header().Add(echo.HeaderWWWAuthenticate, `WSSE profile="UsernameToken"`)
+	c.Response().Header().Add(echo.HeaderWWWAuthenticate, `Basic realm="AtomPub"`)
+	return c.String(http.StatusUnauthorized, "unauthorized")
+}
+
+// atomPubCollectionURL はブログのエントリのコレクションの URL を返す
+func (s *Server) atomPubCollectionURL(blog *domain.Blog) string {
+	return fmt.Sprintf("%s/blogs/%s/atom/entry", s.baseURL, blog.Path)
+}
+
+func (s *Server) atomPubEntry(blog *domain.Blog, entry *domain.Entry, author string, tags []*domain.Tag) *atompub.Entry {
+	contentType := atompub.ContentTypeMarkdown
+	if entry.Format == domain.EntryFormatHTML {
+		contentType = atompub.ContentTypeHTML
+	}
+	categories := make([]string, 0, len(tags))
+	for _, t := range tags {
+		categories = append(categories, t.Name)
+	}
+	var customURL string
+	if entry.HasCustomSlug() {
+		customURL = entry.Slug
+	}
+	return &atompub.Entry{
+		ID:               s.feedTagURI(fmt.Sprintf("entries/%s", entry.ID)),
+		EditURL:          fmt.Sprintf("%s/%s", s.atomPubCollectionURL(blog), entry.ID),
+		AlternateURL:     fmt.Sprintf("%s/blogs/%s/%s", s.baseURL, blog.Path, entry.Permalink()),
+		Author:           author,
+		Title:            entry.Title,
+		Published:        entry.PublishedAt,
+		Edited:           entry.EditedAt,
+		Content:          entry.Body,
+		ContentType:      contentType,
+		FormattedContent: entry.BodyHTML,
+		Categories:       categories,
+		CustomURL:        customURL,
+		Draft:            !entry.IsPublished() && !entry.IsScheduled(),
+	}
+}
+
+// writeAtomPubEntry はエントリのタグと書いたメンバーを読み, エントリを Atom で書き出す
+func (s *Server) writeAtomPubEntry(c echo.Context, code int, blog *domain.Blog, entry *domain.Entry) error {
+	tags, err := s.app.ListTagsByEntry(c.Request().Context(), entry)
+	if err != nil {
+		return err
+	}
+	authors, err := s.app.ListEntryAuthors(c.Request().Context(), []*domain.Entry{entry})
+	if err != nil {
+		return err
+	}
+	var author string
+	if u, ok := authors[entry.AuthorID]; ok {
+		author = u.Name
+	}
+	e := s.atomPubEntry(blog, entry, author, tags)
+	if code == http.StatusCreated {
+		c.Response().Header().Set(echo.HeaderLocation, e.EditURL)
+	}
+	c.Response().Header().Set(echo.HeaderContentType, atompub.EntryContentType)
+	c.Response().WriteHeader(code)
+	return e.WriteEntry(c.Response())
+}
+
+func (s *Server) AtomPubServiceHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionReadDrafts); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		service := &atompub.Service{
+			Title:         blog.Title,
+			CollectionURL: s.atomPubCollectionURL(blog),
+		}
+		c.Response().Header().Set(echo.HeaderContentType, atompub.ServiceContentType)
+		c.Response().WriteHeader(http.StatusOK)
+		return service.WriteService(c.Response())
+	}
+}
+
+func (s *Server) AtomPubCollectionHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		cursor, err := parsePageCursor(c)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid page")
+		}
+		entries, page, err := s.app.ListAllEntriesByBlog(c.Request().Context(), user, blog, cursor, atomPubEntriesLimit)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid page")
+			}
+			return err
+		}
+		tags, err := s.app.ListTagsByEntries(c.Request().Context(), entries)
+		if err != nil {
+			return err
+		}
+		authors, err := s.app.ListEntryAuthors(c.Request().Context(), entries)
+		if err != nil {
+			return err
+		}
+		collectionURL := s.atomPubCollectionURL(blog)
+		f := &atompub.Feed{
+			ID:           s.feedTagURI(fmt.Sprintf("blogs/%d", blog.ID)),
+			Title:        blog.Title,
+			Author:       user.Name,
+			Updated:      time.Now(),
+			AlternateURL: fmt.Sprintf("%s/blogs/%s", s.baseURL, blog.Path),
+			FirstURL:     collectionURL,
+			Entries:      make([]*atompub.Entry, 0, len(entries)),
+		}
+		if page.Next != nil {
+			f.NextURL = fmt.Sprintf("%s?page=%s", collectionURL, page.Next)
+		}
+		for _, e := range entries {
+			var author string
+			if u, ok := authors[e.AuthorID]; ok {
+				author = u.Name
+			}
+			f.Entries = append(f.Entries, s.atomPubEntry(blog, e, author, tags[e.ID]))
+		}
+		c.Response().Header().Set(echo.HeaderContentType, atompub.FeedContentType)
+		c.Response().WriteHeader(http.StatusOK)
+		return f.WriteFeed(c.Response())
+	}
+}
+
+func (s *Server) AtomPubPostEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		input, err := atompub.ParseEntry(c.Request().Body)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid entry")
+		}
+		// フォームから投稿したときと同じく, 下書きでなければ updated の日時で公開し, 未来の日時であれば予約投稿にする
+		var entry *domain.Entry
+		if input.Draft {
+			entry, err = s.app.SaveDraftEntry(c.Request().Context(), user, blog, input.Title, input.CustomURL, input.Content, input.Categories)
+		} else {
+			entry, err = s.app.PublishEntry(c.Request().Context(), user, blog, input.Title, input.CustomURL, input.Content, input.Categories, input.Updated)
+		}
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid params")
+			}
+			if errors.Is(err, app.ErrAlreadyExists) {
+				return c.String(http.StatusConflict, "custom-url already used")
+			}
+			return err
+		}
+		return s.writeAtomPubEntry(c, http.StatusCreated, blog, entry)
+	}
+}
+
+func (s *Server) AtomPubEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionReadDrafts); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		return s.writeAtomPubEntry(c, http.StatusOK, blog, entry)
+	}
+}
+
+func (s *Server) AtomPubEditEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		input, err := atompub.ParseEntry(c.Request().Body)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid entry")
+		}
+		// custom-url を送らないクライアントもあるので, 指定されなければ今のスラッグを保つ
+		slug := input.CustomURL
+		if slug == "" && entry.HasCustomSlug() {
+			slug = entry.Slug
+		}
+		entry, err = s.app.EditEntry(c.Request().Context(), user, blog, entry, input.Title, slug, input.Content, input.Categories)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid params")
+			}
+			if errors.Is(err, app.ErrAlreadyExists) {
+				return c.String(http.StatusConflict, "custom-url already used")
+			}
+			return err
+		}
+		// app:draft が変わった場合は, フォームの公開と非公開の操作と同じように公開状態を変える
+		switch {
+		case input.Draft && (entry.IsPublished() || entry.IsScheduled()):
+			if err := s.app.UnpublishEntry(c.Request().Context(), user, blog, entry); err != nil {
+				return err
+			}
+			entry, err = s.app.FindEntryByID(c.Request().Context(), user, blog, entry.ID)
+			if err != nil {
+				return err
+			}
+		case !input.Draft && !entry.IsPublished() && !entry.IsScheduled():
+			entry, err = s.app.PublishDraftEntry(c.Request().Context(), user, blog, entry, input.Updated)
+			if err != nil {
+				return err
+			}
+		}
+		return s.writeAtomPubEntry(c, http.StatusOK, blog, entry)
+	}
+}
+
+func (s *Server) AtomPubDeleteEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		// フォームから削除したときと同じくゴミ箱に移動し, 保管期間の間は元に戻せるようにする
+		if err := s.app.TrashEntry(c.Request().Context(), user, blog, entry); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+func (s *Server) MyAPIKeyHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		apiKey, err := s.app.FindOrCreateAPIKey(c.Request().Context(), user)
+		if err != nil {
+			return err
+		}
+		return c.Render(http.StatusOK, "my-api-key.html", map[string]interface{}{
+			"User":    user,
+			"APIKey":  apiKey,
+			"BaseURL": s.baseURL,
+		})
+	}
+}
+
+func (s *Server) RegenerateAPIKeyHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		if _, err := s.app.RegenerateAPIKey(c.Request().Context(), user); err != nil {
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, "/my/api-key")
+	}
+}
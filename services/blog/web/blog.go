@@ -3,7 +3,10 @@ package web
 import (
 	"errors"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
+	"time"
 
 	echo "github.com/labstack/echo/v4"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
@@ -14,28 +17,67 @@ func (s *Server) BlogHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		page, _ := strconv.Atoi(c.QueryParam("page"))
-		if page < 1 {
-			page = 1
+		canView, err := s.app.CanViewBlog(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
+		}
+		if !canView {
+			return c.String(http.StatusNotFound, "not found")
+		}
+		cursor, err := parsePageCursor(c)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid page")
+		}
+		entries, page, err := s.app.ListEntriesByBlog(c.Request().Context(), user, blog, cursor, 5)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid page")
+			}
+			return err
+		}
+		entryTags, err := s.app.ListTagsByEntries(c.Request().Context(), entries)
+		if err != nil {
+			return err
+		}
+		tagCounts, err := s.app.ListTagCountsByBlog(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
+		}
+		popularEntries, err := s.app.ListPopularEntriesByBlog(c.Request().Context(), user, blog, time.Now(), 5)
+		if err != nil {
+			return err
+		}
+		// 人気のエントリのスターの数もまとめて数える
+		starredEntries := make([]*domain.Entry, 0, len(entries)+len(popularEntries))
+		starredEntries = append(append(starredEntries, entries...), popularEntries...)
+		starCounts, err := loadStarCounts(c, starredEntries)
+		if err != nil {
+			return err
+		}
+		role, err := s.app.RoleOf(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
 		}
-		// TODO: we should care this err?
-		entries, hasNextPage, _ := s.app.ListEntriesByBlog(c.Request().Context(), blog, page, 5)
 		return c.Render(http.StatusOK, "blog.html", map[string]interface{}{
-			"IsAuthor":    user != nil && blog.UserID == user.ID,
-			"Blog":        blog,
-			"Entries":     entries,
-			"Page":        page,
-			"PrevPage":    page - 1,
-			"NextPage":    page + 1,
-			"HasPrevPage": page > 1,
-			"HasNextPage": hasNextPage,
+			"IsMember":       role.Can(domain.PermissionReadDrafts),
+			"CanWrite":       role.Can(domain.PermissionWriteEntries),
+			"Blog":           blog,
+			"Entries":        entries,
+			"EntryTags":      entryTags,
+			"StarCounts":     starCounts,
+			"TagCounts":      tagCounts,
+			"PopularEntries": popularEntries,
+			"PrevPage":       page.Prev,
+			"NextPage":       page.Next,
+			"HasPrevPage":    page.Prev != nil,
+			"HasNextPage":    page.Next != nil,
 		})
 	}
 }
@@ -44,28 +86,193 @@ func (s *Server) EntryHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		entryID, err := domain.ParseEntryID(c.Param("id"))
-		if err != nil {
-			return c.String(http.StatusBadRequest, "invalid id")
+		// パーマリンクではスラッグで, 以前からの URL ではエントリの ID で検索する
+		var entry *domain.Entry
+		var entryID domain.EntryID
+		slug := c.Param("slug")
+		if slug != "" {
+			entry, err = s.app.FindEntryBySlug(c.Request().Context(), user, blog, slug)
+			// スラッグを変更したエントリは, 古いスラッグからも下のリダイレクトで今のパーマリンクに移る
+			if errors.Is(err, app.ErrNotFound) {
+				entry, err = s.app.FindEntryByOldSlug(c.Request().Context(), user, blog, slug)
+			}
+		} else {
+			entryID, err = domain.ParseEntryID(c.Param("id"))
+			if err != nil {
+				return c.String(http.StatusBadRequest, "invalid id")
+			}
+			entry, err = s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		}
+		// 読めないエントリでも共有リンクのトークンがあれば読めるようにする
+		shared := false
+		if errors.Is(err, app.ErrNotFound) && c.QueryParam("share") != "" {
+			entry, err = s.app.FindSharedEntry(c.Request().Context(), blog, c.QueryParam("share"))
+			if err == nil && !(slug != "" && entry.Slug == slug || slug == "" && entry.ID == entryID) {
+				err = app.ErrNotFound
+			}
+			shared = true
 		}
-		entry, err := s.app.FindEntryByID(c.Request().Context(), blog, entryID)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
+		// ID での URL や公開日が違う URL はパーマリンクにリダイレクトする
+		permalink := "/blogs/" + blog.Path + "/" + entry.Permalink()
+		if c.Request().URL.Path != permalink {
+			if c.QueryString() != "" {
+				permalink += "?" + c.QueryString()
+			}
+			return c.Redirect(http.StatusMovedPermanently, permalink)
+		}
+		tags, err := s.app.ListTagsByEntry(c.Request().Context(), entry)
+		if err != nil {
+			return err
+		}
+		cursor, err := parsePageCursor(c)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid page")
+		}
+		comments, page, err := s.app.ListCommentsByEntry(c.Request().Context(), entry, cursor, 50)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid page")
+			}
+			return err
+		}
+		commentUsers, err := s.app.ListCommentUsers(c.Request().Context(), comments)
+		if err != nil {
+			return err
+		}
+		authors, err := s.app.ListEntryAuthors(c.Request().Context(), []*domain.Entry{entry})
+		if err != nil {
+			return err
+		}
+		starCounts, err := s.app.CountStars(c.Request().Context(), []*domain.Entry{entry})
+		if err != nil {
+			return err
+		}
+		starred, err := s.app.ListStarredEntryIDs(c.Request().Context(), user, []*domain.Entry{entry})
+		if err != nil {
+			return err
+		}
+		role, err := s.app.RoleOf(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
+		}
+		if !isBotRequest(c.Request()) {
+			s.app.RecordEntryView(user, entry, time.Now())
+		}
 		return c.Render(http.StatusOK, "entry.html", map[string]interface{}{
-			"IsAuthor": user != nil && blog.UserID == user.ID,
-			"Blog":     blog,
-			"Entry":    entry,
+			"User":         user,
+			"IsMember":     role.Can(domain.PermissionReadDrafts),
+			"CanWrite":     role.Can(domain.PermissionWriteEntries),
+			"CanModerate":  role.Can(domain.PermissionModerateComments),
+			"Blog":         blog,
+			"Entry":        entry,
+			"Author":       authors[entry.AuthorID],
+			"Shared":       shared,
+			"ShareToken":   c.QueryParam("share"),
+			"StarCount":    starCounts[entry.ID],
+			"Starred":      starred[entry.ID],
+			"Tags":         tags,
+			"Comments":     comments,
+			"CommentUsers": commentUsers,
+			"PrevPage":     page.Prev,
+			"NextPage":     page.Next,
+			"HasPrevPage":  page.Prev != nil,
+			"HasNextPage":  page.Next != nil,
+		})
+	}
+}
+
+func (s *Server) TagHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		canView, err := s.app.CanViewBlog(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
+		}
+		if !canView {
+			return c.String(http.StatusNotFound, "not found")
+		}
+		tagName, err := unescapedParam(c, "tag")
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid tag")
+		}
+		page, _ := strconv.Atoi(c.QueryParam("page"))
+		if page < 1 {
+			page = 1
+		}
+		entries, hasNextPage, err := s.app.ListEntriesByBlogAndTag(c.Request().Context(), user, blog, tagName, page, 5)
+		if err != nil {
+			return err
+		}
+		entryTags, err := s.app.ListTagsByEntries(c.Request().Context(), entries)
+		if err != nil {
+			return err
+		}
+		starCounts, err := s.app.CountStars(c.Request().Context(), entries)
+		if err != nil {
+			return err
+		}
+		role, err := s.app.RoleOf(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
+		}
+		return c.Render(http.StatusOK, "blog-tag.html", map[string]interface{}{
+			"IsMember":    role.Can(domain.PermissionReadDrafts),
+			"CanWrite":    role.Can(domain.PermissionWriteEntries),
+			"Blog":        blog,
+			"TagName":     tagName,
+			"Entries":     entries,
+			"EntryTags":   entryTags,
+			"StarCounts":  starCounts,
+			"Page":        page,
+			"PrevPage":    page - 1,
+			"NextPage":    page + 1,
+			"HasPrevPage": page > 1,
+			"HasNextPage": hasNextPage,
 		})
 	}
 }
+
+// unescapedParam はパスのパラメーターをエスケープを外して返す
+// echo はパラメーターのエスケープを外して渡すが, %2F のようにエスケープしないと表せない文字がパスにあると
+// エスケープされたままのパスでルーティングし, パラメーターもエスケープされたまま渡すので, そのときだけここで外す
+func unescapedParam(c echo.Context, name string) (string, error) {
+	value := c.Param(name)
+	if c.Request().URL.RawPath == "" {
+		return value, nil
+	}
+	return url.PathUnescape(value)
+}
+
+// botUserAgentRE はクローラーやリンクのプレビュー, スクリプトなど, 人が読んでいないとみなす User-Agent にマッチする
+var botUserAgentRE = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|archiver|preview|facebookexternalhit|embedly|headless|lighthouse|curl|wget|python|java/|go-http-client|okhttp|libwww|httpclient|feed`)
+
+// isBotRequest はエントリの閲覧数に数えないボットのリクエストかを判定する
+// User-Agent がないものやボットのもの, ブラウザが先読みしたものをボットとみなす
+func isBotRequest(r *http.Request) bool {
+	if r.Header.Get("Sec-Purpose") != "" || r.Header.Get("Purpose") == "prefetch" || r.Header.Get("X-Moz") == "prefetch" {
+		return true
+	}
+	ua := r.UserAgent()
+	return ua == "" || botUserAgentRE.MatchString(ua)
+}
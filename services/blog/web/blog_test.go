@@ -0,0 +1,44 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	echo "github.com/labstack/echo/v4"
+)
+
+func TestUnescapedParam(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/tags/go", "go"},
+		{"/tags/100%25", "100%"},
+		{"/tags/%2525xx", "%25xx"},
+		{"/tags/%E6%97%A5%E6%9C%AC%E8%AA%9E", "日本語"},
+		{"/tags/a%20b", "a b"},
+		{"/tags/a+b", "a+b"},
+		{"/tags/a%2Fb", "a/b"},
+		{"/tags/100%25%2F%2525", "100%/%25"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			e := echo.New()
+			var got string
+			e.GET("/tags/:tag", func(c echo.Context) error {
+				var err error
+				got, err = unescapedParam(c, "tag")
+				return err
+			})
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d", rec.Code)
+			}
+			if got != tt.want {
+				t.Errorf("tag = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
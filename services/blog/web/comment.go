@@ -0,0 +1,263 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func (s *Server) PostCommentHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		params := new(struct {
+			Body string `form:"body"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		_, err = s.app.PostComment(c.Request().Context(), user, blog, entry, params.Body)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid comment")
+			}
+			if errors.Is(err, app.ErrCommentsDisabled) {
+				return c.String(http.StatusForbidden, "comments disabled")
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/blogs/%s/%s#comments", blog.Path, entry.Permalink()))
+	}
+}
+
+func (s *Server) MyBlogCommentsHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionReadDrafts); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		status := domain.CommentStatus(c.QueryParam("status"))
+		if status == "" {
+			status = domain.CommentStatusPending
+		}
+		page, _ := strconv.Atoi(c.QueryParam("page"))
+		if page < 1 {
+			page = 1
+		}
+		comments, hasNextPage, err := s.app.ListCommentsByBlog(c.Request().Context(), user, blog, status, page, 20)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid status")
+			}
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		users, err := s.app.ListCommentUsers(c.Request().Context(), comments)
+		if err != nil {
+			return err
+		}
+		return c.Render(http.StatusOK, "my-blog-comments.html", map[string]interface{}{
+			"Blog":        blog,
+			"Status":      status,
+			"Comments":    comments,
+			"Users":       users,
+			"Page":        page,
+			"PrevPage":    page - 1,
+			"NextPage":    page + 1,
+			"HasPrevPage": page > 1,
+			"HasNextPage": hasNextPage,
+		})
+	}
+}
+
+func (s *Server) EditBlogCommentsHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionManageBlog); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		params := new(struct {
+			Enabled bool `form:"enabled"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		blog, err = s.app.SetBlogCommentsEnabled(c.Request().Context(), user, blog, params.Enabled)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/comments", blog.Path))
+	}
+}
+
+func (s *Server) ApproveCommentHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionModerateComments); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		commentID, err := domain.ParseCommentID(c.Param("comment_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		comment, err := s.app.FindCommentByID(c.Request().Context(), user, blog, commentID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		from := comment.Status
+		_, err = s.app.ApproveComment(c.Request().Context(), user, blog, comment)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/comments?status=%s", blog.Path, from))
+	}
+}
+
+func (s *Server) HideCommentHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionModerateComments); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		commentID, err := domain.ParseCommentID(c.Param("comment_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		comment, err := s.app.FindCommentByID(c.Request().Context(), user, blog, commentID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		from := comment.Status
+		_, err = s.app.HideComment(c.Request().Context(), user, blog, comment)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/comments?status=%s", blog.Path, from))
+	}
+}
+
+func (s *Server) DeleteCommentHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionModerateComments); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		commentID, err := domain.ParseCommentID(c.Param("comment_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		comment, err := s.app.FindCommentByID(c.Request().Context(), user, blog, commentID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		err = s.app.DeleteComment(c.Request().Context(), user, blog, comment)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/comments?status=%s", blog.Path, comment.Status))
+	}
+}
@@ -0,0 +1,103 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func (s *Server) MyBlogExportsHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		exports, err := s.app.ListBlogExports(c.Request().Context(), user, blog)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Render(http.StatusOK, "my-blog-exports.html", map[string]interface{}{
+			"Blog":      blog,
+			"Exports":   exports,
+			"Retention": app.BlogExportRetention,
+		})
+	}
+}
+
+func (s *Server) RequestBlogExportHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		_, err = s.app.RequestBlogExport(c.Request().Context(), user, blog)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/exports", blog.Path))
+	}
+}
+
+func (s *Server) DownloadBlogExportHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		exportID, err := domain.ParseBlogExportID(c.Param("export_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		export, err := s.app.FindBlogExport(c.Request().Context(), user, blog, exportID)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		r, err := s.app.OpenBlogExport(c.Request().Context(), user, blog, export)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		defer r.Close()
+		filename := fmt.Sprintf("%s-%s.zip", blog.Path, export.CreatedAt.UTC().Format("20060102"))
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+		c.Response().Header().Set(echo.HeaderContentLength, fmt.Sprint(export.Size))
+		c.Response().Header().Set("Cache-Control", "private, no-store")
+		return c.Stream(http.StatusOK, "application/zip", r)
+	}
+}
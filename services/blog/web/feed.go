@@ -0,0 +1,182 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/feed"
+)
+
+// feedEntriesLimit はフィードに含めるエントリの数
+const feedEntriesLimit = 20
+
+// feedTagDate はフィードの ID に使う tag URI (RFC 4151) の日付
+// 一度配信した ID を変えないよう, この値は変更しない
+const feedTagDate = "2025"
+
+// feedTagURI は baseURL のホスト名をもとにフィードやエントリの ID を作る
+// URL とは異なり, ブログのパスやエントリの URL が変わっても ID は変わらない
+func (s *Server) feedTagURI(specific string) string {
+	host := "localhost"
+	if u, err := url.Parse(s.baseURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return fmt.Sprintf("tag:%s,%s:%s", host, feedTagDate, specific)
+}
+
+func (s *Server) feedItem(blog *domain.Blog, entry *domain.Entry, author string) *feed.Item {
+	// 予約投稿されたエントリは公開日時より前に編集されているので, 公開日時より前の更新日時にはしない
+	updated := entry.EditedAt
+	if updated.Before(entry.PublishedAt) {
+		updated = entry.PublishedAt
+	}
+	return &feed.Item{
+		ID:          s.feedTagURI(fmt.Sprintf("entries/%s", entry.ID)),
+		Title:       entry.Title,
+		Link:        fmt.Sprintf("%s/blogs/%s/%s", s.baseURL, blog.Path, entry.Permalink()),
+		Author:      author,
+		ContentHTML: entry.BodyHTML,
+		Published:   entry.PublishedAt,
+		Updated:     updated,
+	}
+}
+
+func (s *Server) blogFeed(ctx context.Context, loaders *app.Loaders, blog *domain.Blog, self string) (*feed.Feed, error) {
+	entries, _, err := s.app.ListEntriesByBlog(ctx, nil, blog, nil, feedEntriesLimit)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := loaders.BlogOwner.Load(ctx, blog.UserID)
+	if err != nil {
+		return nil, err
+	}
+	author := blog.Title
+	if owner != nil {
+		author = owner.Name
+	}
+	f := &feed.Feed{
+		ID:          s.feedTagURI(fmt.Sprintf("blogs/%d", blog.ID)),
+		Title:       blog.Title,
+		Description: blog.Description,
+		Link:        fmt.Sprintf("%s/blogs/%s", s.baseURL, blog.Path),
+		Self:        s.baseURL + self,
+		Author:      author,
+		Items:       make([]*feed.Item, 0, len(entries)),
+	}
+	for _, e := range entries {
+		f.Items = append(f.Items, s.feedItem(blog, e, ""))
+	}
+	return f, nil
+}
+
+func (s *Server) recentFeed(ctx context.Context, loaders *app.Loaders, self string) (*feed.Feed, error) {
+	entries, blogs, err := s.app.ListRecentEntries(ctx, feedEntriesLimit)
+	if err != nil {
+		return nil, err
+	}
+	ownerIDs := make([]domain.UserID, 0, len(blogs))
+	for _, b := range blogs {
+		ownerIDs = append(ownerIDs, b.UserID)
+	}
+	owners, err := loaders.BlogOwner.LoadMany(ctx, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+	f := &feed.Feed{
+		ID:          s.feedTagURI("entries"),
+		Title:       "みんなのブログ",
+		Description: "すべてのブログの新着エントリ",
+		Link:        s.baseURL + "/",
+		Self:        s.baseURL + self,
+		Items:       make([]*feed.Item, 0, len(entries)),
+	}
+	for _, e := range entries {
+		blog, ok := blogs[e.BlogID]
+		if !ok {
+			continue
+		}
+		author := blog.Title
+		if owner, ok := owners[blog.UserID]; ok {
+			author = owner.Name
+		}
+		f.Items = append(f.Items, s.feedItem(blog, e, author))
+	}
+	return f, nil
+}
+
+func (s *Server) BlogAtomFeedHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		// フィードリーダーはサインインしていないので, 公開範囲が private のブログのフィードは誰にも配信しない
+		if blog.Visibility == domain.VisibilityPrivate {
+			return c.String(http.StatusNotFound, "not found")
+		}
+		f, err := s.blogFeed(c.Request().Context(), getLoaders(c), blog, c.Request().URL.Path)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, feed.AtomContentType)
+		c.Response().WriteHeader(http.StatusOK)
+		return f.WriteAtom(c.Response())
+	}
+}
+
+func (s *Server) BlogRSSFeedHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if blog.Visibility == domain.VisibilityPrivate {
+			return c.String(http.StatusNotFound, "not found")
+		}
+		f, err := s.blogFeed(c.Request().Context(), getLoaders(c), blog, c.Request().URL.Path)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, feed.RSSContentType)
+		c.Response().WriteHeader(http.StatusOK)
+		return f.WriteRSS(c.Response())
+	}
+}
+
+func (s *Server) AtomFeedHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		f, err := s.recentFeed(c.Request().Context(), getLoaders(c), c.Request().URL.Path)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, feed.AtomContentType)
+		c.Response().WriteHeader(http.StatusOK)
+		return f.WriteAtom(c.Response())
+	}
+}
+
+func (s *Server) RSSFeedHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		f, err := s.recentFeed(c.Request().Context(), getLoaders(c), c.Request().URL.Path)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, feed.RSSContentType)
+		c.Response().WriteHeader(http.StatusOK)
+		return f.WriteRSS(c.Response())
+	}
+}
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/importer"
+)
+
+// maxImportSize はアップロードして取り込めるファイルの大きさの上限
+// これより大きなファイルは import コマンドで取り込む
+const maxImportSize = 10 << 20
+
+func (s *Server) WillImportEntriesHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionManageBlog); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Render(http.StatusOK, "my-blog-import.html", map[string]interface{}{
+			"Blog":          blog,
+			"MaxImportSize": maxImportSize >> 20,
+		})
+	}
+}
+
+func (s *Server) ImportEntriesHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		// 取り込めないユーザーにはファイルを読む前に断る
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionManageBlog); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		format := importer.FileFormat(c.FormValue("format"))
+		if format != "" && format != importer.FileFormatMT && format != importer.FileFormatWXR {
+			return c.String(http.StatusBadRequest, "invalid format")
+		}
+		loc, err := importer.ParseTimezone(c.FormValue("timezone"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid timezone")
+		}
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return c.String(http.StatusBadRequest, "file is required")
+		}
+		if fileHeader.Size > maxImportSize {
+			return c.String(http.StatusRequestEntityTooLarge, "file too large")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		data, err := io.ReadAll(io.LimitReader(file, maxImportSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > maxImportSize {
+			return c.String(http.StatusRequestEntityTooLarge, "file too large")
+		}
+		entries, err := importer.Parse(data, format, loc)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid file")
+		}
+		results, err := s.app.ImportEntries(c.Request().Context(), user, blog, entries)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		var imported, skipped, failed int
+		for _, r := range results {
+			switch {
+			case r.Entry != nil:
+				imported++
+			case r.Skipped:
+				skipped++
+			default:
+				failed++
+			}
+		}
+		return c.Render(http.StatusOK, "my-blog-import.html", map[string]interface{}{
+			"Blog":          blog,
+			"MaxImportSize": maxImportSize >> 20,
+			"Results":       results,
+			"Imported":      imported,
+			"Skipped":       skipped,
+			"Failed":        failed,
+		})
+	}
+}
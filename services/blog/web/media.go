@@ -0,0 +1,171 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func (s *Server) MyBlogMediaHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		cursor, err := parsePageCursor(c)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid page")
+		}
+		media, page, err := s.app.ListMedia(c.Request().Context(), user, blog, cursor, 24)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid page")
+			}
+			return err
+		}
+		canWrite := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries) == nil
+		return c.Render(http.StatusOK, "my-blog-media.html", map[string]interface{}{
+			"Blog":         blog,
+			"Media":        media,
+			"CanWrite":     canWrite,
+			"MaxMediaSize": app.MaxMediaSize >> 20,
+			"PrevPage":     page.Prev,
+			"NextPage":     page.Next,
+			"HasPrevPage":  page.Prev != nil,
+			"HasNextPage":  page.Next != nil,
+		})
+	}
+}
+
+func (s *Server) UploadMediaHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return c.String(http.StatusBadRequest, "file is required")
+		}
+		if fileHeader.Size > app.MaxMediaSize {
+			return c.String(http.StatusRequestEntityTooLarge, "file too large")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		// ヘッダの大きさを信用せず, 上限を 1 バイト超えるところまでしか読まない
+		data, err := io.ReadAll(io.LimitReader(file, app.MaxMediaSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > app.MaxMediaSize {
+			return c.String(http.StatusRequestEntityTooLarge, "file too large")
+		}
+		_, err = s.app.UploadMedia(c.Request().Context(), user, blog, fileHeader.Filename, data)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrUnsupportedMedia) {
+				return c.String(http.StatusUnsupportedMediaType, "unsupported media")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid file")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/media", blog.Path))
+	}
+}
+
+func (s *Server) DeleteMediaHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		mediaID, err := domain.ParseMediaID(c.Param("media_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		media, err := s.app.FindMedia(c.Request().Context(), user, blog, mediaID)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		err = s.app.DeleteMedia(c.Request().Context(), user, blog, media)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/media", blog.Path))
+	}
+}
+
+func (s *Server) MediaHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return s.serveMedia(c, false)
+	}
+}
+
+func (s *Server) MediaThumbnailHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return s.serveMedia(c, true)
+	}
+}
+
+// serveMedia はメディアのファイルを返す. thumbnail が true の場合はサムネイルを返す
+// メディアのファイルは書き換えないが, 削除やブログの公開範囲の変更があるので共有キャッシュには載せない
+func (s *Server) serveMedia(c echo.Context, thumbnail bool) error {
+	user := getSessionUser(c)
+	mediaID, err := domain.ParseMediaID(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusNotFound, "not found")
+	}
+	media, r, err := s.app.OpenMedia(c.Request().Context(), user, mediaID, thumbnail)
+	if err != nil {
+		if errors.Is(err, app.ErrNotFound) {
+			return c.String(http.StatusNotFound, "not found")
+		}
+		return err
+	}
+	defer r.Close()
+	contentType := media.ContentType
+	if thumbnail {
+		contentType = media.ThumbnailContentType()
+	}
+	c.Response().Header().Set("Cache-Control", "private, max-age=86400")
+	return c.Stream(http.StatusOK, contentType, r)
+}
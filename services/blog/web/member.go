@@ -0,0 +1,271 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func (s *Server) MyBlogMembersHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		role, err := s.app.RoleOf(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
+		}
+		// メンバーでない場合は招待されていれば承諾か辞退を選べるようにする
+		if !role.Can(domain.PermissionReadDrafts) {
+			invitation, err := s.app.FindInvitation(c.Request().Context(), user, blog)
+			if err != nil {
+				if errors.Is(err, app.ErrNotFound) {
+					return c.String(http.StatusForbidden, "permission denied")
+				}
+				return err
+			}
+			return c.Render(http.StatusOK, "my-blog-invitation.html", map[string]interface{}{
+				"User":       user,
+				"Blog":       blog,
+				"Invitation": invitation,
+			})
+		}
+		members, users, err := s.app.ListMembers(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
+		}
+		return c.Render(http.StatusOK, "my-blog-members.html", map[string]interface{}{
+			"User":        user,
+			"Blog":        blog,
+			"Members":     members,
+			"Users":       users,
+			"Owner":       users[blog.UserID],
+			"CanManage":   role.Can(domain.PermissionManageBlog),
+			"CanTransfer": role.Can(domain.PermissionTransferOwnership),
+		})
+	}
+}
+
+func (s *Server) InviteMemberHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		params := new(struct {
+			Name string `form:"name"`
+			Role string `form:"role"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, "invalid params")
+		}
+		role, ok := domain.ParseMemberRole(params.Role)
+		if !ok {
+			return c.String(http.StatusBadRequest, "invalid role")
+		}
+		_, err = s.app.InviteMember(c.Request().Context(), user, blog, params.Name, role)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusBadRequest, "user not found")
+			}
+			if errors.Is(err, app.ErrAlreadyRegistered) {
+				return c.String(http.StatusBadRequest, "already a member")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid params")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/members", blog.Path))
+	}
+}
+
+func (s *Server) AcceptInvitationHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		_, err = s.app.AcceptInvitation(c.Request().Context(), user, blog)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s", blog.Path))
+	}
+}
+
+func (s *Server) DeclineInvitationHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		err = s.app.DeclineInvitation(c.Request().Context(), user, blog)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, "/my/blogs")
+	}
+}
+
+func (s *Server) ChangeMemberRoleHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		userID, err := domain.ParseUserID(c.Param("user_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		params := new(struct {
+			Role string `form:"role"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, "invalid params")
+		}
+		role, ok := domain.ParseMemberRole(params.Role)
+		if !ok {
+			return c.String(http.StatusBadRequest, "invalid role")
+		}
+		member, err := s.app.FindMember(c.Request().Context(), user, blog, userID)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		_, err = s.app.ChangeMemberRole(c.Request().Context(), user, blog, member, role)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid role")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/members", blog.Path))
+	}
+}
+
+func (s *Server) RemoveMemberHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		userID, err := domain.ParseUserID(c.Param("user_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		member, err := s.app.FindMember(c.Request().Context(), user, blog, userID)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		err = s.app.RemoveMember(c.Request().Context(), user, blog, member)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		// 自分自身を外した場合はもうブログを管理できないのでブログ一覧へ
+		if member.UserID == user.ID {
+			return c.Redirect(http.StatusSeeOther, "/my/blogs")
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/members", blog.Path))
+	}
+}
+
+func (s *Server) TransferBlogOwnershipHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		userID, err := domain.ParseUserID(c.Param("user_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		member, err := s.app.FindMember(c.Request().Context(), user, blog, userID)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		_, err = s.app.TransferBlogOwnership(c.Request().Context(), user, blog, member)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/members", blog.Path))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	echo "github.com/labstack/echo/v4"
@@ -15,11 +16,18 @@ import (
 func (s *Server) MyBlogsHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
-		page, _ := strconv.Atoi(c.QueryParam("page"))
-		if page < 1 {
-			page = 1
+		cursor, err := parsePageCursor(c)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid page")
+		}
+		blogs, page, err := s.app.ListBlogsByUser(c.Request().Context(), user, cursor, 10)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid page")
+			}
+			return err
 		}
-		blogs, hasNextPage, err := s.app.ListBlogsByUser(c.Request().Context(), user, page, 10)
+		invitations, invitedBlogs, err := s.app.ListInvitations(c.Request().Context(), user)
 		if err != nil {
 			return err
 		}
@@ -30,11 +38,12 @@ func (s *Server) MyBlogsHandler() echo.HandlerFunc {
 		return c.Render(http.StatusOK, "my-blogs.html", map[string]interface{}{
 			"User":        user,
 			"Blogs":       blogs,
-			"Page":        page,
-			"PrevPage":    page - 1,
-			"NextPage":    page + 1,
-			"HasPrevPage": page > 1,
-			"HasNextPage": hasNextPage,
+			"Invitations": invitations,
+			"BlogsByID":   invitedBlogs,
+			"PrevPage":    page.Prev,
+			"NextPage":    page.Next,
+			"HasPrevPage": page.Prev != nil,
+			"HasNextPage": page.Next != nil,
 		})
 	}
 }
@@ -58,6 +67,12 @@ func (s *Server) CreateBlogHandler() echo.HandlerFunc {
 		}
 		blog, err := s.app.CreateBlog(c.Request().Context(), user, params.Path, params.Title, params.Description)
 		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid params")
+			}
+			if errors.Is(err, app.ErrAlreadyExists) {
+				return c.String(http.StatusBadRequest, "path already used")
+			}
 			return err
 		}
 		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s", blog.Path))
@@ -68,23 +83,80 @@ func (s *Server) MyBlogHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		if blog.UserID != user.ID {
-			return c.String(http.StatusForbidden, "permission denied")
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionReadDrafts); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		cursor, err := parsePageCursor(c)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid page")
+		}
+		entries, page, err := s.app.ListEntriesByBlog(c.Request().Context(), user, blog, cursor, 10)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid page")
+			}
+			return err
+		}
+		viewCounts, err := s.app.CountEntryViews(c.Request().Context(), user, blog, entries)
+		if err != nil {
+			return err
+		}
+		starCounts, err := s.app.CountStars(c.Request().Context(), entries)
+		if err != nil {
+			return err
+		}
+		return c.Render(http.StatusOK, "my-blog.html", map[string]interface{}{
+			"Blog":        blog,
+			"Entries":     entries,
+			"ViewCounts":  viewCounts,
+			"StarCounts":  starCounts,
+			"PrevPage":    page.Prev,
+			"NextPage":    page.Next,
+			"HasPrevPage": page.Prev != nil,
+			"HasNextPage": page.Next != nil,
+		})
+	}
+}
+
+func (s *Server) MyBlogDraftsHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionReadDrafts); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
 		}
 		page, _ := strconv.Atoi(c.QueryParam("page"))
 		if page < 1 {
 			page = 1
 		}
-		// TODO: we should care this err?
-		entries, hasNextPage, _ := s.app.ListEntriesByBlog(c.Request().Context(), blog, page, 10)
-		return c.Render(http.StatusOK, "my-blog.html", map[string]interface{}{
+		entries, hasNextPage, err := s.app.ListDraftEntriesByBlog(c.Request().Context(), user, blog, page, 10)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Render(http.StatusOK, "my-blog-drafts.html", map[string]interface{}{
 			"Blog":        blog,
 			"Entries":     entries,
 			"Page":        page,
@@ -100,18 +172,26 @@ func (s *Server) WillEditBlogHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		if blog.UserID != user.ID {
-			return c.String(http.StatusForbidden, "permission denied")
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionManageBlog); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		redirects, err := s.app.ListBlogPathRedirects(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
 		}
 		return c.Render(http.StatusOK, "my-blog-edit.html", map[string]interface{}{
-			"Blog": blog,
+			"Blog":      blog,
+			"Redirects": redirects,
 		})
 	}
 }
@@ -120,15 +200,18 @@ func (s *Server) EditBlogHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		if blog.UserID != user.ID {
-			return c.String(http.StatusForbidden, "permission denied")
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionManageBlog); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
 		}
 		params := new(struct {
 			Title       string `form:"title"`
@@ -148,28 +231,65 @@ func (s *Server) EditBlogHandler() echo.HandlerFunc {
 	}
 }
 
+func (s *Server) RenameBlogHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		params := new(struct {
+			Path string `form:"path"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		blog, err = s.app.RenameBlog(c.Request().Context(), user, blog, params.Path)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid path")
+			}
+			if errors.Is(err, app.ErrAlreadyExists) {
+				return c.String(http.StatusBadRequest, "path already used")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/edit", blog.Path))
+	}
+}
+
 func (s *Server) DeleteBlogHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		if blog.UserID != user.ID {
-			return c.String(http.StatusForbidden, "permission denied")
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionManageBlog); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
 		}
-		err = s.app.DeleteBlog(c.Request().Context(), user, blog)
+		err = s.app.TrashBlog(c.Request().Context(), user, blog)
 		if err != nil {
 			if errors.Is(err, app.ErrPermissionDenied) {
 				return c.String(http.StatusForbidden, "permission denied")
 			}
 			return err
 		}
-		return c.Redirect(http.StatusSeeOther, "/my/blogs")
+		return c.Redirect(http.StatusSeeOther, "/my/trash")
 	}
 }
 
@@ -177,15 +297,18 @@ func (s *Server) WillPublishEntryHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		if blog.UserID != user.ID {
-			return c.String(http.StatusForbidden, "permission denied")
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
 		}
 		return c.Render(http.StatusOK, "my-entries-publish.html", map[string]interface{}{
 			"Blog": blog,
@@ -197,31 +320,90 @@ func (s *Server) PublishEntryHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		if blog.UserID != user.ID {
-			return c.String(http.StatusForbidden, "permission denied")
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		params := new(struct {
+			Title       string `form:"title"`
+			Slug        string `form:"slug"`
+			Body        string `form:"body"`
+			Tags        string `form:"tags"`
+			PublishedAt string `form:"published_at"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		publishAt, err := parsePublishAt(params.PublishedAt)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid published_at")
+		}
+		_, err = s.app.PublishEntry(c.Request().Context(), user, blog, params.Title, params.Slug, params.Body, splitTagNames(params.Tags), publishAt)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid params")
+			}
+			if errors.Is(err, app.ErrAlreadyExists) {
+				return c.String(http.StatusConflict, "slug already used")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s", blog.Path))
+	}
+}
+
+func (s *Server) SaveDraftEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
 		}
 		params := new(struct {
 			Title string `form:"title"`
+			Slug  string `form:"slug"`
 			Body  string `form:"body"`
+			Tags  string `form:"tags"`
 		})
 		if err := c.Bind(params); err != nil {
 			return c.String(http.StatusBadRequest, err.Error())
 		}
-		_, err = s.app.PublishEntry(c.Request().Context(), user, blog, params.Title, params.Body)
+		entry, err := s.app.SaveDraftEntry(c.Request().Context(), user, blog, params.Title, params.Slug, params.Body, splitTagNames(params.Tags))
 		if err != nil {
 			if errors.Is(err, app.ErrPermissionDenied) {
 				return c.String(http.StatusForbidden, "permission denied")
 			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid params")
+			}
+			if errors.Is(err, app.ErrAlreadyExists) {
+				return c.String(http.StatusConflict, "slug already used")
+			}
 			return err
 		}
-		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s", blog.Path))
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/entries/%s", blog.Path, entry.ID))
 	}
 }
 
@@ -229,30 +411,59 @@ func (s *Server) MyEntryHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		if blog.UserID != user.ID {
-			return c.String(http.StatusForbidden, "permission denied")
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionReadDrafts); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
 		}
 		entryID, err := domain.ParseEntryID(c.Param("id"))
 		if err != nil {
 			return c.String(http.StatusBadRequest, "invalid id")
 		}
-		entry, err := s.app.FindEntryByID(c.Request().Context(), blog, entryID)
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
+		tags, err := s.app.ListTagsByEntry(c.Request().Context(), entry)
+		if err != nil {
+			return err
+		}
+		canWrite, err := s.app.HasPermission(c.Request().Context(), user, blog, domain.PermissionWriteEntries)
+		if err != nil {
+			return err
+		}
+		// 共有リンクはエントリを書けるメンバーにだけ見せる
+		var shareLinks []*domain.ShareLink
+		shareURLs := map[domain.ShareLinkID]string{}
+		if canWrite {
+			shareLinks, err = s.app.ListShareLinks(c.Request().Context(), user, blog, entry)
+			if err != nil {
+				return err
+			}
+			for _, l := range shareLinks {
+				shareURLs[l.ID] = s.shareURL(blog, entry, l)
+			}
+		}
 		return c.Render(http.StatusOK, "my-entry.html", map[string]interface{}{
-			"Blog":  blog,
-			"Entry": entry,
+			"Blog":       blog,
+			"Entry":      entry,
+			"TagNames":   joinTagNames(tags),
+			"Visibility": entry.VisibilityIn(blog),
+			"CanWrite":   canWrite,
+			"ShareLinks": shareLinks,
+			"ShareURLs":  shareURLs,
+			"Now":        time.Now(),
 		})
 	}
 }
@@ -261,21 +472,24 @@ func (s *Server) EditEntryHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		if blog.UserID != user.ID {
-			return c.String(http.StatusForbidden, "permission denied")
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
 		}
 		entryID, err := domain.ParseEntryID(c.Param("id"))
 		if err != nil {
 			return c.String(http.StatusBadRequest, "invalid id")
 		}
-		entry, err := s.app.FindEntryByID(c.Request().Context(), blog, entryID)
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
@@ -284,12 +498,69 @@ func (s *Server) EditEntryHandler() echo.HandlerFunc {
 		}
 		params := new(struct {
 			Title string `form:"title"`
+			Slug  string `form:"slug"`
 			Body  string `form:"body"`
+			Tags  string `form:"tags"`
 		})
 		if err := c.Bind(params); err != nil {
 			return c.String(http.StatusBadRequest, err.Error())
 		}
-		_, err = s.app.EditEntry(c.Request().Context(), user, blog, entry, params.Title, params.Body)
+		_, err = s.app.EditEntry(c.Request().Context(), user, blog, entry, params.Title, params.Slug, params.Body, splitTagNames(params.Tags))
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid params")
+			}
+			if errors.Is(err, app.ErrAlreadyExists) {
+				return c.String(http.StatusConflict, "slug already used")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s", blog.Path))
+	}
+}
+
+func (s *Server) PublishDraftEntryHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		params := new(struct {
+			PublishedAt string `form:"published_at"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		publishAt, err := parsePublishAt(params.PublishedAt)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid published_at")
+		}
+		_, err = s.app.PublishDraftEntry(c.Request().Context(), user, blog, entry, publishAt)
 		if err != nil {
 			if errors.Is(err, app.ErrPermissionDenied) {
 				return c.String(http.StatusForbidden, "permission denied")
@@ -304,21 +575,24 @@ func (s *Server) UnpublishEntryHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
 		path := c.Param("path")
-		blog, err := s.app.FindBlogByPath(c.Request().Context(), path)
+		blog, err := s.findBlogByPath(c, path)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
 			}
 			return err
 		}
-		if blog.UserID != user.ID {
-			return c.String(http.StatusForbidden, "permission denied")
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
 		}
 		entryID, err := domain.ParseEntryID(c.Param("id"))
 		if err != nil {
 			return c.String(http.StatusBadRequest, "invalid id")
 		}
-		entry, err := s.app.FindEntryByID(c.Request().Context(), blog, entryID)
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
 		if err != nil {
 			if errors.Is(err, app.ErrNotFound) {
 				return c.String(http.StatusNotFound, "not found")
@@ -335,3 +609,32 @@ func (s *Server) UnpublishEntryHandler() echo.HandlerFunc {
 		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s", blog.Path))
 	}
 }
+
+// publishAtLayout は <input type="datetime-local"> が送信する日時の形式
+const publishAtLayout = "2006-01-02T15:04"
+
+// parsePublishAt はフォームで指定された公開日時をパースする
+// 空文字列の場合はゼロ値を返す
+func parsePublishAt(str string) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(publishAtLayout, str, time.Local)
+}
+
+// splitTagNames はカンマ区切りで入力されたタグの名前を分割する
+func splitTagNames(str string) []string {
+	if str == "" {
+		return nil
+	}
+	return strings.Split(str, ",")
+}
+
+// joinTagNames はタグの名前をフォームに入力するためにカンマ区切りでつなげる
+func joinTagNames(tags []*domain.Tag) string {
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,16 @@
+package web
+
+import (
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// parsePageCursor は ?page= で指定された一覧の位置をパースする
+// 指定されていない場合は一覧の先頭を表す nil を返す
+func parsePageCursor(c echo.Context) (*domain.Cursor, error) {
+	str := c.QueryParam("page")
+	if str == "" {
+		return nil, nil
+	}
+	return domain.ParseCursor(str)
+}
@@ -3,6 +3,7 @@ package web
 import (
 	"html/template"
 	"io"
+	"net/url"
 
 	echo "github.com/labstack/echo/v4"
 
@@ -24,6 +25,7 @@ func newRenderer() (*renderer, error) {
 		"unescapedHTML": func(html string) template.HTML {
 			return template.HTML(html)
 		},
+		"pathEscape": url.PathEscape,
 	})
 
 	dir, err := templateAssets.Templates.ReadDir(".")
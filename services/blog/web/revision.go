@@ -0,0 +1,176 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/diff"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func (s *Server) EntryRevisionsHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionReadDrafts); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		page, _ := strconv.Atoi(c.QueryParam("page"))
+		if page < 1 {
+			page = 1
+		}
+		revisions, hasNextPage, err := s.app.ListEntryRevisions(c.Request().Context(), user, blog, entry, page, 20)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Render(http.StatusOK, "my-entry-revisions.html", map[string]interface{}{
+			"Blog":        blog,
+			"Entry":       entry,
+			"Revisions":   revisions,
+			"Page":        page,
+			"PrevPage":    page - 1,
+			"NextPage":    page + 1,
+			"HasPrevPage": page > 1,
+			"HasNextPage": hasNextPage,
+		})
+	}
+}
+
+func (s *Server) EntryRevisionsDiffHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionReadDrafts); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		// "0" はエントリの現在の内容を表す
+		fromID, err := domain.ParseEntryRevisionID(c.QueryParam("from"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid from")
+		}
+		toID, err := domain.ParseEntryRevisionID(c.QueryParam("to"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid to")
+		}
+		from, err := s.app.FindEntryRevisionByID(c.Request().Context(), user, blog, entry, fromID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		to, err := s.app.FindEntryRevisionByID(c.Request().Context(), user, blog, entry, toID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		return c.Render(http.StatusOK, "my-entry-revisions-diff.html", map[string]interface{}{
+			"Blog":  blog,
+			"Entry": entry,
+			"From":  from,
+			"To":    to,
+			"Lines": diff.Lines(from.Body, to.Body),
+		})
+	}
+}
+
+func (s *Server) RestoreEntryRevisionHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if err := s.app.Authorize(c.Request().Context(), user, blog, domain.PermissionWriteEntries); err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		revisionID, err := domain.ParseEntryRevisionID(c.Param("revision_id"))
+		if err != nil || revisionID == 0 {
+			return c.String(http.StatusBadRequest, "invalid revision id")
+		}
+		revision, err := s.app.FindEntryRevisionByID(c.Request().Context(), user, blog, entry, revisionID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		_, err = s.app.RestoreEntryRevision(c.Request().Context(), user, blog, entry, revision)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/entries/%s", blog.Path, entry.ID))
+	}
+}
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func (s *Server) SearchHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		query := c.QueryParam("q")
+		page, _ := strconv.Atoi(c.QueryParam("page"))
+		if page < 1 {
+			page = 1
+		}
+		entries, blogs, hasNextPage, err := s.app.SearchEntries(c.Request().Context(), query, page, 10)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid query")
+			}
+			return err
+		}
+		return c.Render(http.StatusOK, "search.html", map[string]interface{}{
+			"User":        user,
+			"Query":       query,
+			"Entries":     entries,
+			"Blogs":       blogs,
+			"Page":        page,
+			"PrevPage":    page - 1,
+			"NextPage":    page + 1,
+			"HasPrevPage": page > 1,
+			"HasNextPage": hasNextPage,
+		})
+	}
+}
+
+func (s *Server) BlogSearchHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		canView, err := s.app.CanViewBlog(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
+		}
+		if !canView {
+			return c.String(http.StatusNotFound, "not found")
+		}
+		query := c.QueryParam("q")
+		page, _ := strconv.Atoi(c.QueryParam("page"))
+		if page < 1 {
+			page = 1
+		}
+		entries, hasNextPage, err := s.app.SearchEntriesInBlog(c.Request().Context(), user, blog, query, page, 10)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid query")
+			}
+			return err
+		}
+		role, err := s.app.RoleOf(c.Request().Context(), user, blog)
+		if err != nil {
+			return err
+		}
+		return c.Render(http.StatusOK, "blog-search.html", map[string]interface{}{
+			"IsMember":    role.Can(domain.PermissionReadDrafts),
+			"Blog":        blog,
+			"Query":       query,
+			"Entries":     entries,
+			"Page":        page,
+			"PrevPage":    page - 1,
+			"NextPage":    page + 1,
+			"HasPrevPage": page > 1,
+			"HasNextPage": hasNextPage,
+		})
+	}
+}
@@ -2,25 +2,31 @@ package web
 
 import (
 	"context"
+	"errors"
 	"net/http"
-	"strconv"
 	"strings"
+	"time"
 
 	echo "github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/atompub"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
 // Server は Web サーバーを表す構造体
 type Server struct {
-	e   *echo.Echo
-	app *app.App
+	e       *echo.Echo
+	app     *app.App
+	baseURL string
+	// wsseNonces は AtomPub の WSSE 認証で使われた Nonce. 同じヘッダを送り直されても受け付けない
+	wsseNonces *atompub.NonceCache
 }
 
 // NewServer は Web サーバーを作成する
-func NewServer(app *app.App, serviceName string) (*Server, error) {
+// baseURL はフィードなどで絶対 URL を組み立てるために使う
+func NewServer(app *app.App, serviceName, baseURL string) (*Server, error) {
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
@@ -33,7 +39,7 @@ func NewServer(app *app.App, serviceName string) (*Server, error) {
 	}
 	e.Renderer = renderer
 
-	return &Server{e, app}, nil
+	return &Server{e, app, baseURL, atompub.NewNonceCache()}, nil
 }
 
 // Echo はサーバーが使用する Echo のインスタンスを取得する
@@ -60,6 +66,9 @@ func (s *Server) attachMiddlewares() {
 			return strings.HasPrefix(ctx.Path(), "/server/health")
 		},
 	}))
+	// CSRF のチェックでフォームを読むので, その前に本文の大きさを制限する
+	// メディアのアップロードを受け付けられるよう, 上限はファイルの大きさに余裕を持たせる
+	s.e.Use(middleware.BodyLimit("11M"))
 	s.e.Use(middleware.CSRFWithConfig(middleware.CSRFConfig{
 		TokenLookup: "form:csrf_token",
 		Skipper: func(c echo.Context) bool {
@@ -67,11 +76,16 @@ func (s *Server) attachMiddlewares() {
 			if h := r.Header.Get("X-Requested-With"); h != "" {
 				return true
 			}
+			// AtomPub API と JSON API はクッキーではなく API キーで認証するので, CSRF の対象にならない
+			if strings.HasPrefix(c.Path(), "/blogs/:path/atom") || strings.HasPrefix(c.Path(), "/api/v1") {
+				return true
+			}
 			return false
 		},
 	}))
 	s.e.Use(middleware.Secure())
 	s.e.Use(s.CustomContextMiddleware())
+	s.e.Use(s.BlogPathRedirectMiddleware())
 }
 
 func (s *Server) attachHandlers() {
@@ -80,6 +94,9 @@ func (s *Server) attachHandlers() {
 	setupStaticRoutes(s.e)
 
 	s.e.GET("/", s.IndexHandler())
+	s.e.GET("/search", s.SearchHandler())
+	s.e.GET("/feed.atom", s.AtomFeedHandler())
+	s.e.GET("/feed.rss", s.RSSFeedHandler())
 	s.e.GET("/signup", s.WillSignupHandler())
 	s.e.POST("/signup", s.SignupHandler())
 	s.e.GET("/signin", s.WillSigninHandler())
@@ -90,16 +107,93 @@ func (s *Server) attachHandlers() {
 	s.e.GET("/my/blogs/-/create", s.WillCreateBlogHandler(), requireSessionMiddleware())
 	s.e.POST("/my/blogs", s.CreateBlogHandler(), requireSessionMiddleware())
 	s.e.GET("/my/blogs/:path", s.MyBlogHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/drafts", s.MyBlogDraftsHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/comments", s.MyBlogCommentsHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/comments/settings", s.EditBlogCommentsHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/comments/:comment_id/approve", s.ApproveCommentHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/comments/:comment_id/hide", s.HideCommentHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/comments/:comment_id/delete", s.DeleteCommentHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/members", s.MyBlogMembersHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/members", s.InviteMemberHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/members/-/accept", s.AcceptInvitationHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/members/-/decline", s.DeclineInvitationHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/members/:user_id/role", s.ChangeMemberRoleHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/members/:user_id/delete", s.RemoveMemberHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/members/:user_id/transfer", s.TransferBlogOwnershipHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/media", s.MyBlogMediaHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/media", s.UploadMediaHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/media/:media_id/delete", s.DeleteMediaHandler(), requireSessionMiddleware())
 	s.e.GET("/my/blogs/:path/edit", s.WillEditBlogHandler(), requireSessionMiddleware())
 	s.e.POST("/my/blogs/:path/edit", s.EditBlogHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/visibility", s.EditBlogVisibilityHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/rename", s.RenameBlogHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/import", s.WillImportEntriesHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/import", s.ImportEntriesHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/exports", s.MyBlogExportsHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/exports", s.RequestBlogExportHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/exports/:export_id/download", s.DownloadBlogExportHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/webhooks", s.MyBlogWebhooksHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/webhooks", s.CreateWebhookHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/webhooks/:webhook_id/delete", s.DeleteWebhookHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/webhooks/:webhook_id/deliveries", s.WebhookDeliveriesHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", s.RedeliverWebhookHandler(), requireSessionMiddleware())
 	s.e.POST("/my/blogs/:path/delete", s.DeleteBlogHandler(), requireSessionMiddleware())
 	s.e.GET("/my/blogs/:path/entries/-/publish", s.WillPublishEntryHandler(), requireSessionMiddleware())
 	s.e.POST("/my/blogs/:path/entries/-/publish", s.PublishEntryHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/entries/-/draft", s.SaveDraftEntryHandler(), requireSessionMiddleware())
 	s.e.GET("/my/blogs/:path/entries/:id", s.MyEntryHandler(), requireSessionMiddleware())
 	s.e.POST("/my/blogs/:path/entries/:id/edit", s.EditEntryHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/entries/:id/revisions", s.EntryRevisionsHandler(), requireSessionMiddleware())
+	s.e.GET("/my/blogs/:path/entries/:id/revisions/diff", s.EntryRevisionsDiffHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/entries/:id/revisions/:revision_id/restore", s.RestoreEntryRevisionHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/entries/:id/publish", s.PublishDraftEntryHandler(), requireSessionMiddleware())
 	s.e.POST("/my/blogs/:path/entries/:id/unpublish", s.UnpublishEntryHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/entries/:id/visibility", s.EditEntryVisibilityHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/entries/:id/shares", s.CreateShareLinkHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/entries/:id/shares/:share_id/delete", s.RevokeShareLinkHandler(), requireSessionMiddleware())
+	s.e.POST("/my/blogs/:path/entries/:id/delete", s.TrashEntryHandler(), requireSessionMiddleware())
+	s.e.GET("/my/trash", s.TrashHandler(), requireSessionMiddleware())
+	s.e.GET("/my/api-key", s.MyAPIKeyHandler(), requireSessionMiddleware())
+	s.e.POST("/my/api-key/regenerate", s.RegenerateAPIKeyHandler(), requireSessionMiddleware())
+	s.e.GET("/my/tokens", s.MyAccessTokensHandler(), requireSessionMiddleware())
+	s.e.POST("/my/tokens", s.CreateAccessTokenHandler(), requireSessionMiddleware())
+	s.e.POST("/my/tokens/:token_id/revoke", s.RevokeAccessTokenHandler(), requireSessionMiddleware())
+	s.e.POST("/my/trash/blogs/:blog_id/restore", s.RestoreBlogHandler(), requireSessionMiddleware())
+	s.e.POST("/my/trash/entries/:entry_id/restore", s.RestoreEntryHandler(), requireSessionMiddleware())
+	s.e.GET("/my/stars", s.MyStarsHandler(), requireSessionMiddleware())
+	s.e.GET("/media/:id", s.MediaHandler())
+	s.e.GET("/media/:id/thumbnail", s.MediaThumbnailHandler())
 	s.e.GET("/blogs/:path", s.BlogHandler())
 	s.e.GET("/blogs/:path/entries/:id", s.EntryHandler())
+	s.e.GET("/blogs/:path/:year/:month/:day/:slug", s.EntryHandler())
+	s.e.POST("/blogs/:path/entries/:id/comments", s.PostCommentHandler(), requireSessionMiddleware())
+	s.e.POST("/blogs/:path/entries/:id/star", s.StarEntryHandler(), requireSessionMiddleware())
+	s.e.POST("/blogs/:path/entries/:id/unstar", s.UnstarEntryHandler(), requireSessionMiddleware())
+	s.e.GET("/blogs/:path/tags/:tag", s.TagHandler())
+	s.e.GET("/blogs/:path/search", s.BlogSearchHandler())
+	s.e.GET("/blogs/:path/feed.atom", s.BlogAtomFeedHandler())
+	s.e.GET("/blogs/:path/feed.rss", s.BlogRSSFeedHandler())
+	s.e.GET("/blogs/:path/atom", s.AtomPubServiceHandler(), s.atomPubAuthMiddleware())
+	s.e.GET("/blogs/:path/atom/entry", s.AtomPubCollectionHandler(), s.atomPubAuthMiddleware())
+	s.e.POST("/blogs/:path/atom/entry", s.AtomPubPostEntryHandler(), s.atomPubAuthMiddleware())
+	s.e.GET("/blogs/:path/atom/entry/:id", s.AtomPubEntryHandler(), s.atomPubAuthMiddleware())
+	s.e.PUT("/blogs/:path/atom/entry/:id", s.AtomPubEditEntryHandler(), s.atomPubAuthMiddleware())
+	s.e.DELETE("/blogs/:path/atom/entry/:id", s.AtomPubDeleteEntryHandler(), s.atomPubAuthMiddleware())
+
+	// JSON API. エラーはすべて apiErrorMiddleware で JSON にする
+	api := s.e.Group("/api/v1", apiErrorMiddleware(), s.apiAuthMiddleware(), s.accessTokenMiddleware())
+	api.GET("/openapi.yaml", s.APIOpenAPIHandler())
+	api.GET("/blogs", s.APIListBlogsHandler())
+	api.POST("/blogs", s.APICreateBlogHandler(), requireAPIUserMiddleware())
+	api.GET("/my/blogs", s.APIListMyBlogsHandler(), requireAPIUserMiddleware())
+	api.GET("/blogs/:path", s.APIBlogHandler())
+	api.PATCH("/blogs/:path", s.APIEditBlogHandler(), requireAPIUserMiddleware())
+	api.GET("/blogs/:path/entries", s.APIListEntriesHandler())
+	api.POST("/blogs/:path/entries", s.APICreateEntryHandler(), requireAPIUserMiddleware())
+	api.POST("/blogs/:path/preview", s.APIPreviewEntryHandler(), requireAPIUserMiddleware())
+	api.GET("/blogs/:path/entries/:id", s.APIEntryHandler())
+	api.PATCH("/blogs/:path/entries/:id", s.APIEditEntryHandler(), requireAPIUserMiddleware())
+	api.DELETE("/blogs/:path/entries/:id", s.APIDeleteEntryHandler(), requireAPIUserMiddleware())
 }
 
 // 静的ファイルルーティングをまとめた関数
@@ -122,18 +216,76 @@ func setupStaticRoutes(e *echo.Echo) {
 type CustomContext struct {
 	echo.Context
 	User *domain.User
+	// Loaders はリクエストの間だけ使う Loader. ページの一覧に並ぶブログやエントリごとの検索をまとめる
+	Loaders *app.Loaders
+	// Blog は BlogPathRedirectMiddleware がルーティングの :path で見つけたブログ. ハンドラーで検索し直さないよう findBlogByPath で使う
+	Blog *domain.Blog
 }
 
 func (s *Server) CustomContextMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			user := s.userFromSessionCookie(c)
-			cc := &CustomContext{Context: c, User: user}
+			cc := &CustomContext{Context: c, User: user, Loaders: s.app.NewLoaders()}
 			return next(cc)
 		}
 	}
 }
 
+// BlogPathRedirectMiddleware はパスを変更したブログの古いパスへのアクセスを新しいパスにリダイレクトする
+// 古いパスは他のブログに使わせないので, ルーティングで :path に当たった部分が古いパスであれば常にリダイレクトしてよい
+// ほとんどのリクエストは今のパスへのものなので, 今のパスのブログが見つからなかったときだけ古いパスを検索する
+// 今のパスで見つけたブログは CustomContext.Blog に入れ, ハンドラーで同じブログを検索し直さないようにする
+func (s *Server) BlogPathRedirectMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Param("path")
+			if path == "" {
+				return next(c)
+			}
+			current, err := s.app.FindBlogByPath(c.Request().Context(), path)
+			if err == nil {
+				if cc, ok := c.(*CustomContext); ok {
+					cc.Blog = current
+				}
+				return next(c)
+			}
+			if !errors.Is(err, app.ErrNotFound) {
+				return err
+			}
+			blog, err := s.app.FindBlogByOldPath(c.Request().Context(), path)
+			if err != nil {
+				if errors.Is(err, app.ErrNotFound) {
+					return next(c)
+				}
+				return err
+			}
+			u := *c.Request().URL
+			for _, prefix := range []string{"/blogs/", "/my/blogs/", "/api/v1/blogs/"} {
+				if rest, ok := strings.CutPrefix(u.Path, prefix+path); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+					u.Path = prefix + blog.Path + rest
+					u.RawPath = ""
+					// フォームの送信はメソッドと本文を保ったまま送り直してもらう
+					code := http.StatusMovedPermanently
+					if c.Request().Method != http.MethodGet && c.Request().Method != http.MethodHead {
+						code = http.StatusPermanentRedirect
+					}
+					return c.Redirect(code, u.RequestURI())
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// findBlogByPath はパスでブログを検索する. BlogPathRedirectMiddleware が同じパスで見つけたブログがあれば, 検索し直さずにそれを返す
+func (s *Server) findBlogByPath(c echo.Context, path string) (*domain.Blog, error) {
+	if cc, ok := c.(*CustomContext); ok && cc.Blog != nil && cc.Blog.Path == path {
+		return cc.Blog, nil
+	}
+	return s.app.FindBlogByPath(c.Request().Context(), path)
+}
+
 func (s *Server) healthcheckHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		return c.String(http.StatusOK, "I'm alive.")
@@ -143,36 +295,43 @@ func (s *Server) healthcheckHandler() echo.HandlerFunc {
 func (s *Server) IndexHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
-		page, _ := strconv.Atoi(c.QueryParam("page"))
-		if page < 1 {
-			page = 1
+		cursor, err := parsePageCursor(c)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid page")
 		}
-		blogs, hasNextPage, err := s.app.ListBlogs(c.Request().Context(), page, 10)
+		blogs, entries, page, err := s.app.ListBlogsWithLatestEntries(c.Request().Context(), cursor, 10)
 		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid page")
+			}
 			return err
 		}
-
-		// FIXME: N+1 問題
-		entries := make(map[domain.BlogID]*domain.Entry)
-		for _, v := range blogs {
-			blogEntries, _, err := s.app.ListEntriesByBlog(c.Request().Context(), v, 1, 1)
-			if err != nil {
-				return err
-			}
-			if len(blogEntries) == 1 {
-				entries[v.ID] = blogEntries[0]
-			}
+		popularEntries, popularBlogs, err := s.app.ListPopularEntries(c.Request().Context(), time.Now(), 10)
+		if err != nil {
+			return err
+		}
+		// ブログの最新のエントリと人気のエントリのスターの数をまとめて数える
+		starredEntries := make([]*domain.Entry, 0, len(entries)+len(popularEntries))
+		starredEntries = append(starredEntries, popularEntries...)
+		for _, e := range entries {
+			starredEntries = append(starredEntries, e)
+		}
+		starCounts, err := loadStarCounts(c, starredEntries)
+		if err != nil {
+			return err
 		}
 
 		return c.Render(http.StatusOK, "index.html", map[string]interface{}{
-			"User":        user,
-			"Blogs":       blogs,
-			"Entries":     entries,
-			"Page":        page,
-			"PrevPage":    page - 1,
-			"NextPage":    page + 1,
-			"HasPrevPage": page > 1,
-			"HasNextPage": hasNextPage,
+			"User":           user,
+			"Blogs":          blogs,
+			"Entries":        entries,
+			"PopularEntries": popularEntries,
+			"PopularBlogs":   popularBlogs,
+			"StarCounts":     starCounts,
+			"PrevPage":       page.Prev,
+			"NextPage":       page.Next,
+			"HasPrevPage":    page.Prev != nil,
+			"HasNextPage":    page.Next != nil,
 		})
 	}
 }
@@ -0,0 +1,182 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+// shareURL は共有リンクの絶対 URL を組み立てる
+func (s *Server) shareURL(blog *domain.Blog, entry *domain.Entry, link *domain.ShareLink) string {
+	return fmt.Sprintf("%s/blogs/%s/%s?share=%s", s.baseURL, blog.Path, entry.Permalink(), url.QueryEscape(s.app.ShareLinkToken(link)))
+}
+
+func (s *Server) EditBlogVisibilityHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		params := new(struct {
+			Visibility string `form:"visibility"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		visibility, ok := domain.ParseVisibility(params.Visibility)
+		if !ok {
+			return c.String(http.StatusBadRequest, "invalid visibility")
+		}
+		blog, err = s.app.SetBlogVisibility(c.Request().Context(), user, blog, visibility)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/edit", blog.Path))
+	}
+}
+
+func (s *Server) EditEntryVisibilityHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		params := new(struct {
+			Visibility string `form:"visibility"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		visibility, ok := domain.ParseVisibility(params.Visibility)
+		if !ok {
+			return c.String(http.StatusBadRequest, "invalid visibility")
+		}
+		entry, err = s.app.SetEntryVisibility(c.Request().Context(), user, blog, entry, visibility)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/entries/%s", blog.Path, entry.ID))
+	}
+}
+
+func (s *Server) CreateShareLinkHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		params := new(struct {
+			Days int `form:"days"`
+		})
+		if err := c.Bind(params); err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		_, err = s.app.CreateShareLink(c.Request().Context(), user, blog, entry, time.Duration(params.Days)*24*time.Hour)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid days")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/entries/%s", blog.Path, entry.ID))
+	}
+}
+
+func (s *Server) RevokeShareLinkHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		linkID, err := domain.ParseShareLinkID(c.Param("share_id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		link, err := s.app.FindShareLink(c.Request().Context(), user, blog, entry, linkID)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		err = s.app.RevokeShareLink(c.Request().Context(), user, blog, link)
+		if err != nil {
+			if errors.Is(err, app.ErrPermissionDenied) {
+				return c.String(http.StatusForbidden, "permission denied")
+			}
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/my/blogs/%s/entries/%s", blog.Path, entry.ID))
+	}
+}
@@ -0,0 +1,105 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/app"
+	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/blog/domain"
+)
+
+func (s *Server) StarEntryHandler() echo.HandlerFunc {
+	return s.starEntryHandler(true)
+}
+
+func (s *Server) UnstarEntryHandler() echo.HandlerFunc {
+	return s.starEntryHandler(false)
+}
+
+// starEntryHandler はエントリにスターを付け外しする
+// JavaScript から X-Requested-With ヘッダを付けて呼ばれた場合はスターの状態を JSON で返し, フォームから呼ばれた場合はエントリにリダイレクトする
+func (s *Server) starEntryHandler(star bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		path := c.Param("path")
+		blog, err := s.findBlogByPath(c, path)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		entryID, err := domain.ParseEntryID(c.Param("id"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid id")
+		}
+		entry, err := s.app.FindEntryByID(c.Request().Context(), user, blog, entryID)
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		var count int
+		if star {
+			count, err = s.app.StarEntry(c.Request().Context(), user, blog, entry)
+		} else {
+			count, err = s.app.UnstarEntry(c.Request().Context(), user, blog, entry)
+		}
+		if err != nil {
+			if errors.Is(err, app.ErrNotFound) {
+				return c.String(http.StatusNotFound, "not found")
+			}
+			return err
+		}
+		if c.Request().Header.Get("X-Requested-With") != "" {
+			return c.JSON(http.StatusOK, map[string]interface{}{
+				"starred": star,
+				"count":   count,
+			})
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/blogs/%s/%s#stars", blog.Path, entry.Permalink()))
+	}
+}
+
+func (s *Server) MyStarsHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := getSessionUser(c)
+		cursor, err := parsePageCursor(c)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "invalid page")
+		}
+		entries, blogs, page, err := s.app.ListStarredEntries(c.Request().Context(), user, cursor, 20)
+		if err != nil {
+			if errors.Is(err, app.ErrInvalidArgument) {
+				return c.String(http.StatusBadRequest, "invalid page")
+			}
+			return err
+		}
+		starCounts, err := s.app.CountStars(c.Request().Context(), entries)
+		if err != nil {
+			return err
+		}
+		return c.Render(http.StatusOK, "my-stars.html", map[string]interface{}{
+			"User":        user,
+			"Entries":     entries,
+			"BlogsByID":   blogs,
+			"StarCounts":  starCounts,
+			"PrevPage":    page.Prev,
+			"NextPage":    page.Next,
+			"HasPrevPage": page.Prev != nil,
+			"HasNextPage": page.Next != nil,
+		})
+	}
+}
+
+// loadStarCounts はリクエストの Loader で複数のエントリのスターの数を数える. スターが付いていないエントリは結果に含まれない
+func loadStarCounts(c echo.Context, entries []*domain.Entry) (map[domain.EntryID]int, error) {
+	entryIDs := make([]domain.EntryID, 0, len(entries))
+	for _, e := range entries {
+		entryIDs = append(entryIDs, e.ID)
+	}
+	return getLoaders(c).StarCount.LoadMany(c.Request().Context(), entryIDs)
+}
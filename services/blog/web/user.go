@@ -3,6 +3,7 @@ package web
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	echo "github.com/labstack/echo/v4"
@@ -37,11 +38,47 @@ func requireSessionMiddleware() echo.MiddlewareFunc {
 	}
 }
 
+// accessTokenMiddleware は Authorization: Bearer ヘッダで送られたパーソナルアクセストークンでユーザーを認証し, CustomContext.User に入れる
+// トークンで認証したリクエストでは, ブログに対する権限はトークンのスコープで許されたものに限られる
+// Bearer のトークンを送らないリクエストはそのまま次に渡し, 不正なトークンは app.ErrAuthenticationFailed で断る
+func (s *Server) accessTokenMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, ok := bearerToken(c.Request())
+			if !ok {
+				return next(c)
+			}
+			user, accessToken, err := s.app.AuthenticateByAccessToken(c.Request().Context(), token)
+			if err != nil {
+				return err
+			}
+			c.SetRequest(c.Request().WithContext(app.WithAccessToken(c.Request().Context(), accessToken)))
+			cc := c.(*CustomContext)
+			cc.User = user
+			return next(cc)
+		}
+	}
+}
+
+// bearerToken は Authorization ヘッダから Bearer のトークンを取り出す. 認証方式の名前は大文字と小文字を区別しない
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get(echo.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
 func getSessionUser(c echo.Context) *domain.User {
 	cc := c.(*CustomContext)
 	return cc.User
 }
 
+func getLoaders(c echo.Context) *app.Loaders {
+	cc := c.(*CustomContext)
+	return cc.Loaders
+}
+
 func (s *Server) WillSignupHandler() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		user := getSessionUser(c)
@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// userAgent は Webhook のリクエストに付ける User-Agent
+const userAgent = "blog-webhook/1.0"
+
+// maxResponseSize はレスポンスの本文を読み捨てる大きさの上限
+const maxResponseSize = 64 << 10
+
+// ErrPrivateAddress は Webhook の URL がプライベートなネットワークのアドレスを指している場合のエラー
+var ErrPrivateAddress = errors.New("webhook: private network address is not allowed")
+
+// HTTPSender は domain.WebhookSender に対する HTTP を使った実装
+// ctx のトレースコンテキストを traceparent ヘッダとしてリクエストに付け, 受け取る側のトレースにつなげる
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender は HTTPSender を作成する. timeout は 1 回のリクエストにかける時間の上限
+// allowPrivateNetwork が false の場合, ループバックやプライベートなネットワークのアドレスには接続しない
+// (ブログのオーナーが登録した URL からサービスの内部に届くリクエストを送れないようにする)
+func NewHTTPSender(timeout time.Duration, allowPrivateNetwork bool) *HTTPSender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetwork {
+		// 名前解決した後のアドレスを確かめるため, 接続する直前に検査する
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(addrPort.Addr()) {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+	return &HTTPSender{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// リダイレクトは辿らずに, リダイレクトのレスポンスを配信の失敗として扱う
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send は body を JSON として url に POST し, レスポンスのステータスコードを返す
+func (s *HTTPSender) Send(ctx context.Context, url string, header map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// コネクションを使い回せるよう, 本文を読み捨てる
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxResponseSize))
+	return res.StatusCode, nil
+}
+
+// isPublicAddr はアドレスがインターネットから届くアドレスかを判定する
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace はキャリアグレード NAT で使われるアドレス (RFC 6598)
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"203.0.113.1", true},
+		{"2001:4860:4860::8888", true},
+		// ループバック
+		{"127.0.0.1", false},
+		{"127.255.255.254", false},
+		{"::1", false},
+		// RFC 1918
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"172.31.255.255", false},
+		{"172.32.0.1", true},
+		{"192.168.1.1", false},
+		// キャリアグレード NAT (RFC 6598)
+		{"100.64.0.1", false},
+		{"100.127.255.255", false},
+		{"100.128.0.1", true},
+		// リンクローカル
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		// IPv6 のユニークローカルアドレス
+		{"fc00::1", false},
+		{"fd12:3456:789a::1", false},
+		// 未指定, マルチキャスト, ブロードキャスト
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"ff02::1", false},
+		{"255.255.255.255", false},
+		// IPv4 射影アドレスは IPv4 のアドレスとして判定する
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:8.8.8.8", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPSenderRejectsPrivateAddress(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	// httptest のサーバーはループバックで待ち受けるので, 接続する前に拒否される
+	_, err := NewHTTPSender(time.Second, false).Send(context.Background(), srv.URL, nil, []byte(`{}`))
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("Send() error = %v, want %v", err, ErrPrivateAddress)
+	}
+	if called {
+		t.Error("request reached the private address")
+	}
+}
+
+func TestHTTPSenderSend(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s := NewHTTPSender(time.Second, true)
+	status, err := s.Send(context.Background(), srv.URL, map[string]string{"X-Blog-Event": "entry.published"}, []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", status, http.StatusNoContent)
+	}
+	if got.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", got.Method)
+	}
+	for k, want := range map[string]string{
+		"Content-Type": "application/json",
+		"User-Agent":   userAgent,
+		"X-Blog-Event": "entry.published",
+	} {
+		if v := got.Header.Get(k); v != want {
+			t.Errorf("header %s = %q, want %q", k, v, want)
+		}
+	}
+
+	// リダイレクトは辿らずに, そのレスポンスを返す
+	status, err = s.Send(context.Background(), srv.URL+"/redirect", nil, []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusFound {
+		t.Errorf("status = %d, want %d", status, http.StatusFound)
+	}
+	if got.URL.Path != "/redirect" {
+		t.Errorf("redirect was followed to %s", got.URL.Path)
+	}
+}
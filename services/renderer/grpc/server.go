@@ -2,6 +2,10 @@ package grpc
 
 import (
 	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/renderer/pb/renderer"
 	"github.com/mackerelio-labs/mackerel-demo-gocon-2025/services/renderer/renderer"
@@ -19,8 +23,21 @@ func NewServer() *Server {
 
 // Render は受け取った文書を HTML に変換する
 func (s *Server) Render(ctx context.Context, in *pb.RenderRequest) (*pb.RenderReply, error) {
-	html, err := renderer.Render(ctx, in.Src)
+	var html string
+	var err error
+	if in.Restricted {
+		html, err = renderer.RenderRestricted(ctx, in.Src)
+	} else {
+		images := make(map[string]renderer.Image, len(in.Images))
+		for _, image := range in.Images {
+			images[image.Src] = renderer.Image{Width: image.Width, Height: image.Height}
+		}
+		html, err = renderer.Render(ctx, in.Src, images)
+	}
 	if err != nil {
+		if errors.Is(err, renderer.ErrUnsupportedImage) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, err
 	}
 	return &pb.RenderReply{Html: html}, nil
@@ -10,7 +10,9 @@ import (
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
@@ -36,20 +38,110 @@ func (r customHeadingRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegist
 	})
 }
 
+// Image は文書から参照してよい画像の大きさを表す
+type Image struct {
+	Width  uint32
+	Height uint32
+}
+
+// ErrUnsupportedImage は文書が参照してよい画像以外の画像を参照しているときに返される
+var ErrUnsupportedImage = errors.New("image nodes must point at uploaded media")
+
 // customImageRenderer は画像ノードを処理するカスタムレンダラー
-type customImageRenderer struct{}
+// images に含まれる画像だけを, レイアウトがずれないよう width と height を付けてレンダリングする
+type customImageRenderer struct {
+	images map[string]Image
+}
 
 func (r customImageRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
 	reg.Register(ast.KindImage, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		// FIXME: 画像ノードはサポートしない
-		return ast.WalkStop, errors.New("image nodes are not supported")
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		image := node.(*ast.Image)
+		size, ok := r.images[string(image.Destination)]
+		if !ok {
+			return ast.WalkStop, ErrUnsupportedImage
+		}
+		_, _ = w.WriteString("<img src=\"")
+		_, _ = w.Write(util.EscapeHTML(util.URLEscape(image.Destination, true)))
+		_, _ = w.WriteString("\" alt=\"")
+		_, _ = w.Write(util.EscapeHTML(altText(image, source)))
+		_, _ = w.WriteString("\"")
+		if image.Title != nil {
+			_, _ = w.WriteString(" title=\"")
+			_, _ = w.Write(util.EscapeHTML(image.Title))
+			_, _ = w.WriteString("\"")
+		}
+		fmt.Fprintf(w, " width=\"%d\" height=\"%d\" loading=\"lazy\">", size.Width, size.Height)
+		// 代替テキストは alt 属性に出力したので子ノードは処理しない
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// altText は画像ノードの子ノードのテキストを連結して代替テキストにする
+func altText(node ast.Node, source []byte) []byte {
+	var buf bytes.Buffer
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			buf.Write(n.Segment.Value(source))
+		case *ast.String:
+			buf.Write(n.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf.Bytes()
+}
+
+// restrictedHeadingRenderer は制限されたレンダリングで見出しノードを段落として処理するレンダラー
+type restrictedHeadingRenderer struct{}
+
+func (r restrictedHeadingRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHeading, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString("<p>")
+		} else {
+			_, _ = w.WriteString("</p>\n")
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// restrictedImageRenderer は制限されたレンダリングで画像ノードを代替テキストとして処理するレンダラー
+type restrictedImageRenderer struct{}
+
+func (r restrictedImageRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindImage, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		// 子ノードの代替テキストだけを出力する
+		return ast.WalkContinue, nil
+	})
+}
+
+// nofollowLinkTransformer はリンクに rel="nofollow ugc" を付ける
+type nofollowLinkTransformer struct{}
+
+func (t nofollowLinkTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindLink, ast.KindAutoLink:
+			n.SetAttributeString("rel", []byte("nofollow ugc"))
+		}
+		return ast.WalkContinue, nil
 	})
 }
 
 var tracer = otel.Tracer("renderer")
 
 // Render は受け取った文書を HTML に変換する
-func Render(ctx context.Context, src string) (string, error) {
+// 画像は images に含まれるものだけを参照でき, それ以外の画像を参照している場合は ErrUnsupportedImage を返す
+func Render(ctx context.Context, src string, images map[string]Image) (string, error) {
 	_, span := tracer.Start(ctx, "renderer.Render")
 	defer span.End()
 
@@ -58,7 +150,38 @@ func Render(ctx context.Context, src string) (string, error) {
 		goldmark.WithRendererOptions(
 			renderer.WithNodeRenderers(
 				util.Prioritized(&customHeadingRenderer{}, 100),
-				util.Prioritized(&customImageRenderer{}, 100),
+				util.Prioritized(&customImageRenderer{images}, 100),
+			),
+		),
+	)
+
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(src), &buf); err != nil {
+		span.SetStatus(codes.Error, "failed to convert markdown to HTML")
+		span.RecordError(err)
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// RenderRestricted は読者が書いたコメントなど, 信頼できない文書を機能を制限して HTML に変換する
+// 見出しは段落に, 画像は代替テキストに置き換え, リンクには rel="nofollow ugc" を付ける
+func RenderRestricted(ctx context.Context, src string) (string, error) {
+	_, span := tracer.Start(ctx, "renderer.RenderRestricted")
+	defer span.End()
+
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.Strikethrough, extension.Linkify),
+		goldmark.WithParserOptions(
+			parser.WithASTTransformers(
+				util.Prioritized(&nofollowLinkTransformer{}, 100),
+			),
+		),
+		goldmark.WithRendererOptions(
+			renderer.WithNodeRenderers(
+				util.Prioritized(&restrictedHeadingRenderer{}, 100),
+				util.Prioritized(&restrictedImageRenderer{}, 100),
 			),
 		),
 	)